@@ -0,0 +1,179 @@
+package codeowners
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	managedRegionBegin = "# BEGIN GENERATED RULES, DO NOT EDIT! (https://github.com/gmolau/codeowners)"
+	managedRegionEnd   = "# END GENERATED RULES"
+)
+
+// AppendConflict describes a manually maintained rule and a generated rule
+// that target the same pattern.
+type AppendConflict struct {
+	Pattern       string
+	ManualRule    string
+	ManualLine    int
+	GeneratedRule string
+}
+
+func (c AppendConflict) String() string {
+	return fmt.Sprintf("pattern %s is owned by manual rule %q (line %d) and generated rule %q",
+		c.Pattern, c.ManualRule, c.ManualLine, c.GeneratedRule)
+}
+
+// AppendCodeownersRules merges rules into the managed region of an existing CO
+// file and returns the merged file. Everything outside the managed region is
+// left untouched, if there is no managed region yet it is appended to the end.
+// Manual rules that target the same pattern as a generated rule are reported
+// as conflicts.
+func AppendCodeownersRules(existing string, rules []Rule) (string, []AppendConflict, error) {
+	lines := strings.Split(existing, "\n")
+
+	begin, end := -1, -1
+	for i, line := range lines {
+		switch strings.TrimSpace(line) {
+		case managedRegionBegin:
+			if begin != -1 {
+				return "", nil, fmt.Errorf("duplicate managed region begin marker in line %d", i+1)
+			}
+			begin = i
+		case managedRegionEnd:
+			if begin == -1 || end != -1 {
+				return "", nil, fmt.Errorf("unexpected managed region end marker in line %d", i+1)
+			}
+			end = i
+		}
+	}
+
+	if begin != -1 && end == -1 {
+		return "", nil, fmt.Errorf("managed region starting in line %d is not terminated", begin+1)
+	}
+
+	var before, after []string
+	if begin == -1 {
+		before = trimTrailingEmptyLines(lines)
+		if len(before) > 0 {
+			before = append(before, "")
+		}
+	} else {
+		before = lines[:begin]
+		after = lines[end+1:]
+	}
+
+	region := append([]string{managedRegionBegin}, ruleStrings(rules)...)
+	region = append(region, managedRegionEnd)
+
+	merged := append(append(append([]string{}, before...), region...), after...)
+	if begin == -1 {
+		merged = append(merged, "")
+	}
+
+	conflicts := findAppendConflicts(before, after, begin, end, rules)
+
+	return strings.Join(merged, "\n"), conflicts, nil
+}
+
+// findAppendConflicts compares the patterns of the manual rules before and
+// after the managed region with the patterns of the generated rules.
+func findAppendConflicts(before, after []string, begin, end int, rules []Rule) []AppendConflict {
+	generated := map[string]string{}
+	for _, rule := range rules {
+		generated[rule.Pattern] = rule.String()
+	}
+
+	var conflicts []AppendConflict
+	check := func(lines []string, offset int) {
+		for i, line := range lines {
+			if !isCodeownersRule(line) {
+				continue
+			}
+
+			pattern := rulePattern(line)
+			if rule, ok := generated[pattern]; ok {
+				conflicts = append(conflicts, AppendConflict{
+					Pattern:       pattern,
+					ManualRule:    strings.TrimSpace(line),
+					ManualLine:    offset + i + 1,
+					GeneratedRule: rule,
+				})
+			}
+		}
+	}
+
+	check(before, 0)
+	if end != -1 {
+		check(after, end+1)
+	}
+
+	return conflicts
+}
+
+// rulePattern returns the pattern of a rule in the root CO file, i.e. its
+// first token.
+func rulePattern(rule string) string {
+	return strings.Fields(rule)[0]
+}
+
+func trimTrailingEmptyLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// WriteIfChanged writes content to path unless the file already has exactly
+// that content.
+func WriteIfChanged(path, content string) error {
+	existing, err := os.ReadFile(path)
+	if err == nil && string(existing) == content {
+		return nil
+	}
+
+	return WriteFileAtomic(path, content)
+}
+
+// WriteFileAtomic writes content to path by writing to a temporary file in the
+// same dir first and renaming it afterwards, so that readers never observe a
+// partially written file. Missing parent dirs are created.
+func WriteFileAtomic(path, content string) error {
+	dir := filepath.Dir(path)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return fmt.Errorf("can't create dir %s: %w", dir, err)
+	}
+
+	tmpFile, err := os.CreateTemp(dir, ".codeowners-*")
+	if err != nil {
+		return fmt.Errorf("can't create temporary file in %s: %w", dir, err)
+	}
+	defer os.Remove(tmpFile.Name()) // No-op after a successful rename
+
+	_, err = tmpFile.WriteString(content)
+	if err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("can't write temporary file %s: %w", tmpFile.Name(), err)
+	}
+
+	err = tmpFile.Close()
+	if err != nil {
+		return fmt.Errorf("can't close temporary file %s: %w", tmpFile.Name(), err)
+	}
+
+	err = os.Chmod(tmpFile.Name(), 0644)
+	if err != nil {
+		return fmt.Errorf("can't set permissions of temporary file %s: %w", tmpFile.Name(), err)
+	}
+
+	err = os.Rename(tmpFile.Name(), path)
+	if err != nil {
+		return fmt.Errorf("can't move temporary file to %s: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendCodeownersRules(t *testing.T) {
+	rules := []Rule{
+		{Pattern: "/src/dir1", Owners: []string{"@org/user"}},
+		{Pattern: "/src/dir2/main.go", Owners: []string{"@org/gopher"}},
+	}
+
+	// A file without managed region gets it appended
+	existing := `# Manual rules
+* @org/admin
+/src/dir1 @org/legacy
+
+`
+	expected := `# Manual rules
+* @org/admin
+/src/dir1 @org/legacy
+
+` + managedRegionBegin + `
+/src/dir1 @org/user
+/src/dir2/main.go @org/gopher
+` + managedRegionEnd + "\n"
+
+	merged, conflicts, err := AppendCodeownersRules(existing, rules)
+	require.NoError(t, err)
+	require.Equal(t, expected, merged)
+	require.Equal(t, []AppendConflict{{
+		Pattern:       "/src/dir1",
+		ManualRule:    "/src/dir1 @org/legacy",
+		ManualLine:    3,
+		GeneratedRule: "/src/dir1 @org/user",
+	}}, conflicts)
+
+	// An existing managed region is replaced, everything around it is kept
+	existing = `* @org/admin
+` + managedRegionBegin + `
+/old @org/old
+` + managedRegionEnd + `
+/docs @org/docs
+`
+	expected = `* @org/admin
+` + managedRegionBegin + `
+/src/dir1 @org/user
+/src/dir2/main.go @org/gopher
+` + managedRegionEnd + `
+/docs @org/docs
+`
+
+	merged, conflicts, err = AppendCodeownersRules(existing, rules)
+	require.NoError(t, err)
+	require.Equal(t, expected, merged)
+	require.Empty(t, conflicts)
+
+	// An unterminated managed region is an error
+	_, _, err = AppendCodeownersRules(managedRegionBegin+"\n/old @org/old\n", rules)
+	require.Error(t, err)
+}
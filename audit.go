@@ -0,0 +1,125 @@
+package codeowners
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// AuditReport is the consolidated result of all checks run by Audit.
+type AuditReport struct {
+	Findings []Finding `json:"findings"`
+	Coverage Coverage  `json:"coverage"`
+	Stats    Stats     `json:"stats"`
+
+	// Labels counts the rules per label.
+	Labels map[string]int `json:"labels,omitempty"`
+
+	// Suppressions are the suppression comments of the CO files, their
+	// findings are dropped from Findings.
+	Suppressions []Suppression `json:"suppressions,omitempty"`
+}
+
+// Failed checks whether the audit found any errors.
+func (r AuditReport) Failed() bool {
+	return HasErrors(r.Findings)
+}
+
+// Audit runs the syntax lint, the policy checks including the never-owned
+// paths, the coverage computation without generated files, the stale rule
+// detection, the conflict and duplicate pattern detection between CO files,
+// the case collision detection, the expiry check and the search for ignored
+// CO files on the repo in root and consolidates their results. The severities
+// of the config are applied and suppressed findings are dropped.
+func Audit(ctx context.Context, root string, cfg Config) (AuditReport, error) {
+	var report AuditReport
+
+	lintFindings, err := LintCodeownersFiles(ctx, root, cfg.Lint)
+	if err != nil {
+		return report, err
+	}
+
+	rules, err := RewriteCodeownersRules(ctx, root, Options{})
+	if err != nil {
+		return report, fmt.Errorf("error while rewriting codeowner rules: %w", err)
+	}
+
+	files, err := ListFiles(ctx, root)
+	if err != nil {
+		return report, err
+	}
+
+	ignored, err := FindIgnoredCodeownersFiles(ctx, root)
+	if err != nil {
+		return report, err
+	}
+
+	report.Stats = computeStats(rules)
+	report.Labels = countLabels(rules)
+	report.Coverage = ComputeCoverage(rules, ExcludeNeverOwned(ExcludeGenerated(files, NewGeneratedPaths(root)), cfg.Policy.NeverOwned))
+
+	report.Findings = append(report.Findings, lintFindings...)
+	report.Findings = append(report.Findings, ignored...)
+	report.Findings = append(report.Findings, CheckPolicies(rules, cfg.Policy)...)
+	report.Findings = append(report.Findings, CheckRequiredCodeowners(files, cfg.Policy)...)
+	report.Findings = append(report.Findings, CheckNeverOwned(rules, cfg.Policy.NeverOwned)...)
+	report.Findings = append(report.Findings, FindStaleRules(rules, files)...)
+	report.Findings = append(report.Findings, FindConflicts(rules, files)...)
+	report.Findings = append(report.Findings, FindShadowedRules(rules, files)...)
+	report.Findings = append(report.Findings, FindDuplicatePatterns(rules)...)
+	report.Findings = append(report.Findings, FindCaseCollisions(files)...)
+	report.Findings = append(report.Findings, CheckExpiry(rules, time.Now(), cfg.Lint.EffectiveExpiryWarningDays())...)
+
+	if percent := report.Coverage.Percent(); percent < cfg.Policy.MinCoverage {
+		report.Findings = append(report.Findings, Finding{
+			Check:    "min-coverage",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("%.1f%% of files are owned, at least %.1f%% are required", percent, cfg.Policy.MinCoverage),
+		})
+	}
+
+	suppressions, invalid, err := FindSuppressions(ctx, root)
+	if err != nil {
+		return report, err
+	}
+	report.Findings = ApplySeverities(append(report.Findings, invalid...), cfg.Severities, cfg.Strict)
+	report.Findings = ApplySuppressions(report.Findings, suppressions)
+	report.Suppressions = suppressions
+
+	SortFindings(report.Findings)
+	if report.Findings == nil {
+		report.Findings = []Finding{}
+	}
+
+	return report, nil
+}
+
+// RestrictToOwners limits the findings, stats and labels of the report to the
+// rules involving the owners of the filter, rules are all rules of the repo.
+// Findings not located at such a rule are dropped. The coverage stays the one
+// of the whole repo.
+func (r *AuditReport) RestrictToOwners(filter OwnerFilter, rules []Rule) {
+	if len(filter) == 0 {
+		return
+	}
+
+	owned := filter.Rules(rules)
+	r.Findings = filter.Findings(r.Findings, rules)
+	r.Stats = computeStats(owned)
+	r.Labels = countLabels(owned)
+}
+
+// countLabels counts the rules per label, nil if no rule has labels.
+func countLabels(rules []Rule) map[string]int {
+	var counts map[string]int
+	for _, rule := range rules {
+		for _, label := range rule.Labels {
+			if counts == nil {
+				counts = map[string]int{}
+			}
+			counts[label]++
+		}
+	}
+
+	return counts
+}
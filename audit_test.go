@@ -0,0 +1,146 @@
+package codeowners
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudit(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "CODEOWNERS", "*.md @org/docs\n")
+	writeFile(t, repoPath, "README.md", "")
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/user @not_valid\nmain.go\nlib.go @someone\n")
+	writeFile(t, repoPath, "src/main.go", "")
+	writeFile(t, repoPath, "other/file.txt", "")
+
+	cfg := Config{Policy: PolicyConfig{RequireTeams: true, MinCoverage: 90}}
+
+	report, err := Audit(context.Background(), repoPath, cfg)
+	require.NoError(t, err)
+	require.True(t, report.Failed())
+
+	require.Equal(t, Coverage{Files: 5, Owned: 3, Unowned: []string{"CODEOWNERS", "other/file.txt"}}, report.Coverage)
+	require.Equal(t, Stats{Rules: 3, SourceFiles: 2, Owners: 4}, report.Stats)
+
+	expected := []string{
+		"error: 60.0% of files are owned, at least 90.0% are required [CO008 min-coverage]",
+		"src/CODEOWNERS:1:11: error: @not_valid is not a valid user, team or email address [CO003 invalid-owner]",
+		"src/CODEOWNERS:1: error: owner @not_valid of /src is not a team [CO006 require-teams]",
+		"src/CODEOWNERS:2:1: error: rule for main.go has no owners and is dropped [CO002 missing-owners]",
+		"src/CODEOWNERS:3: error: owner @someone of /src/lib.go is not a team [CO006 require-teams]",
+		"src/CODEOWNERS:3: warning: pattern /src/lib.go doesn't match any file [CO001 stale-pattern]",
+	}
+
+	var findings []string
+	for _, finding := range report.Findings {
+		findings = append(findings, finding.String())
+	}
+	require.Equal(t, expected, findings)
+}
+
+func TestCheckExpiry(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admins\n")
+	writeFile(t, repoPath, "legacy/CODEOWNERS", "# codeowners: expires=2025-12-31\n@org/old\nnew.go @org/new # codeowners: expires=2026-02-15\n")
+
+	rules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.NoError(t, err)
+
+	now := time.Date(2026, 1, 20, 15, 0, 0, 0, time.UTC)
+	require.Equal(t, []Finding{
+		{Check: "expired-rule", Severity: SeverityError, Message: "ownership of /legacy expired on 2025-12-31", File: "legacy/CODEOWNERS", Line: 2},
+		{Check: "expiring-rule", Severity: SeverityWarning, Message: "ownership of /legacy/new.go expires on 2026-02-15 (in 26 days)", File: "legacy/CODEOWNERS", Line: 3},
+	}, CheckExpiry(rules, now, 30))
+
+	// The expiry date itself is still valid
+	findings := CheckExpiry(rules[:2], time.Date(2025, 12, 31, 23, 0, 0, 0, time.UTC), 0)
+	require.Len(t, findings, 1)
+	require.Equal(t, "expiring-rule", findings[0].Check)
+
+	writeFile(t, repoPath, "legacy/CODEOWNERS", "@org/old # codeowners: expires=soon\n")
+	_, err = RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.EqualError(t, err, `error while processing CODEOWNERS files: legacy/CODEOWNERS:1:24: invalid expiry date "soon", expected YYYY-MM-DD`)
+}
+
+func TestLabelPolicies(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admins @org/ops\n")
+	writeFile(t, repoPath, "new/CODEOWNERS", "# codeowners: label=provisional\n@org/guess\nlib.go @org/lib @org/ops # codeowners: label=migration, provisional\n")
+	writeFile(t, repoPath, "new/lib.go", "")
+
+	one := 1
+	cfg := Config{Policy: PolicyConfig{MinOwners: 2, Labels: map[string]LabelPolicy{"provisional": {MinOwners: &one}}}}
+
+	report, err := Audit(context.Background(), repoPath, cfg)
+	require.NoError(t, err)
+	require.False(t, report.Failed())
+	require.Equal(t, map[string]int{"provisional": 2, "migration": 1}, report.Labels)
+
+	rules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.NoError(t, err)
+	require.Nil(t, rules[0].Labels)
+	require.Equal(t, []string{"provisional"}, rules[1].Labels)
+	require.Equal(t, []string{"provisional", "migration"}, rules[2].Labels)
+
+	// Without the override the provisional rule violates the policy
+	findings := CheckPolicies(rules, PolicyConfig{MinOwners: 2})
+	require.Len(t, findings, 1)
+	require.Equal(t, "new/CODEOWNERS", findings[0].File)
+}
+
+func TestCheckRequiredCodeowners(t *testing.T) {
+	files := []string{
+		"CODEOWNERS",
+		".github/workflows/ci.yml",
+		"docs/index.md",
+		"services/a/CODEOWNERS",
+		"services/a/main.go",
+		"services/b/main.go",
+		"services/c/sub/CODEOWNERS",
+		"tools/lint.sh",
+	}
+
+	var dirs []string
+	for _, finding := range CheckRequiredCodeowners(files, PolicyConfig{RequireCodeowners: true}) {
+		dirs = append(dirs, finding.File)
+	}
+	require.Equal(t, []string{"docs", "tools"}, dirs)
+
+	dirs = nil
+	for _, finding := range CheckRequiredCodeowners(files, PolicyConfig{RequireCodeownersDirs: []string{"services/*", ".github"}}) {
+		dirs = append(dirs, finding.File)
+	}
+	require.Equal(t, []string{".github", "services/b"}, dirs)
+
+	require.Empty(t, CheckRequiredCodeowners(files, PolicyConfig{}))
+}
+
+func TestFindIgnoredCodeownersFiles(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, ".gitignore", "*.log\nbuild/\n")
+	writeFile(t, repoPath, "build/svc/CODEOWNERS", "* @org/build\n")
+	writeFile(t, repoPath, "src/CODEOWNERS", "* @org/src\n")
+	writeFile(t, repoPath, "src/.gitignore", "gen\n")
+	writeFile(t, repoPath, "src/gen/CODEOWNERS", "* @org/gen\n")
+
+	findings, err := FindIgnoredCodeownersFiles(context.Background(), repoPath)
+	require.NoError(t, err)
+
+	expected := []string{
+		`build/svc/CODEOWNERS: warning: CODEOWNERS file is skipped because build is ignored by "build/" in .gitignore:2 [CO012 ignored-codeowners]`,
+		`src/gen/CODEOWNERS: warning: CODEOWNERS file is skipped because src/gen is ignored by "gen" in src/.gitignore:1 [CO012 ignored-codeowners]`,
+	}
+
+	var messages []string
+	for _, finding := range findings {
+		messages = append(messages, finding.String())
+	}
+	require.Equal(t, expected, messages)
+}
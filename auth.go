@@ -0,0 +1,146 @@
+package codeowners
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ServerTokenEnv is the environment variable with the bearer token of the
+// server, see LoadServerTokens.
+const ServerTokenEnv = "CODEOWNERS_SERVER_TOKEN"
+
+// WebhookSecretEnv is the environment variable with the secret of the
+// webhook, see LoadWebhookSecret.
+const WebhookSecretEnv = "CODEOWNERS_WEBHOOK_SECRET"
+
+// LoadWebhookSecret returns the webhook secret from file, if it isn't empty,
+// or from $CODEOWNERS_WEBHOOK_SECRET. Empty if neither is set.
+func LoadWebhookSecret(file string) (string, error) {
+	if file == "" {
+		return strings.TrimSpace(os.Getenv(WebhookSecretEnv)), nil
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("can't read webhook secret file: %w", err)
+	}
+
+	secret := strings.TrimSpace(string(content))
+	if secret == "" {
+		return "", fmt.Errorf("webhook secret file %s is empty", file)
+	}
+
+	return secret, nil
+}
+
+// unauthenticatedPaths are the endpoints that don't require a token or client
+// certificate since probes usually can't send one. The webhook is
+// authenticated by its signature instead.
+var unauthenticatedPaths = []string{"/healthz", "/readyz", "/-/webhook"}
+
+// LoadServerTokens returns the accepted bearer tokens: the token in
+// $CODEOWNERS_SERVER_TOKEN and the tokens in file, one per line, if file
+// isn't empty. Several tokens allow rotating them without downtime. Empty
+// lines and lines starting with "#" are skipped.
+func LoadServerTokens(file string) ([]string, error) {
+	var tokens []string
+	if token := strings.TrimSpace(os.Getenv(ServerTokenEnv)); token != "" {
+		tokens = append(tokens, token)
+	}
+
+	if file == "" {
+		return tokens, nil
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("can't read token file: %w", err)
+	}
+
+	found := false
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			tokens = append(tokens, line)
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("token file %s contains no tokens", file)
+	}
+
+	return tokens, nil
+}
+
+// RequireBearerToken rejects the requests to next that don't carry one of the
+// tokens in their Authorization header, except for the probes.
+func RequireBearerToken(tokens []string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ContainsString(unauthenticatedPaths, r.URL.Path) || validBearerToken(tokens, r.Header.Get("Authorization")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("WWW-Authenticate", `Bearer realm="codeowners"`)
+		writeJSONError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+	})
+}
+
+// validBearerToken checks whether the Authorization header carries one of the
+// tokens, in constant time per token.
+func validBearerToken(tokens []string, header string) bool {
+	const prefix = "Bearer "
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return false
+	}
+	token := strings.TrimSpace(header[len(prefix):])
+
+	valid := false
+	for _, t := range tokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			valid = true
+		}
+	}
+
+	return valid
+}
+
+// RequireClientCert rejects the requests to next without a client certificate
+// verified by the TLS config of ClientCATLSConfig, except for the probes.
+func RequireClientCert(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ContainsString(unauthenticatedPaths, r.URL.Path) || (r.TLS != nil && len(r.TLS.VerifiedChains) > 0) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		writeJSONError(w, http.StatusUnauthorized, "missing client certificate")
+	})
+}
+
+// ClientCATLSConfig returns a TLS config that verifies client certificates
+// against the CAs in the PEM file caFile (mTLS). Clients without certificate
+// can still connect for the probes, RequireClientCert rejects their other
+// requests.
+func ClientCATLSConfig(caFile string) (*tls.Config, error) {
+	content, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("can't read client CA file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(content) {
+		return nil, fmt.Errorf("client CA file %s contains no PEM certificates", caFile)
+	}
+
+	return &tls.Config{
+		ClientCAs:  pool,
+		ClientAuth: tls.VerifyClientCertIfGiven,
+		MinVersion: tls.VersionTLS12,
+	}, nil
+}
@@ -0,0 +1,63 @@
+package codeowners
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireBearerToken(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "tokens")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("# Rotated on 2026-01-01\nold-token\n\nnew-token\n"), 0600))
+	require.NoError(t, os.Setenv(ServerTokenEnv, "env-token"))
+	defer os.Unsetenv(ServerTokenEnv)
+
+	tokens, err := LoadServerTokens(tokenFile)
+	require.NoError(t, err)
+	require.Equal(t, []string{"env-token", "old-token", "new-token"}, tokens)
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	handler := RequireBearerToken(tokens, ok)
+	request := func(target, authorization string) int {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		if authorization != "" {
+			r.Header.Set("Authorization", authorization)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, r)
+		return recorder.Code
+	}
+
+	require.Equal(t, http.StatusOK, request("/owners?path=a", "Bearer new-token"))
+	require.Equal(t, http.StatusOK, request("/owners?path=a", "bearer env-token"))
+	require.Equal(t, http.StatusUnauthorized, request("/owners?path=a", "Bearer wrong"))
+	require.Equal(t, http.StatusUnauthorized, request("/-/reload", ""))
+	require.Equal(t, http.StatusOK, request("/readyz", ""))
+
+	require.NoError(t, os.WriteFile(tokenFile, []byte("# No tokens\n"), 0600))
+	_, err = LoadServerTokens(tokenFile)
+	require.Error(t, err)
+}
+
+func TestRequireClientCert(t *testing.T) {
+	handler := RequireClientCert(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	request := func(target string, state *tls.ConnectionState) int {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		r.TLS = state
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, r)
+		return recorder.Code
+	}
+
+	verified := &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{}}}}
+	require.Equal(t, http.StatusOK, request("/owners?path=a", verified))
+	require.Equal(t, http.StatusUnauthorized, request("/owners?path=a", &tls.ConnectionState{}))
+	require.Equal(t, http.StatusUnauthorized, request("/owners?path=a", nil))
+	require.Equal(t, http.StatusOK, request("/healthz", nil))
+}
@@ -0,0 +1,202 @@
+package codeowners
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// azureAutoReviewersPolicyType is the id of the Azure DevOps "Automatically
+// included reviewers" branch policy type.
+const azureAutoReviewersPolicyType = "fd2167ab-b0be-447a-8ec8-39368250530e"
+
+// AzurePolicy is an Azure DevOps "Automatically included reviewers" branch
+// policy in the format of the policy configurations REST API.
+type AzurePolicy struct {
+	IsEnabled  bool                `json:"isEnabled"`
+	IsBlocking bool                `json:"isBlocking"`
+	Type       AzurePolicyType     `json:"type"`
+	Settings   AzurePolicySettings `json:"settings"`
+}
+
+type AzurePolicyType struct {
+	ID string `json:"id"`
+}
+
+type AzurePolicySettings struct {
+	RequiredReviewerIDs  []string           `json:"requiredReviewerIds"`
+	MinimumApproverCount int                `json:"minimumApproverCount"`
+	CreatorVoteCounts    bool               `json:"creatorVoteCounts"`
+	FilenamePatterns     []string           `json:"filenamePatterns"`
+	Message              string             `json:"message"`
+	Scope                []AzurePolicyScope `json:"scope"`
+}
+
+type AzurePolicyScope struct {
+	RepositoryID string `json:"repositoryId,omitempty"`
+	RefName      string `json:"refName"`
+	MatchKind    string `json:"matchKind"`
+}
+
+// AzureOptions configures the conversion of rules to Azure DevOps policies.
+type AzureOptions struct {
+	// RepositoryID and RefName scope the policies, e.g. to refs/heads/main.
+	RepositoryID string
+	RefName      string
+
+	// Identities maps owners to the ids of the corresponding Azure DevOps
+	// identities. Owners without identity are used as id as is.
+	Identities map[string]string
+
+	// Optional policies add the reviewers without requiring their approval.
+	Optional bool
+}
+
+// AzurePolicies converts the rules to one "Automatically included reviewers"
+// policy per distinct set of owners. Azure DevOps applies every matching
+// policy, so paths overridden by a later rule with other owners are excluded
+// from the policy of the earlier rule to keep GitHub's last-match-wins
+// semantics.
+func AzurePolicies(rules []Rule, opts AzureOptions) []AzurePolicy {
+	var policies []AzurePolicy
+	byOwners := map[string]int{}
+
+	for i, rule := range rules {
+		key := strings.Join(rule.Owners, " ")
+		index, ok := byOwners[key]
+		if !ok {
+			index = len(policies)
+			byOwners[key] = index
+			policies = append(policies, newAzurePolicy(rule.Owners, opts))
+		}
+
+		settings := &policies[index].Settings
+		settings.FilenamePatterns = append(settings.FilenamePatterns, azurePathFilters(rule.Pattern)...)
+
+		prefix := azurePathPrefix(rule.Pattern)
+		for _, later := range rules[i+1:] {
+			if strings.Join(later.Owners, " ") == key {
+				continue
+			}
+
+			for _, filter := range azurePathFilters(later.Pattern) {
+				if strings.HasPrefix(filter, prefix) && !ContainsString(settings.FilenamePatterns, "!"+filter) {
+					settings.FilenamePatterns = append(settings.FilenamePatterns, "!"+filter)
+				}
+			}
+		}
+	}
+
+	return policies
+}
+
+// newAzurePolicy creates an empty policy for the owners.
+func newAzurePolicy(owners []string, opts AzureOptions) AzurePolicy {
+	ids := make([]string, len(owners))
+	for i, owner := range owners {
+		ids[i] = owner
+		if id, ok := opts.Identities[owner]; ok {
+			ids[i] = id
+		}
+	}
+
+	return AzurePolicy{
+		IsEnabled:  true,
+		IsBlocking: !opts.Optional,
+		Type:       AzurePolicyType{ID: azureAutoReviewersPolicyType},
+		Settings: AzurePolicySettings{
+			RequiredReviewerIDs:  ids,
+			MinimumApproverCount: 1,
+			FilenamePatterns:     []string{},
+			Message:              fmt.Sprintf("Code owners: %s", strings.Join(owners, " ")),
+			Scope: []AzurePolicyScope{{
+				RepositoryID: opts.RepositoryID,
+				RefName:      opts.RefName,
+				MatchKind:    "Exact",
+			}},
+		},
+	}
+}
+
+// azurePathFilters converts a CODEOWNERS pattern to Azure DevOps path
+// filters. A "*" in a filter matches across dirs, so unanchored patterns are
+// prefixed with "*/" and "**" becomes "*".
+func azurePathFilters(p string) []string {
+	compiled := compilePattern(p)
+	if len(compiled.segments) == 0 {
+		return nil
+	}
+
+	path := strings.Join(compiled.segments, "/")
+	path = strings.ReplaceAll(path, "**", "*")
+	if compiled.anchored {
+		path = "/" + path
+	} else {
+		path = "*/" + path
+	}
+
+	matchesNested := compiled.segments[len(compiled.segments)-1] != "*"
+	switch {
+	case compiled.dirOnly:
+		return []string{path + "/*"}
+	case matchesNested:
+		return []string{path, path + "/*"}
+	}
+
+	return []string{path}
+}
+
+// azurePathPrefix returns the literal prefix of the path filters of a pattern
+// up to the first wildcard. Filters of later rules with that prefix may
+// override the pattern.
+func azurePathPrefix(p string) string {
+	filters := azurePathFilters(p)
+	if len(filters) == 0 {
+		return ""
+	}
+
+	filter := filters[0]
+	if i := strings.IndexAny(filter, "*?"); i >= 0 {
+		return filter[:i]
+	}
+
+	return filter
+}
+
+var azureTerraformTemplate = template.Must(template.New("terraform").Funcs(template.FuncMap{
+	"quote": func(values []string) string {
+		quoted := make([]string, len(values))
+		for i, value := range values {
+			quoted[i] = fmt.Sprintf("%q", value)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	},
+}).Parse(`{{ range $i, $p := . }}{{ if $i }}
+{{ end }}resource "azuredevops_branch_policy_auto_reviewers" "codeowners_{{ $i }}" {
+  project_id = var.project_id
+  enabled    = {{ $p.IsEnabled }}
+  blocking   = {{ $p.IsBlocking }}
+
+  settings {
+    auto_reviewer_ids           = {{ quote $p.Settings.RequiredReviewerIDs }}
+    minimum_number_of_reviewers = {{ $p.Settings.MinimumApproverCount }}
+    submitter_can_vote          = {{ $p.Settings.CreatorVoteCounts }}
+    message                     = {{ printf "%q" $p.Settings.Message }}
+    path_filters                = {{ quote $p.Settings.FilenamePatterns }}
+{{ range $p.Settings.Scope }}
+    scope {
+      repository_id  = {{ if .RepositoryID }}{{ printf "%q" .RepositoryID }}{{ else }}var.repository_id{{ end }}
+      repository_ref = {{ printf "%q" .RefName }}
+      match_type     = {{ printf "%q" .MatchKind }}
+    }
+{{ end }}  }
+}
+{{ end }}`))
+
+// WriteAzureTerraform writes the policies as azuredevops_branch_policy_auto_reviewers
+// resources of the Terraform Azure DevOps provider. The project and, unless
+// set, the repository are referenced as variables.
+func WriteAzureTerraform(w io.Writer, policies []AzurePolicy) error {
+	return azureTerraformTemplate.Execute(w, policies)
+}
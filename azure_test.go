@@ -0,0 +1,41 @@
+package codeowners
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzurePolicies(t *testing.T) {
+	rules := []Rule{
+		{Pattern: "*", Owners: []string{"@org/admins"}},
+		{Pattern: "/src", Owners: []string{"@org/dev"}},
+		{Pattern: "/src/*.md", Owners: []string{"@org/docs"}},
+		{Pattern: "/docs/", Owners: []string{"@org/docs"}},
+		{Pattern: "/src/lib", Owners: []string{"@org/dev"}},
+	}
+
+	policies := AzurePolicies(rules, AzureOptions{
+		RefName:    "refs/heads/main",
+		Identities: map[string]string{"@org/dev": "7b5dd6f0-0000-0000-0000-000000000000"},
+		Optional:   true,
+	})
+	require.Len(t, policies, 3)
+
+	require.Equal(t, []string{"*/*", "!/src", "!/src/*", "!/src/*.md", "!/src/*.md/*", "!/docs/*", "!/src/lib", "!/src/lib/*"}, policies[0].Settings.FilenamePatterns)
+	require.Equal(t, []string{"@org/admins"}, policies[0].Settings.RequiredReviewerIDs)
+
+	// Rules of the same owners are merged, /src/lib doesn't exclude /src
+	require.Equal(t, []string{"/src", "/src/*", "!/src/*.md", "!/src/*.md/*", "/src/lib", "/src/lib/*"}, policies[1].Settings.FilenamePatterns)
+	require.Equal(t, []string{"7b5dd6f0-0000-0000-0000-000000000000"}, policies[1].Settings.RequiredReviewerIDs)
+	require.False(t, policies[1].IsBlocking)
+
+	require.Equal(t, []string{"/src/*.md", "/src/*.md/*", "!/src/lib", "!/src/lib/*", "/docs/*"}, policies[2].Settings.FilenamePatterns)
+
+	var out strings.Builder
+	require.NoError(t, WriteAzureTerraform(&out, policies[2:]))
+	require.Contains(t, out.String(), `resource "azuredevops_branch_policy_auto_reviewers" "codeowners_0" {`)
+	require.Contains(t, out.String(), `path_filters                = ["/src/*.md", "/src/*.md/*", "!/src/lib", "!/src/lib/*", "/docs/*"]`)
+	require.Contains(t, out.String(), `repository_id  = var.repository_id`)
+}
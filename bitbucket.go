@@ -0,0 +1,31 @@
+package codeowners
+
+import "strings"
+
+// bitbucketRules converts the owners of the rules to the format of the Code
+// Owners app for Bitbucket Data Center: Users stay @user, teams become groups
+// (@org/team becomes @@team), emails are kept as is.
+func bitbucketRules(rules []Rule) []Rule {
+	converted := make([]Rule, len(rules))
+	for i, rule := range rules {
+		owners := make([]string, len(rule.Owners))
+		for j, owner := range rule.Owners {
+			owners[j] = bitbucketOwner(owner)
+		}
+
+		rule.Owners = owners
+		converted[i] = rule
+	}
+
+	return converted
+}
+
+// bitbucketOwner converts a single owner to the Bitbucket format.
+func bitbucketOwner(owner string) string {
+	if !isTeamOwner(owner) {
+		return owner
+	}
+
+	team := owner[strings.Index(owner, "/")+1:]
+	return "@@" + team
+}
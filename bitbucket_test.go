@@ -0,0 +1,20 @@
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitbucketTarget(t *testing.T) {
+	rules := []Rule{
+		{Pattern: "*", Owners: []string{"@org/admins"}},
+		{Pattern: "/src", Owners: []string{"@someone", "dev@example.com", "@org/dev-team"}},
+	}
+
+	output := GenerateCodeownersFile(rules, GenerateOptions{NoHeader: true, Target: TargetBitbucket})
+	require.Equal(t, "* @@admins\n/src @someone dev@example.com @@dev-team\n", output)
+
+	// The input rules are left untouched
+	require.Equal(t, []string{"@org/admins"}, rules[0].Owners)
+}
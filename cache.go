@@ -0,0 +1,141 @@
+package codeowners
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// rulesCacheFileName is the name of the rules cache inside the git dir.
+const rulesCacheFileName = "codeowners-rules.json"
+
+// rulesCacheFormat is incremented whenever the layout of rulesCache changes,
+// which invalidates existing caches.
+const rulesCacheFormat = 1
+
+// rulesCache is the serialized result of LoadRulesCached, from which a Matcher
+// is rebuilt in linear time.
+type rulesCache struct {
+	// Key is the input hash the rules were computed for, see rulesCacheKey.
+	Key   string `json:"key"`
+	Rules []Rule `json:"rules"`
+}
+
+// LoadRulesCached is RewriteCodeownersRules followed by the NeverOwnedRules of
+// the never-owned patterns of the config, with a cache of the rules in the git
+// dir of the repo in root, which is used as long as HEAD, the uncommitted
+// changes, the options, the teams manifest and the never-owned patterns are
+// the same. Without git the rules are always rewritten. Failing to write the
+// cache isn't an error, the cache is an optimization only.
+func LoadRulesCached(ctx context.Context, root string, opts Options, neverOwned []string) ([]Rule, error) {
+	rewrite := func() ([]Rule, error) {
+		rules, err := RewriteCodeownersRules(ctx, root, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		return append(rules, NeverOwnedRules(neverOwned, opts)...), nil
+	}
+
+	key, err := rulesCacheKey(ctx, root, opts, neverOwned)
+	if err != nil {
+		return rewrite()
+	}
+
+	cachePath, err := runGit(ctx, root, "rev-parse", "--git-path", rulesCacheFileName)
+	if err != nil {
+		return rewrite()
+	}
+	if !filepath.IsAbs(cachePath) {
+		cachePath = filepath.Join(root, cachePath)
+	}
+
+	if rules, ok := readRulesCache(cachePath, key); ok {
+		return rules, nil
+	}
+
+	rules, err := rewrite()
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := json.Marshal(rulesCache{Key: key, Rules: rules})
+	if err == nil {
+		_ = WriteFileAtomic(cachePath, string(content))
+	}
+
+	return rules, nil
+}
+
+// readRulesCache returns the cached rules if the cache at cachePath exists
+// and was written for key.
+func readRulesCache(cachePath, key string) ([]Rule, bool) {
+	content, err := os.ReadFile(cachePath)
+	if err != nil {
+		return nil, false
+	}
+
+	var cache rulesCache
+	err = json.Unmarshal(content, &cache)
+	if err != nil || cache.Key != key {
+		return nil, false
+	}
+
+	return cache.Rules, true
+}
+
+// rulesCacheKey hashes everything the rules of the repo in root depend on: the
+// tool version, HEAD, the size and modification time of every file with
+// uncommitted changes, since they might be modified again without changing the
+// status, the options, the content of the teams manifest, which may be ignored
+// by git, and the never-owned patterns.
+func rulesCacheKey(ctx context.Context, root string, opts Options, neverOwned []string) (string, error) {
+	head, err := GitHeadCommit(ctx, root)
+	if err != nil {
+		return "", err
+	}
+
+	top, err := runGit(ctx, root, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+
+	changes, err := GitWorkingTreeChanges(ctx, root, false)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\n%s\n%s\n%s\n", rulesCacheFormat, ToolVersion(), root, head)
+	for _, file := range changes {
+		info, err := os.Lstat(longPath(filepath.Join(top, filepath.FromSlash(file))))
+		if err != nil {
+			fmt.Fprintf(h, "%q deleted\n", file)
+			continue
+		}
+		fmt.Fprintf(h, "%q %d %d\n", file, info.Size(), info.ModTime().UnixNano())
+	}
+	writeOptionsKey(h, opts)
+
+	if opts.TeamsManifest != "" {
+		content, err := os.ReadFile(longPath(filepath.Join(root, opts.TeamsManifest)))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "manifest %x\n", sha256.Sum256(content))
+	}
+	fmt.Fprintf(h, "never-owned=%q\n", neverOwned)
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeOptionsKey writes the options that affect the rewritten rules to w.
+func writeOptionsKey(w io.Writer, opts Options) {
+	fmt.Fprintf(w, "prefix=%q unanchored=%t skip-root=%t strict=%t manifest=%q readmes=%t skip-generated=%t max-size=%d output=%q files=%q\n",
+		opts.PathPrefix, opts.Unanchored, opts.SkipRootCodeowners, opts.Strict, opts.TeamsManifest, opts.ReadmeOwners, opts.SkipGenerated, opts.MaxFileSize, opts.Output, opts.Files)
+}
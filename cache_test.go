@@ -0,0 +1,89 @@
+package codeowners
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadRulesCached(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repoPath := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoPath
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	git("init", "-q")
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admin\n")
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/dev\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "Add owners")
+
+	ctx := context.Background()
+	rules, err := LoadRulesCached(ctx, repoPath, Options{}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"* @org/admin", "/src @org/dev"}, ruleStrings(rules))
+
+	// An unchanged repo uses the cache
+	cachePath := filepath.Join(repoPath, ".git", rulesCacheFileName)
+	content, err := os.ReadFile(cachePath)
+	require.NoError(t, err)
+	var cache rulesCache
+	require.NoError(t, json.Unmarshal(content, &cache))
+	cache.Rules = []Rule{{Pattern: "/cached", Owners: []string{"@org/cache"}}}
+	content, err = json.Marshal(cache)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(cachePath, content, 0644))
+
+	rules, err = LoadRulesCached(ctx, repoPath, Options{}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/cached @org/cache"}, ruleStrings(rules))
+
+	// Other options and uncommitted changes invalidate it
+	rules, err = LoadRulesCached(ctx, repoPath, Options{PathPrefix: "sub"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/sub @org/admin", "/sub/src @org/dev"}, ruleStrings(rules))
+
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/web\n")
+	rules, err = LoadRulesCached(ctx, repoPath, Options{}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"* @org/admin", "/src @org/web"}, ruleStrings(rules))
+
+	// So do the never-owned patterns and the content of the teams manifest,
+	// even if git ignores it
+	rules, err = LoadRulesCached(ctx, repoPath, Options{}, []string{"/gen/"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"* @org/admin", "/src @org/web", "/gen/ # " + neverOwnedComment}, ruleStrings(rules))
+
+	writeFile(t, repoPath, ".gitignore", "teams.yaml\n")
+	writeFile(t, repoPath, "teams.yaml", "\"@org/docs\":\n  - docs\n")
+	rules, err = LoadRulesCached(ctx, repoPath, Options{TeamsManifest: "teams.yaml"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"* @org/admin", "/docs @org/docs", "/src @org/web"}, ruleStrings(rules))
+
+	writeFile(t, repoPath, "teams.yaml", "\"@org/writers\":\n  - docs\n")
+	rules, err = LoadRulesCached(ctx, repoPath, Options{TeamsManifest: "teams.yaml"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"* @org/admin", "/docs @org/writers", "/src @org/web"}, ruleStrings(rules))
+
+	// Without git the rules are rewritten every time
+	plainPath := t.TempDir()
+	writeFile(t, plainPath, "CODEOWNERS", "@org/admin\n")
+	rules, err = LoadRulesCached(ctx, plainPath, Options{}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"* @org/admin"}, ruleStrings(rules))
+}
@@ -0,0 +1,65 @@
+package codeowners
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// FindCaseCollisions reports tracked paths that differ only by case, e.g.
+// Docs/a.md and docs/b.md. They can't coexist on case-insensitive file
+// systems like the defaults of macOS and Windows, and CODEOWNERS patterns
+// match them case-sensitively. Colliding dirs are reported once, not for
+// every file inside of them.
+func FindCaseCollisions(files []string) []Finding {
+	variants := map[string]map[string]bool{}
+	for _, file := range files {
+		for p := file; p != "." && p != "/" && p != ""; p = path.Dir(p) {
+			key := strings.ToLower(p)
+			if variants[key] == nil {
+				variants[key] = map[string]bool{}
+			}
+			variants[key][p] = true
+		}
+	}
+
+	keys := make([]string, 0, len(variants))
+	for key := range variants {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var findings []Finding
+	for _, key := range keys {
+		if len(variants[key]) < 2 || collidingParent(variants, key) {
+			continue
+		}
+
+		paths := make([]string, 0, len(variants[key]))
+		for p := range variants[key] {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+
+		findings = append(findings, Finding{
+			Check:    "case-collision",
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("paths %s differ only by case, CODEOWNERS matching may behave inconsistently for them", strings.Join(paths, ", ")),
+		})
+	}
+
+	return findings
+}
+
+// collidingParent checks whether any parent dir of the lowercased path key
+// collides already.
+func collidingParent(variants map[string]map[string]bool, key string) bool {
+	for dir := path.Dir(key); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if len(variants[dir]) > 1 {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,22 @@
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindCaseCollisions(t *testing.T) {
+	files := []string{"Docs/a.md", "docs/b.md", "docs/README.md", "src/Main.go", "src/main.go", "src/util.go", "README.md"}
+
+	findings := FindCaseCollisions(files)
+	require.Len(t, findings, 2)
+	require.Equal(t, Finding{
+		Check:    "case-collision",
+		Severity: SeverityWarning,
+		Message:  "paths Docs, docs differ only by case, CODEOWNERS matching may behave inconsistently for them",
+	}, findings[0])
+	require.Contains(t, findings[1].Message, "paths src/Main.go, src/main.go differ")
+
+	require.Empty(t, FindCaseCollisions([]string{"a/b", "a/c", "B"}))
+}
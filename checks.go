@@ -0,0 +1,101 @@
+package codeowners
+
+import "strings"
+
+// checkIDs assigns every check a stable ID, which identifies it in reports and
+// suppressions even if its name changes. IDs are never reused, new checks get
+// the next free ID.
+var checkIDs = []struct {
+	id    string
+	check string
+}{
+	{"CO001", "stale-pattern"},
+	{"CO002", "missing-owners"},
+	{"CO003", "invalid-owner"},
+	{"CO004", "escaping-pattern"},
+	{"CO005", "min-owners"},
+	{"CO006", "require-teams"},
+	{"CO007", "require-codeowners"},
+	{"CO008", "min-coverage"},
+	{"CO009", "never-owned"},
+	{"CO010", "expired-rule"},
+	{"CO011", "expiring-rule"},
+	{"CO012", "ignored-codeowners"},
+	{"CO013", "unknown-pragma"},
+	{"CO014", "no-effective-rules"},
+	{"CO015", "trailing-whitespace"},
+	{"CO016", "tab-separator"},
+	{"CO017", "renamed-owner"},
+	{"CO018", "owner-casing"},
+	{"CO019", "duplicate-owner"},
+	{"CO020", "email-owner"},
+	{"CO021", "unresolved-email"},
+	{"CO022", "unknown-owner"},
+	{"CO023", "overlapping-claim"},
+	{"CO024", "duplicate-pattern"},
+	{"CO025", "case-collision"},
+	{"CO026", "moved-pattern"},
+	{"CO027", "renamed-path"},
+	{"CO028", "max-rules"},
+	{"CO029", "github-codeowners-error"},
+	{"CO030", "invalid-suppression"},
+	{"CO031", "unsupported-pattern"},
+	{"CO032", "shadowed-rule"},
+}
+
+// checkID returns the ID of a check, empty if it has none.
+func checkID(check string) string {
+	for _, c := range checkIDs {
+		if c.check == check {
+			return c.id
+		}
+	}
+
+	return ""
+}
+
+// lookupCheckID returns the ID of a check given by its ID or name, case
+// insensitive for IDs.
+func lookupCheckID(s string) (string, bool) {
+	for _, c := range checkIDs {
+		if strings.EqualFold(c.id, s) || c.check == s {
+			return c.id, true
+		}
+	}
+
+	return "", false
+}
+
+// severityOff turns a check off in Config.Severities.
+const severityOff = "off"
+
+// severityOverrides are the valid values of Config.Severities.
+var severityOverrides = []string{severityOff, string(SeverityWarning), string(SeverityError)}
+
+// ApplySeverities overrides the severities of the findings with the
+// configured ones, keyed by check ID or name, and drops the findings of the
+// checks that are turned off. With strict, the remaining warnings become
+// errors, e.g. to phase in checks as warnings before enforcing them.
+func ApplySeverities(findings []Finding, severities map[string]string, strict bool) []Finding {
+	var kept []Finding
+	for _, finding := range findings {
+		severity, ok := severities[finding.ID()]
+		if !ok {
+			severity, ok = severities[finding.Check]
+		}
+
+		switch {
+		case severity == severityOff:
+			continue
+		case ok:
+			finding.Severity = Severity(severity)
+		}
+		if strict {
+			finding.Severity = SeverityError
+		}
+
+		kept = append(kept, finding)
+	}
+
+	return kept
+}
@@ -0,0 +1,56 @@
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckIDs(t *testing.T) {
+	seen := map[string]bool{}
+	for _, c := range checkIDs {
+		require.False(t, seen[c.id], c.id)
+		require.False(t, seen[c.check], c.check)
+		seen[c.id], seen[c.check] = true, true
+	}
+
+	require.Equal(t, "CO001", Finding{Check: "stale-pattern"}.ID())
+	require.Equal(t, "", Finding{Check: "made-up"}.ID())
+}
+
+func TestApplySeverities(t *testing.T) {
+	findings := []Finding{
+		{Check: "stale-pattern", Severity: SeverityWarning, File: "CODEOWNERS", Line: 1},
+		{Check: "trailing-whitespace", Severity: SeverityWarning, File: "CODEOWNERS", Line: 2},
+		{Check: "invalid-owner", Severity: SeverityError, File: "CODEOWNERS", Line: 3},
+		{Check: "owner-casing", Severity: SeverityWarning, File: "CODEOWNERS", Line: 4},
+	}
+
+	repoPath := t.TempDir()
+	writeFile(t, repoPath, ConfigFileName, "severities:\n  stale-pattern: error\n  CO015: off\n  co003: warning\n")
+	cfg, err := LoadConfig(repoPath, "")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"CO001": "error", "CO015": "off", "CO003": "warning"}, cfg.Severities)
+
+	require.Equal(t, []Finding{
+		{Check: "stale-pattern", Severity: SeverityError, File: "CODEOWNERS", Line: 1},
+		{Check: "invalid-owner", Severity: SeverityWarning, File: "CODEOWNERS", Line: 3},
+		{Check: "owner-casing", Severity: SeverityWarning, File: "CODEOWNERS", Line: 4},
+	}, ApplySeverities(findings, cfg.Severities, false))
+
+	strict := ApplySeverities(findings, cfg.Severities, true)
+	require.Len(t, strict, 3)
+	for _, finding := range strict {
+		require.Equal(t, SeverityError, finding.Severity, finding.Check)
+	}
+
+	writeFile(t, repoPath, ConfigFileName, "severities:\n  made-up: error\n")
+	_, err = LoadConfig(repoPath, "")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unknown check made-up")
+
+	writeFile(t, repoPath, ConfigFileName, "severities:\n  CO001: fatal\n")
+	_, err = LoadConfig(repoPath, "")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `invalid severity "fatal" for CO001, expected one of off, warning, error`)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gmolau/codeowners"
+)
+
+// githubOutputEnv is set by GitHub Actions to the file that collects the
+// outputs of the current step.
+const githubOutputEnv = "GITHUB_OUTPUT"
+
+// StepOutputs are the results of a generator run that are written as outputs
+// of a GitHub Actions step, so that later steps can branch on them without
+// parsing logs. Unknown values are omitted.
+type StepOutputs struct {
+	// Changed reports whether the generated file differs from the file in
+	// the repo, nil if there is nothing to compare with.
+	Changed *bool
+
+	RuleCount int
+
+	// Coverage is only computed for local checkouts.
+	Coverage *codeowners.Coverage
+
+	// ReportPath is the --report-file, if any.
+	ReportPath string
+}
+
+// lines renders the outputs as "key=value" lines.
+func (o StepOutputs) lines() []string {
+	var lines []string
+	if o.Changed != nil {
+		lines = append(lines, "changed="+strconv.FormatBool(*o.Changed))
+	}
+	lines = append(lines, fmt.Sprintf("rule_count=%d", o.RuleCount))
+	if o.Coverage != nil {
+		lines = append(lines,
+			fmt.Sprintf("unowned_count=%d", o.Coverage.Files-o.Coverage.Owned),
+			"coverage="+formatPercent(o.Coverage.Percent()))
+	}
+	if o.ReportPath != "" {
+		lines = append(lines, "report_path="+o.ReportPath)
+	}
+
+	return lines
+}
+
+// writeStepOutputs appends the outputs to the GitHub Actions output file at
+// path, which other steps of the job may have written to already.
+func writeStepOutputs(path string, outputs StepOutputs) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("can't open %s: %w", githubOutputEnv, err)
+	}
+
+	_, err = file.WriteString(strings.Join(outputs.lines(), "\n") + "\n")
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("can't write %s: %w", githubOutputEnv, err)
+	}
+
+	return nil
+}
+
+// fileDiffers checks whether the file at path doesn't exist or differs from
+// content.
+func fileDiffers(path, content string) bool {
+	existing, err := os.ReadFile(path)
+	return err != nil || string(existing) != content
+}
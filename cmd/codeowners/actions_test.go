@@ -0,0 +1,28 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gmolau/codeowners"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteStepOutputs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output")
+	require.NoError(t, os.WriteFile(path, []byte("previous=step\n"), 0644))
+
+	changed := true
+	require.NoError(t, writeStepOutputs(path, StepOutputs{
+		Changed:    &changed,
+		RuleCount:  312,
+		Coverage:   &codeowners.Coverage{Files: 200, Owned: 183},
+		ReportPath: "report.json",
+	}))
+	require.NoError(t, writeStepOutputs(path, StepOutputs{RuleCount: 1}))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "previous=step\nchanged=true\nrule_count=312\nunowned_count=17\ncoverage=91.5\nreport_path=report.json\nrule_count=1\n", string(content))
+}
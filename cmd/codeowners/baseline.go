@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/gmolau/codeowners"
+)
+
+// Baseline records the findings of a repo at some point, e.g. when the linter
+// is introduced to a legacy repo, so that only new findings fail the lint.
+type Baseline struct {
+	Findings []codeowners.Finding `json:"findings"`
+}
+
+// baselineKey identifies a finding across runs. The line and column are left
+// out, so that findings survive edits above them.
+type baselineKey struct {
+	check   string
+	file    string
+	message string
+}
+
+func newBaselineKey(finding codeowners.Finding) baselineKey {
+	return baselineKey{check: finding.Check, file: finding.File, message: finding.Message}
+}
+
+// loadBaseline reads the baseline file at path.
+func loadBaseline(path string) (Baseline, error) {
+	var baseline Baseline
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return baseline, fmt.Errorf("can't read baseline: %w", err)
+	}
+
+	err = json.Unmarshal(content, &baseline)
+	if err != nil {
+		return baseline, fmt.Errorf("can't parse baseline %s: %w", path, err)
+	}
+
+	return baseline, nil
+}
+
+// writeBaseline writes the findings as baseline file to path.
+func writeBaseline(path string, findings []codeowners.Finding) error {
+	if findings == nil {
+		findings = []codeowners.Finding{}
+	}
+
+	var b bytes.Buffer
+	err := writeJSON(&b, Baseline{Findings: findings})
+	if err != nil {
+		return err
+	}
+
+	err = codeowners.WriteFileAtomic(path, b.String())
+	if err != nil {
+		return fmt.Errorf("can't write baseline: %w", err)
+	}
+
+	return nil
+}
+
+// filter removes the findings recorded in the baseline. Every recorded
+// finding hides at most one finding, so that a second occurrence of the same
+// problem in a file is reported. It returns the new findings, the number of
+// hidden findings and the number of recorded findings that are gone.
+func (b Baseline) filter(findings []codeowners.Finding) ([]codeowners.Finding, int, int) {
+	recorded := map[baselineKey]int{}
+	for _, finding := range b.Findings {
+		recorded[newBaselineKey(finding)]++
+	}
+
+	var newFindings []codeowners.Finding
+	hidden := 0
+	for _, finding := range findings {
+		key := newBaselineKey(finding)
+		if recorded[key] > 0 {
+			recorded[key]--
+			hidden++
+			continue
+		}
+
+		newFindings = append(newFindings, finding)
+	}
+
+	return newFindings, hidden, len(b.Findings) - hidden
+}
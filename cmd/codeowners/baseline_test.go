@@ -0,0 +1,38 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gmolau/codeowners"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaseline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	recorded := []codeowners.Finding{
+		{Check: "invalid-owner", Severity: codeowners.SeverityError, Message: "@a_b is not a valid user, team or email address", File: "src/CODEOWNERS", Line: 3, Column: 7},
+		{Check: "trailing-whitespace", Severity: codeowners.SeverityWarning, Message: "line has trailing whitespace", File: "src/CODEOWNERS", Line: 4, Fixable: true},
+		{Check: "stale-pattern", Severity: codeowners.SeverityWarning, Message: "pattern /docs/old doesn't match any file", File: "docs/CODEOWNERS", Line: 2},
+	}
+	require.NoError(t, writeBaseline(path, recorded))
+
+	baseline, err := loadBaseline(path)
+	require.NoError(t, err)
+	require.Equal(t, recorded, baseline.Findings)
+
+	findings := []codeowners.Finding{
+		// Moved down by an edit above
+		{Check: "invalid-owner", Severity: codeowners.SeverityError, Message: "@a_b is not a valid user, team or email address", File: "src/CODEOWNERS", Line: 5, Column: 7},
+		{Check: "trailing-whitespace", Severity: codeowners.SeverityWarning, Message: "line has trailing whitespace", File: "src/CODEOWNERS", Line: 6, Fixable: true},
+		{Check: "trailing-whitespace", Severity: codeowners.SeverityWarning, Message: "line has trailing whitespace", File: "src/CODEOWNERS", Line: 9, Fixable: true},
+		{Check: "invalid-owner", Severity: codeowners.SeverityError, Message: "@c_d is not a valid user, team or email address", File: "src/CODEOWNERS", Line: 10, Column: 7},
+	}
+	newFindings, hidden, gone := baseline.filter(findings)
+	require.Equal(t, findings[2:], newFindings)
+	require.Equal(t, 2, hidden)
+	require.Equal(t, 1, gone)
+
+	_, err = loadBaseline(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
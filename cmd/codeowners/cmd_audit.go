@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/gmolau/codeowners"
+)
+
+// exitCodeFindings is the exit code used when checks report errors.
+const exitCodeFindings = 2
+
+// runAudit implements the audit command which runs all checks and prints a
+// consolidated report.
+func runAudit(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("audit", flag.ExitOnError)
+	root := flags.String("root", ".", "dir inside the repo to audit")
+	noDiscover := flags.Bool("no-discover", false, "use the given root as is instead of the enclosing git repository")
+	configFile := flags.String("config", "", "config file (default "+codeowners.ConfigFileName+" in the repo root)")
+	format := flags.String("format", FormatText, "output format: text, json, yaml, csv (the findings) or sarif")
+	owner := flags.String("owner", "", "only report the rules involving these owners, a comma separated list of owners or globs like @org/*")
+	strict := flags.Bool("strict", false, "report all warnings as errors, like strict in the config")
+	flags.BoolVar(strict, "Werror", false, "alias for --strict")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s audit [flags]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args) // Exits on error
+
+	if err := validFormat(*format, FormatSARIF); err != nil {
+		return err
+	}
+
+	repoRoot, err := resolveRoot(*root, !*noDiscover)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := codeowners.LoadConfig(repoRoot, *configFile)
+	if err != nil {
+		return err
+	}
+	cfg.Strict = cfg.Strict || *strict
+
+	ownerFilter, err := codeowners.ParseOwnerFilter(*owner)
+	if err != nil {
+		return err
+	}
+
+	report, err := codeowners.Audit(ctx, repoRoot, cfg)
+	if err != nil {
+		return err
+	}
+
+	if len(ownerFilter) > 0 {
+		rules, err := codeowners.RewriteCodeownersRules(ctx, repoRoot, codeowners.Options{})
+		if err != nil {
+			return fmt.Errorf("error while rewriting codeowner rules: %w", err)
+		}
+
+		report.RestrictToOwners(ownerFilter, rules)
+	}
+
+	switch *format {
+	case FormatText:
+		err = writeAuditText(os.Stdout, report)
+	case FormatSARIF:
+		err = writeJSON(os.Stdout, toSARIF(report.Findings))
+	default:
+		err = writeFormatted(os.Stdout, *format, report)
+	}
+	if err != nil {
+		return err
+	}
+
+	if report.Failed() {
+		os.Exit(exitCodeFindings)
+	}
+
+	return nil
+}
+
+// writeAuditText writes the findings followed by a summary.
+func writeAuditText(w io.Writer, report codeowners.AuditReport) error {
+	for _, finding := range report.Findings {
+		_, err := fmt.Fprintln(w, finding)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "\n%s from %s, %s\n%d of %s owned (%.1f%%)\n%s\n",
+		codeowners.Pluralize(report.Stats.Rules, "rule"), codeowners.Pluralize(report.Stats.SourceFiles, "CODEOWNERS file"), codeowners.Pluralize(report.Stats.Owners, "owner"),
+		report.Coverage.Owned, codeowners.Pluralize(report.Coverage.Files, "file"), report.Coverage.Percent(),
+		codeowners.Pluralize(len(report.Findings), "finding"))
+	if err != nil {
+		return err
+	}
+
+	for _, suppression := range report.Suppressions {
+		_, err = fmt.Fprintln(w, suppression)
+		if err != nil {
+			return err
+		}
+	}
+
+	labels := make([]string, 0, len(report.Labels))
+	for label := range report.Labels {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	for _, label := range labels {
+		_, err = fmt.Fprintf(w, "%s labeled %s\n", codeowners.Pluralize(report.Labels[label], "rule"), label)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeJSON writes v as indented JSON.
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/gmolau/codeowners"
+	"gopkg.in/yaml.v3"
+)
+
+// runAzurePolicies implements the azure-policies command which exports the
+// rules as Azure DevOps branch policies.
+func runAzurePolicies(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("azure-policies", flag.ExitOnError)
+	root := flags.String("root", ".", "dir inside the repo whose CODEOWNERS files are evaluated")
+	noDiscover := flags.Bool("no-discover", false, "use the given root as is instead of the enclosing git repository")
+	format := flags.String("format", "json", "output format: json for the policy configurations API or terraform")
+	repositoryID := flags.String("repository-id", "", "id of the Azure DevOps repository the policies apply to")
+	branch := flags.String("branch", "refs/heads/main", "branch the policies apply to")
+	identitiesFile := flags.String("identities", "", "YAML file mapping owners to Azure DevOps identity ids")
+	optional := flags.Bool("optional", false, "add the owners as optional instead of required reviewers")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s azure-policies [flags]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args) // Exits on error
+
+	rules, err := loadRules(ctx, *root, !*noDiscover, codeowners.Options{})
+	if err != nil {
+		return err
+	}
+
+	opts := codeowners.AzureOptions{
+		RepositoryID: *repositoryID,
+		RefName:      *branch,
+		Optional:     *optional,
+	}
+
+	if *identitiesFile != "" {
+		content, err := os.ReadFile(*identitiesFile)
+		if err != nil {
+			return fmt.Errorf("can't read identities file %s: %w", *identitiesFile, err)
+		}
+
+		err = yaml.Unmarshal(content, &opts.Identities)
+		if err != nil {
+			return fmt.Errorf("can't parse identities file %s: %w", *identitiesFile, err)
+		}
+	}
+
+	for _, owner := range codeowners.ListOwners(rules) {
+		if _, ok := opts.Identities[owner.Owner]; !ok {
+			log.Printf("warning: no Azure DevOps identity for %s, using the owner as id", owner.Owner)
+		}
+	}
+
+	policies := codeowners.AzurePolicies(rules, opts)
+
+	switch *format {
+	case "json":
+		return writeJSON(os.Stdout, policies)
+	case "terraform":
+		return codeowners.WriteAzureTerraform(os.Stdout, policies)
+	}
+
+	return fmt.Errorf("unknown format %s", *format)
+}
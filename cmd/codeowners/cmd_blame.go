@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gmolau/codeowners"
+)
+
+// blameResult is the JSON representation of the origin of a rule.
+type blameResult struct {
+	Rule   string               `json:"rule"`
+	Source string               `json:"source"`
+	Blame  codeowners.BlameInfo `json:"blame"`
+}
+
+// runBlame implements the blame command which reports who introduced the
+// rules for a pattern, given as pattern or as complete line of the generated
+// file.
+func runBlame(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("blame", flag.ExitOnError)
+	root := flags.String("root", ".", "dir inside the repo whose CODEOWNERS files are evaluated")
+	all := flags.Bool("all", false, "blame every rule for the pattern, not only the effective (last) one")
+	format := flags.String("format", FormatText, "output format: text, json, yaml or csv")
+	jsonOutput := flags.Bool("json", false, "shorthand for --format json")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s blame [flags] pattern|line\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args) // Exits on error
+
+	if *jsonOutput {
+		*format = FormatJSON
+	}
+	if err := validFormat(*format); err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("no pattern given")
+	}
+
+	// Accept complete lines of the generated file, with or without quotes
+	fields := strings.Fields(strings.Join(flags.Args(), " "))
+	pattern := fields[0]
+
+	repoRoot, err := resolveRoot(*root, true)
+	if err != nil {
+		return err
+	}
+
+	rules, err := loadRules(ctx, repoRoot, false, codeowners.Options{})
+	if err != nil {
+		return err
+	}
+
+	matching := findRulesByPattern(rules, pattern)
+	if len(matching) == 0 {
+		return fmt.Errorf("no rule for pattern %s", pattern)
+	}
+	if !*all {
+		matching = matching[len(matching)-1:]
+	}
+
+	var results []blameResult
+	for _, rule := range matching {
+		info, err := codeowners.GitBlameLine(ctx, repoRoot, rule.Source, rule.Line)
+		if err != nil {
+			return fmt.Errorf("can't blame %s: %w", rule.Location(), err)
+		}
+
+		results = append(results, blameResult{Rule: rule.String(), Source: rule.Location(), Blame: info})
+	}
+
+	if *format != FormatText {
+		return writeFormatted(os.Stdout, *format, results)
+	}
+
+	for _, result := range results {
+		fmt.Printf("%s\n  declared in %s\n  introduced in %.12s by %s <%s> on %s: %s\n",
+			result.Rule, result.Source, result.Blame.Commit, result.Blame.Author, result.Blame.Email,
+			result.Blame.Time.Format(time.RFC3339), result.Blame.Summary)
+	}
+
+	return nil
+}
+
+// findRulesByPattern returns the rules for pattern in declaration order.
+func findRulesByPattern(rules []codeowners.Rule, pattern string) []codeowners.Rule {
+	var matching []codeowners.Rule
+	for _, rule := range rules {
+		if rule.Pattern == pattern {
+			matching = append(matching, rule)
+		}
+	}
+
+	return matching
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/gmolau/codeowners"
+)
+
+// runPRComment implements the pr-comment command which renders a Markdown
+// summary of the ownership impact of a pull request and optionally posts it.
+func runPRComment(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("pr-comment", flag.ExitOnError)
+	root := flags.String("root", ".", "dir inside the repo whose CODEOWNERS files are evaluated")
+	repo := flags.String("repo", os.Getenv("GITHUB_REPOSITORY"), "GitHub repository as owner/name (default $GITHUB_REPOSITORY)")
+	number := flags.Int("pr", 0, "number of the pull request, its changed files are fetched from the GitHub API")
+	base := flags.String("base", "origin/main", "base revision to compute the changed files with git if --pr isn't given")
+	head := flags.String("head", "HEAD", "head revision to compute the changed files with git if --pr isn't given")
+	token := flags.String("token", "", "GitHub token (default $GITHUB_TOKEN)")
+	post := flags.Bool("post", false, "post the comment on the pull request instead of printing it")
+	ownership := addOwnershipFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s pr-comment [flags]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args) // Exits on error
+
+	if *post && (*repo == "" || *number <= 0) {
+		return fmt.Errorf("--post requires --repo and --pr")
+	}
+
+	repoRoot, err := resolveRoot(*root, true)
+	if err != nil {
+		return err
+	}
+
+	client := codeowners.NewGitHubClient(codeowners.GitHubToken(*token))
+
+	var files []string
+	if *number > 0 {
+		files, err = client.PullRequestFiles(ctx, *repo, *number)
+	} else {
+		files, err = codeowners.GitChangedFiles(ctx, repoRoot, *base, *head)
+	}
+	if err != nil {
+		return fmt.Errorf("can't determine changed files: %w", err)
+	}
+
+	cfg, err := ownership.config(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	opts := ownership.options()
+	rules, err := loadRules(ctx, repoRoot, false, opts)
+	if err != nil {
+		return err
+	}
+	rules = append(rules, codeowners.NeverOwnedRules(cfg.Policy.NeverOwned, opts)...)
+
+	outdated, err := isGeneratedFileOutdated(ctx, repoRoot, rules)
+	if err != nil {
+		return err
+	}
+
+	summary := codeowners.ReviewSummary{
+		Requests:              codeowners.ResolveReviewRequests(rules, files),
+		GeneratedFileChanged:  codeowners.ContainsString(files, codeowners.GeneratedFileName),
+		GeneratedFileOutdated: outdated,
+	}
+	comment := codeowners.RenderReviewComment(summary)
+
+	if !*post {
+		_, err = fmt.Print(comment)
+		return err
+	}
+
+	return client.CreateIssueComment(ctx, *repo, *number, comment)
+}
+
+// isGeneratedFileOutdated checks whether the generated CODEOWNERS file in root
+// assigns other owners to any file than rules. The ownership is compared
+// instead of the text, so that files generated with other output options,
+// e.g. --no-header or --minify, aren't outdated.
+func isGeneratedFileOutdated(ctx context.Context, root string, rules []codeowners.Rule) (bool, error) {
+	path := filepath.Join(root, codeowners.GeneratedFileName)
+
+	existing, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("can't read %s: %w", path, err)
+	}
+
+	files, err := codeowners.ListFiles(ctx, root)
+	if err != nil {
+		return false, fmt.Errorf("can't list files of %s: %w", root, err)
+	}
+
+	return !codeowners.SameOwnership(codeowners.ParseCodeownersRules(string(existing)), rules, files), nil
+}
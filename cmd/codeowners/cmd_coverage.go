@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gmolau/codeowners"
+)
+
+// runCoverage implements the coverage command which prints how many files
+// of the repo are owned, in total, per top-level dir or per package.
+func runCoverage(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("coverage", flag.ExitOnError)
+	root := flags.String("root", ".", "dir inside the repo whose CODEOWNERS files are evaluated")
+	noDiscover := flags.Bool("no-discover", false, "use the given root as is instead of the enclosing git repository")
+	configFile := flags.String("config", "", "config file (default "+codeowners.ConfigFileName+" in the repo root)")
+	byDir := flags.Bool("by-dir", false, "print the coverage per top-level dir")
+	byPackage := flags.String("by-package", "", "print the coverage per package of these kinds, a comma separated list of "+strings.Join(codeowners.PackageKinds, ", "))
+	sortBy := flags.String("sort", codeowners.SortByDir, "order of the dirs with --by-dir and --by-package: dir, coverage (worst first) or unowned (most first)")
+	format := flags.String("format", FormatText, "output format: text, json, yaml or csv")
+	jsonOutput := flags.Bool("json", false, "shorthand for --format json")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s coverage [flags]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args) // Exits on error
+
+	if *jsonOutput {
+		*format = FormatJSON
+	}
+	if err := validFormat(*format); err != nil {
+		return err
+	}
+
+	repoRoot, err := resolveRoot(*root, !*noDiscover)
+	if err != nil {
+		return err
+	}
+
+	rules, files, err := loadCoverageInputs(ctx, repoRoot, *configFile)
+	if err != nil {
+		return err
+	}
+
+	if *byDir && *byPackage != "" {
+		return fmt.Errorf("--by-dir and --by-package can't be combined")
+	}
+
+	if !*byDir && *byPackage == "" {
+		coverage := codeowners.ComputeCoverage(rules, files)
+		if *format != FormatText {
+			return writeFormatted(os.Stdout, *format, coverage)
+		}
+
+		_, err = fmt.Printf("%d of %s owned (%.1f%%)\n", coverage.Owned, codeowners.Pluralize(coverage.Files, "file"), coverage.Percent())
+		return err
+	}
+
+	header := "DIR"
+	var dirs []codeowners.DirCoverage
+	if *byPackage != "" {
+		header = "PACKAGE"
+		dirs, err = coverageByPackage(repoRoot, rules, files, *byPackage, *sortBy)
+	} else {
+		dirs, err = codeowners.ComputeCoverageByDir(rules, files, *sortBy)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *format != FormatText {
+		return writeFormatted(os.Stdout, *format, dirs)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\tFILES\tUNOWNED\tCOVERAGE\n", header)
+	for _, dir := range dirs {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.1f%%\n", dir.Dir, dir.Files, dir.Unowned, dir.Percent)
+	}
+
+	return w.Flush()
+}
+
+// loadCoverageInputs rewrites the rules of the repo in root and lists the files
+// that should be owned, i.e. the files of the repo except for the generated
+// and never-owned ones.
+func loadCoverageInputs(ctx context.Context, root, configFile string) ([]codeowners.Rule, []string, error) {
+	rules, err := codeowners.RewriteCodeownersRules(ctx, root, codeowners.Options{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while rewriting codeowner rules: %w", err)
+	}
+
+	files, err := codeowners.ListFiles(ctx, root)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cfg, err := codeowners.LoadConfig(root, configFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	files = codeowners.ExcludeNeverOwned(codeowners.ExcludeGenerated(files, codeowners.NewGeneratedPaths(root)), cfg.Policy.NeverOwned)
+
+	return rules, files, nil
+}
+
+// coverageByPackage finds the packages of the kinds in the repo and computes
+// their coverage.
+func coverageByPackage(root string, rules []codeowners.Rule, files []string, kinds, sortBy string) ([]codeowners.DirCoverage, error) {
+	parsedKinds, err := codeowners.ParsePackageKinds(kinds)
+	if err != nil {
+		return nil, err
+	}
+
+	packages, err := codeowners.FindPackages(root, files, parsedKinds)
+	if err != nil {
+		return nil, err
+	}
+
+	return codeowners.ComputeCoverageByPackage(rules, files, packages, sortBy)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/gmolau/codeowners"
+)
+
+// runGitHubErrors implements the github-errors command which reports the
+// problems GitHub itself found in the CODEOWNERS file of a branch, e.g. after
+// pushing the generated file or for a candidate branch.
+func runGitHubErrors(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("github-errors", flag.ExitOnError)
+	repo := flags.String("repo", os.Getenv("GITHUB_REPOSITORY"), "GitHub repository as owner/name (default $GITHUB_REPOSITORY)")
+	ref := flags.String("ref", "", "branch, tag or commit whose CODEOWNERS file is checked (default: the default branch)")
+	token := flags.String("token", "", "GitHub token (default $GITHUB_TOKEN)")
+	format := flags.String("format", FormatText, "output format: text, json, yaml, csv or sarif")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s github-errors [flags]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args) // Exits on error
+
+	if *repo == "" {
+		flags.Usage()
+		return fmt.Errorf("--repo is required")
+	}
+	if err := validFormat(*format, FormatSARIF); err != nil {
+		return err
+	}
+
+	errs, err := codeowners.NewGitHubClient(codeowners.GitHubToken(*token)).CodeownersErrors(ctx, *repo, *ref)
+	if err != nil {
+		return fmt.Errorf("can't fetch the CODEOWNERS errors of %s: %w", *repo, err)
+	}
+
+	findings := codeowners.CodeownersErrorFindings(errs)
+	switch *format {
+	case FormatText:
+		for _, finding := range findings {
+			fmt.Println(finding)
+		}
+	case FormatSARIF:
+		err = writeJSON(os.Stdout, toSARIF(findings))
+	default:
+		err = writeFormatted(os.Stdout, *format, findings)
+	}
+	if err != nil {
+		return err
+	}
+
+	if codeowners.HasErrors(findings) {
+		os.Exit(exitCodeFindings)
+	}
+
+	return nil
+}
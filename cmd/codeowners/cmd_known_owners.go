@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gmolau/codeowners"
+)
+
+// runKnownOwners implements the known-owners command which exports the
+// members and teams of a GitHub org to a known owners file, against which
+// lint and audit validate the owners offline.
+func runKnownOwners(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("known-owners", flag.ExitOnError)
+	org := flags.String("org", "", "GitHub org whose members and teams are exported")
+	output := flags.String("output", "", "file to write, e.g. the known-owners-file of "+codeowners.ConfigFileName+" (default stdout)")
+	token := flags.String("token", "", "GitHub token with read access to the org (default $GITHUB_TOKEN)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s known-owners --org org [flags]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args) // Exits on error
+
+	if *org == "" {
+		return fmt.Errorf("--org is required")
+	}
+
+	owners, err := codeowners.ExportKnownOwners(ctx, codeowners.NewGitHubClient(codeowners.GitHubToken(*token)), *org)
+	if err != nil {
+		return err
+	}
+
+	content := codeowners.FormatKnownOwnersFile(*org, owners, time.Now())
+	if *output == "" {
+		_, err = fmt.Print(content)
+		return err
+	}
+
+	err = os.WriteFile(*output, []byte(content), 0644)
+	if err != nil {
+		return fmt.Errorf("can't write known owners file: %w", err)
+	}
+
+	return nil
+}
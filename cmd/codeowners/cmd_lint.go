@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/gmolau/codeowners"
+)
+
+// runLint implements the lint command which checks the nested CO files,
+// including the expiry of rules, rules for never-owned paths and CO files in
+// ignored dirs, and optionally fixes mechanical findings in place.
+func runLint(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	root := flags.String("root", ".", "dir inside the repo to lint")
+	noDiscover := flags.Bool("no-discover", false, "use the given root as is instead of the enclosing git repository")
+	configFile := flags.String("config", "", "config file (default "+codeowners.ConfigFileName+" in the repo root)")
+	fix := flags.Bool("fix", false, "rewrite the nested CODEOWNERS files to fix mechanical findings")
+	format := flags.String("format", FormatText, "output format: text, json, yaml, csv or sarif")
+	resolveEmails := flags.Bool("resolve-emails", false, "look up the GitHub accounts of email owners and warn about emails without account")
+	rewriteEmails := flags.Bool("rewrite-emails", false, "with --resolve-emails, report email owners with account as fixable, --fix replaces them by the account")
+	token := flags.String("token", "", "GitHub token for --resolve-emails (default $GITHUB_TOKEN)")
+	baselineFile := flags.String("baseline", "", "only report the findings that aren't recorded in this baseline file")
+	updateBaseline := flags.Bool("update-baseline", false, "record the current findings in the --baseline file instead of reporting them")
+	strict := flags.Bool("strict", false, "report all warnings as errors, like strict in the config")
+	flags.BoolVar(strict, "Werror", false, "alias for --strict")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s lint [flags]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args) // Exits on error
+
+	if err := validFormat(*format, FormatSARIF); err != nil {
+		return err
+	}
+	if *updateBaseline && *baselineFile == "" {
+		return fmt.Errorf("--update-baseline requires --baseline")
+	}
+
+	repoRoot, err := resolveRoot(*root, !*noDiscover)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := codeowners.LoadConfig(repoRoot, *configFile)
+	if err != nil {
+		return err
+	}
+	cfg.Strict = cfg.Strict || *strict
+
+	rules, err := codeowners.RewriteCodeownersRules(ctx, repoRoot, codeowners.Options{})
+	if err != nil {
+		return fmt.Errorf("error while rewriting codeowner rules: %w", err)
+	}
+
+	if *resolveEmails {
+		cfg.Lint.ResolvedEmails, err = codeowners.ResolveEmailOwners(ctx, codeowners.NewGitHubClient(codeowners.GitHubToken(*token)), rules)
+		if err != nil {
+			return err
+		}
+		cfg.Lint.RewriteEmails = cfg.Lint.RewriteEmails || *rewriteEmails
+	}
+
+	var fixed, findings []codeowners.Finding
+	if *fix {
+		fixed, findings, err = codeowners.FixCodeownersFiles(ctx, repoRoot, cfg.Lint)
+	} else {
+		findings, err = codeowners.LintCodeownersFiles(ctx, repoRoot, cfg.Lint)
+	}
+	if err != nil {
+		return err
+	}
+
+	findings = append(findings, codeowners.CheckExpiry(rules, time.Now(), cfg.Lint.EffectiveExpiryWarningDays())...)
+	findings = append(findings, codeowners.CheckNeverOwned(rules, cfg.Policy.NeverOwned)...)
+
+	ignored, err := codeowners.FindIgnoredCodeownersFiles(ctx, repoRoot)
+	if err != nil {
+		return err
+	}
+	findings = append(findings, ignored...)
+
+	suppressions, invalid, err := codeowners.FindSuppressions(ctx, repoRoot)
+	if err != nil {
+		return err
+	}
+	findings = codeowners.ApplySeverities(append(findings, invalid...), cfg.Severities, cfg.Strict)
+	findings = codeowners.ApplySuppressions(findings, suppressions)
+
+	codeowners.SortFindings(fixed)
+	codeowners.SortFindings(findings)
+
+	if *updateBaseline {
+		err = writeBaseline(*baselineFile, findings)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Printf("recorded %s in %s\n", codeowners.Pluralize(len(findings), "finding"), *baselineFile)
+		return err
+	}
+
+	result := lintResult{Fixed: fixed, Findings: findings, Suppressions: suppressions}
+	if *baselineFile != "" {
+		baseline, err := loadBaseline(*baselineFile)
+		if err != nil {
+			return err
+		}
+
+		result.Findings, result.Baselined, result.BaselineGone = baseline.filter(findings)
+	}
+	if result.Findings == nil {
+		result.Findings = []codeowners.Finding{}
+	}
+
+	switch *format {
+	case FormatText:
+		err = writeLintText(os.Stdout, result)
+	case FormatSARIF:
+		err = writeJSON(os.Stdout, toSARIF(result.Findings))
+	default:
+		err = writeFormatted(os.Stdout, *format, result)
+	}
+	if err != nil {
+		return err
+	}
+
+	if codeowners.HasErrors(result.Findings) {
+		os.Exit(exitCodeFindings)
+	}
+
+	return nil
+}
+
+// lintResult is the structured output of the lint command, the CSV output
+// only lists the remaining findings.
+type lintResult struct {
+	Fixed        []codeowners.Finding     `json:"fixed,omitempty"`
+	Findings     []codeowners.Finding     `json:"findings"`
+	Suppressions []codeowners.Suppression `json:"suppressions,omitempty"`
+
+	// Baselined is the number of findings hidden by the baseline,
+	// BaselineGone the number of findings of the baseline that are fixed.
+	Baselined    int `json:"baselined,omitempty"`
+	BaselineGone int `json:"baselineGone,omitempty"`
+}
+
+// writeLintText writes the fixed findings followed by the remaining ones, the
+// suppressions and a summary.
+func writeLintText(w io.Writer, result lintResult) error {
+	fixed, findings, suppressions := result.Fixed, result.Findings, result.Suppressions
+
+	fixable := 0
+	for _, finding := range findings {
+		if finding.Fixable {
+			fixable++
+		}
+	}
+
+	for _, finding := range fixed {
+		_, err := fmt.Fprintf(w, "fixed: %s\n", finding)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, finding := range findings {
+		_, err := fmt.Fprintln(w, finding)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, suppression := range suppressions {
+		_, err := fmt.Fprintln(w, suppression)
+		if err != nil {
+			return err
+		}
+	}
+
+	if result.Baselined > 0 || result.BaselineGone > 0 {
+		_, err := fmt.Fprintf(w, "\n%s hidden by the baseline, %s of the baseline fixed\n", codeowners.Pluralize(result.Baselined, "finding"), codeowners.Pluralize(result.BaselineGone, "finding"))
+		if err != nil {
+			return err
+		}
+	}
+
+	if fixable > 0 {
+		_, err := fmt.Fprintf(w, "\n%d of %s can be fixed with --fix\n", fixable, codeowners.Pluralize(len(findings), "finding"))
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/gmolau/codeowners"
+)
+
+// exitCodeMergeConflict is the exit code git expects from merge drivers that
+// leave conflicts.
+const exitCodeMergeConflict = 1
+
+// runMergeDriver implements the merge-driver command, a git merge driver that
+// merges CODEOWNERS files semantically. It is invoked by git with the paths of
+// the ancestor, current and other version and writes the result to current.
+func runMergeDriver(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("merge-driver", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s merge-driver %%O %%A %%B\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args) // Exits on error
+
+	if flags.NArg() != 3 {
+		flags.Usage()
+		return fmt.Errorf("expected 3 files, got %d", flags.NArg())
+	}
+
+	var contents [3]string
+	for i, path := range flags.Args() {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("can't read %s: %w", path, err)
+		}
+		contents[i] = string(content)
+	}
+
+	merged, conflict := codeowners.MergeCodeowners(contents[0], contents[1], contents[2])
+
+	current := flags.Arg(1)
+	err := os.WriteFile(current, []byte(merged), 0644)
+	if err != nil {
+		return fmt.Errorf("can't write %s: %w", current, err)
+	}
+
+	if conflict {
+		os.Exit(exitCodeMergeConflict)
+	}
+
+	return nil
+}
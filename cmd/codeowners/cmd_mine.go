@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/gmolau/codeowners"
+)
+
+// runMine implements the mine command which prints the review requests the
+// uncommitted changes in the working tree would trigger.
+func runMine(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("mine", flag.ExitOnError)
+	root := flags.String("root", ".", "dir inside the repo")
+	staged := flags.Bool("staged", false, "only consider staged changes")
+	format := flags.String("format", FormatText, "output format: text, json, yaml or csv")
+	jsonOutput := flags.Bool("json", false, "shorthand for --format json")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s mine [flags]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args) // Exits on error
+
+	if *jsonOutput {
+		*format = FormatJSON
+	}
+	if err := validFormat(*format); err != nil {
+		return err
+	}
+
+	repoRoot, err := resolveRoot(*root, true)
+	if err != nil {
+		return err
+	}
+
+	files, err := codeowners.GitWorkingTreeChanges(ctx, repoRoot, *staged)
+	if err != nil {
+		return fmt.Errorf("can't determine changed files: %w", err)
+	}
+
+	// The rules of the working tree, as they will be once committed
+	rules, err := loadRules(ctx, repoRoot, false, codeowners.Options{})
+	if err != nil {
+		return err
+	}
+
+	requests := codeowners.ResolveReviewRequests(rules, files)
+
+	if *format != FormatText {
+		return writeFormatted(os.Stdout, *format, requests)
+	}
+
+	return writeReviewRequestsText(os.Stdout, requests)
+}
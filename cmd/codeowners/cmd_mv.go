@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/gmolau/codeowners"
+)
+
+// runMv implements the mv command which moves a path together with its
+// ownership and regenerates the CODEOWNERS file.
+func runMv(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("mv", flag.ExitOnError)
+	root := flags.String("root", ".", "dir inside the repo")
+	noDiscover := flags.Bool("no-discover", false, "use the given root as is instead of the enclosing git repository")
+	noGenerate := flags.Bool("no-generate", false, "don't regenerate "+codeowners.GeneratedFileName+" after the move")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s mv [flags] old/path new/path\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args) // Exits on error
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	repoRoot, err := resolveRoot(*root, !*noDiscover)
+	if err != nil {
+		return err
+	}
+
+	// Like git mv, the paths are relative to the current dir
+	from, err := repoRelativePath(repoRoot, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	to, err := repoRelativePath(repoRoot, flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	result, err := codeowners.MoveOwnership(ctx, repoRoot, from, to)
+	if err != nil {
+		return err
+	}
+
+	if !result.Moved {
+		log.Printf("%s was already moved to %s, updating the ownership only", from, to)
+	}
+	for _, source := range result.Relocated {
+		fmt.Printf("relocated %s\n", source)
+	}
+	for _, update := range result.Updates {
+		fmt.Printf("%s:%d: %s -> %s\n", update.Source, update.Line, update.OldPattern, update.NewPattern)
+	}
+	for _, finding := range result.Findings {
+		log.Print(finding)
+	}
+
+	if *noGenerate {
+		return nil
+	}
+
+	// Only an existing generated file is regenerated, with default options
+	// like pr-comment expects it
+	path := filepath.Join(repoRoot, codeowners.GeneratedFileName)
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+
+	rules, err := codeowners.RewriteCodeownersRules(ctx, repoRoot, codeowners.Options{})
+	if err != nil {
+		return fmt.Errorf("error while rewriting codeowner rules: %w", err)
+	}
+
+	return codeowners.WriteIfChanged(path, codeowners.GenerateCodeownersFile(rules, codeowners.GenerateOptions{}))
+}
+
+// repoRelativePath makes p, which is relative to the current dir, relative to
+// the repo root.
+func repoRelativePath(root, p string) (string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+
+	absPath, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+
+	relPath, err := filepath.Rel(absRoot, absPath)
+	if err != nil {
+		return "", fmt.Errorf("can't make %s relative to %s: %w", p, root, err)
+	}
+
+	return filepath.ToSlash(relPath), nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gmolau/codeowners"
+)
+
+// ownedResult is the JSON representation of everything owned by an owner.
+type ownedResult struct {
+	Owner    string         `json:"owner"`
+	Patterns []ownedPattern `json:"patterns"`
+	Files    []string       `json:"files,omitempty"`
+}
+
+// ownedPattern is the JSON representation of a rule owned by an owner.
+type ownedPattern struct {
+	Pattern string   `json:"pattern"`
+	Owners  []string `json:"owners"`
+	Source  string   `json:"source"`
+}
+
+// runFilesOwnedBy implements the files-owned-by command which lists the
+// patterns and optionally the files effectively owned by an owner.
+func runFilesOwnedBy(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("files-owned-by", flag.ExitOnError)
+	root := flags.String("root", ".", "dir inside the repo whose CODEOWNERS files are evaluated")
+	noDiscover := flags.Bool("no-discover", false, "use the given root as is instead of the enclosing git repository")
+	listFiles := flags.Bool("files", false, "list every file effectively owned instead of only the patterns")
+	format := flags.String("format", FormatText, "output format: text, json, yaml or csv")
+	jsonOutput := flags.Bool("json", false, "shorthand for --format json")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s files-owned-by [flags] owner\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args) // Exits on error
+
+	if *jsonOutput {
+		*format = FormatJSON
+	}
+	if err := validFormat(*format); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected exactly one owner, got %d", flags.NArg())
+	}
+	owner := flags.Arg(0)
+
+	repoRoot, err := resolveRoot(*root, !*noDiscover)
+	if err != nil {
+		return err
+	}
+
+	rules, err := loadRules(ctx, repoRoot, false, codeowners.Options{})
+	if err != nil {
+		return err
+	}
+
+	result := ownedResult{Owner: owner, Patterns: []ownedPattern{}}
+	for _, rule := range codeowners.OwnedPatterns(rules, owner) {
+		result.Patterns = append(result.Patterns, ownedPattern{
+			Pattern: rule.Pattern,
+			Owners:  rule.Owners,
+			Source:  rule.Location(),
+		})
+	}
+
+	if *listFiles {
+		result.Files, err = codeowners.OwnedFiles(ctx, repoRoot, rules, owner)
+		if err != nil {
+			return err
+		}
+	}
+
+	if *format != FormatText {
+		return writeFormatted(os.Stdout, *format, result)
+	}
+
+	var lines []string
+	if *listFiles {
+		lines = result.Files
+	} else {
+		for _, pattern := range result.Patterns {
+			lines = append(lines, pattern.Pattern)
+		}
+	}
+
+	if len(lines) == 0 {
+		return nil
+	}
+
+	_, err = fmt.Println(strings.Join(lines, "\n"))
+	return err
+}
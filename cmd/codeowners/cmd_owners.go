@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gmolau/codeowners"
+)
+
+// runListOwners implements the list-owners command which prints the distinct
+// owners referenced by the nested CODEOWNERS files.
+func runListOwners(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("list-owners", flag.ExitOnError)
+	root := flags.String("root", ".", "dir inside the repo whose CODEOWNERS files are evaluated")
+	noDiscover := flags.Bool("no-discover", false, "use the given root as is instead of the enclosing git repository")
+	format := flags.String("format", FormatText, "output format: text, json, yaml or csv")
+	jsonOutput := flags.Bool("json", false, "shorthand for --format json")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s list-owners [flags]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args) // Exits on error
+
+	if *jsonOutput {
+		*format = FormatJSON
+	}
+	if err := validFormat(*format); err != nil {
+		return err
+	}
+
+	rules, err := loadRules(ctx, *root, !*noDiscover, codeowners.Options{})
+	if err != nil {
+		return err
+	}
+
+	owners := codeowners.ListOwners(rules)
+
+	if *format != FormatText {
+		return writeFormatted(os.Stdout, *format, owners)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "OWNER\tRULES\tSOURCES")
+	for _, owner := range owners {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", owner.Owner, owner.Rules, strings.Join(owner.Sources, ", "))
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/gmolau/codeowners"
+)
+
+// runPrune implements the prune command which removes the rules of deleted
+// paths from the nested CO files and prints the cleanup as diff.
+func runPrune(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("prune", flag.ExitOnError)
+	root := flags.String("root", ".", "dir inside the repo to prune")
+	noDiscover := flags.Bool("no-discover", false, "use the given root as is instead of the enclosing git repository")
+	dryRun := flags.Bool("dry-run", false, "only print the diff without modifying the files")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s prune [flags]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args) // Exits on error
+
+	repoRoot, err := resolveRoot(*root, !*noDiscover)
+	if err != nil {
+		return err
+	}
+
+	files, err := codeowners.PruneStaleRules(ctx, repoRoot)
+	if err != nil {
+		return err
+	}
+
+	removed := 0
+	for _, file := range files {
+		removed += len(file.Removed)
+		_, err = os.Stdout.WriteString(file.Diff())
+		if err != nil {
+			return err
+		}
+	}
+
+	if *dryRun {
+		log.Printf("would remove %d stale rules from %d CODEOWNERS files", removed, len(files))
+		return nil
+	}
+
+	err = codeowners.WritePrunedFiles(repoRoot, files)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("removed %d stale rules from %d CODEOWNERS files", removed, len(files))
+	return nil
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gmolau/codeowners"
+)
+
+// runQuery implements the query command which prints the owners of the given
+// paths, relative to the current dir, or of the newline separated paths read
+// from stdin if the only argument is "-", which are relative to the repo root
+// like the output of git diff --name-only.
+func runQuery(ctx context.Context, args []string) error {
+	return runQueryCommand(ctx, "query", args)
+}
+
+// runWhoOwns implements the who-owns command, an alias of query.
+func runWhoOwns(ctx context.Context, args []string) error {
+	return runQueryCommand(ctx, "who-owns", args)
+}
+
+// runQueryCommand implements the query command under the given name.
+func runQueryCommand(ctx context.Context, name string, args []string) error {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	root := flags.String("root", ".", "dir inside the repo whose CODEOWNERS files are queried")
+	noDiscover := flags.Bool("no-discover", false, "use the given root as is instead of the enclosing git repository")
+	format := flags.String("format", FormatText, "output format: text, json (one object per line), yaml (one document per path) or csv")
+	jsonOutput := flags.Bool("json", false, "shorthand for --format json")
+	asOf := flags.String("as-of", "", "query the ownership at a past date (YYYY-MM-DD) or commit")
+	cache := flags.Bool("cache", false, "cache the rules in the git dir until HEAD or the uncommitted changes change")
+	ownership := addOwnershipFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s %s [flags] path... | -\n", os.Args[0], name)
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args) // Exits on error
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("no paths given")
+	}
+	if *jsonOutput {
+		*format = FormatJSON
+	}
+	if err := validFormat(*format); err != nil {
+		return err
+	}
+	if *asOf != "" && (*ownership.teams != "" || *ownership.readmes) {
+		return fmt.Errorf("--as-of can't be combined with --teams or --readme-owners")
+	}
+
+	repoRoot, err := resolveRoot(*root, !*noDiscover)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := ownership.config(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	opts := ownership.options()
+	var rules []codeowners.Rule
+	switch {
+	case *asOf != "":
+		rules, err = loadRulesAsOf(ctx, repoRoot, false, *asOf, opts)
+	case *cache:
+		rules, err = loadRulesCached(ctx, repoRoot, false, opts, cfg.Policy.NeverOwned)
+	default:
+		rules, err = loadRules(ctx, repoRoot, false, opts)
+	}
+	if err != nil {
+		return err
+	}
+	if !*cache { // The cache includes the never-owned rules
+		rules = append(rules, codeowners.NeverOwnedRules(cfg.Policy.NeverOwned, opts)...)
+	}
+
+	matcher := codeowners.NewMatcher(rules)
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	first := true
+	printResult := func(path string) error {
+		// Dirs of the checkout are resolved as dirs, the past ones only
+		// with a trailing "/"
+		if *asOf == "" && !strings.HasSuffix(path, "/") && isDir(filepath.Join(repoRoot, filepath.FromSlash(path))) {
+			path += "/"
+		}
+
+		result := codeowners.QueryOwners(matcher, path)
+		header := first
+		first = false
+
+		switch *format {
+		case FormatText:
+			return writeQueryText(out, result)
+		case FormatJSON:
+			return json.NewEncoder(out).Encode(result)
+		case FormatYAML:
+			_, err := fmt.Fprintln(out, "---")
+			if err != nil {
+				return err
+			}
+			return writeYAML(out, result)
+		default:
+			return writeCSV(out, tableRows(result), header)
+		}
+	}
+
+	if flags.NArg() == 1 && flags.Arg(0) == "-" {
+		return forEachLine(os.Stdin, func(path string) error {
+			err := printResult(path)
+			if err != nil {
+				return err
+			}
+
+			// Flush per line so that the command works interactively in pipelines
+			return out.Flush()
+		})
+	}
+
+	for _, arg := range flags.Args() {
+		path, err := queryPath(repoRoot, arg)
+		if err != nil {
+			return err
+		}
+
+		err = printResult(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// queryPath makes a path argument of query, which is relative to the current
+// dir, relative to the repo root like the patterns. The trailing "/" of a dir
+// is kept.
+func queryPath(repoRoot, arg string) (string, error) {
+	path, err := repoRelativePath(repoRoot, arg)
+	if err != nil {
+		return "", err
+	}
+
+	if path == ".." || strings.HasPrefix(path, "../") {
+		return "", fmt.Errorf("path %s is outside of the repo %s", arg, repoRoot)
+	}
+
+	if strings.HasSuffix(filepath.ToSlash(arg), "/") && !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+
+	return path, nil
+}
+
+// writeQueryText writes the path followed by its owners, separated by spaces.
+// Unowned paths are written without owners.
+func writeQueryText(w io.Writer, result codeowners.QueryResult) error {
+	line := result.Path
+	if len(result.Owners) > 0 {
+		line = fmt.Sprintf("%s %s", result.Path, strings.Join(result.Owners, " "))
+	}
+
+	_, err := fmt.Fprintln(w, line)
+	return err
+}
+
+// isDir checks whether path is an existing dir.
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// forEachLine calls fn with every non-empty line of r, without surrounding
+// whitespace.
+func forEachLine(r io.Reader, fn func(line string) error) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		err := fn(line)
+		if err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
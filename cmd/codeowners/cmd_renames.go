@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/gmolau/codeowners"
+)
+
+// runRenames implements the renames command which reports the rules of the
+// nested CO files that point to renamed dirs or files.
+func runRenames(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("renames", flag.ExitOnError)
+	root := flags.String("root", ".", "dir inside the repo to check")
+	noDiscover := flags.Bool("no-discover", false, "use the given root as is instead of the enclosing git repository")
+	base := flags.String("base", "", "revision to detect renames since (default the last commit of "+codeowners.GeneratedFileName+")")
+	format := flags.String("format", FormatText, "output format: text, json, yaml or csv")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s renames [flags]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args) // Exits on error
+
+	if err := validFormat(*format); err != nil {
+		return err
+	}
+
+	repoRoot, err := resolveRoot(*root, !*noDiscover)
+	if err != nil {
+		return err
+	}
+
+	findings, err := codeowners.SuggestRenameMigrations(ctx, repoRoot, *base)
+	if err != nil {
+		return err
+	}
+
+	if *format != FormatText {
+		if findings == nil {
+			findings = []codeowners.Finding{}
+		}
+		return writeFormatted(os.Stdout, *format, findings)
+	}
+
+	for _, finding := range findings {
+		fmt.Println(finding)
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/gmolau/codeowners"
+)
+
+// runRequestReviews implements the request-reviews command which requests
+// reviews of a pull request from the owners of its changed files.
+func runRequestReviews(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("request-reviews", flag.ExitOnError)
+	root := flags.String("root", ".", "dir inside the repo whose CODEOWNERS files are evaluated")
+	repo := flags.String("repo", os.Getenv("GITHUB_REPOSITORY"), "GitHub repository as owner/name (default $GITHUB_REPOSITORY)")
+	number := flags.Int("pr", 0, "number of the pull request")
+	token := flags.String("token", "", "GitHub token (default $GITHUB_TOKEN)")
+	dryRun := flags.Bool("dry-run", false, "print the reviewers instead of requesting reviews")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s request-reviews [flags]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args) // Exits on error
+
+	if *repo == "" || *number <= 0 {
+		flags.Usage()
+		return fmt.Errorf("--repo and --pr are required")
+	}
+
+	client := codeowners.NewGitHubClient(codeowners.GitHubToken(*token))
+
+	pr, err := client.PullRequest(ctx, *repo, *number)
+	if err != nil {
+		return err
+	}
+
+	files, err := client.PullRequestFiles(ctx, *repo, *number)
+	if err != nil {
+		return err
+	}
+
+	rules, err := loadRules(ctx, *root, true, codeowners.Options{})
+	if err != nil {
+		return err
+	}
+
+	requests := codeowners.ResolveReviewRequests(rules, files)
+	users, teams := splitReviewers(requests, repoOrg(*repo), pr.User.Login)
+
+	if *dryRun || (len(users) == 0 && len(teams) == 0) {
+		fmt.Printf("users: %s\nteams: %s\n", strings.Join(users, " "), strings.Join(teams, " "))
+		return nil
+	}
+
+	return client.RequestReviewers(ctx, *repo, *number, users, teams)
+}
+
+// splitReviewers splits the requested owners into user logins and team slugs
+// as expected by the GitHub API. Emails, teams of other orgs and the author
+// of the pull request can't be requested and are skipped with a warning.
+func splitReviewers(requests codeowners.ReviewRequests, org, author string) (users, teams []string) {
+	for _, owner := range requests.Owners {
+		name := strings.TrimPrefix(owner.Owner, "@")
+		teamOrg, slug, isTeam := codeowners.SplitTeam(owner.Owner)
+
+		switch {
+		case !strings.HasPrefix(owner.Owner, "@"):
+			log.Printf("warning: can't request review from email owner %s", owner.Owner)
+		case isTeam:
+			if !strings.EqualFold(teamOrg, org) {
+				log.Printf("warning: can't request review from team %s outside of org %s", owner.Owner, org)
+				continue
+			}
+			teams = append(teams, slug)
+		case strings.EqualFold(name, author):
+			// GitHub rejects review requests from the author
+		default:
+			users = append(users, name)
+		}
+	}
+
+	return users, teams
+}
+
+// repoOrg returns the owner part of a repository "owner/name".
+func repoOrg(repo string) string {
+	return strings.SplitN(repo, "/", 2)[0]
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gmolau/codeowners"
+)
+
+// runScaffold implements the scaffold command which creates nested CO files
+// for the dirs of the teams manifest that don't have one yet, or for the
+// packages of the repo with owners inferred from the git history.
+func runScaffold(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("scaffold", flag.ExitOnError)
+	root := flags.String("root", ".", "dir inside the repo to scaffold")
+	noDiscover := flags.Bool("no-discover", false, "use the given root as is instead of the enclosing git repository")
+	teams := flags.String("teams", codeowners.TeamsManifestFileName, "teams manifest relative to the repo root")
+	packages := flags.String("packages", "", "scaffold the packages of these kinds instead of the teams manifest, a comma separated list of "+strings.Join(codeowners.PackageKinds, ", "))
+	since := flags.String("since", "1 year ago", "with --packages, infer the owners from the commits since this date")
+	maxOwners := flags.Int("max-owners", 2, "with --packages, the maximum number of owners per package, the authors of the most commits")
+	dryRun := flags.Bool("dry-run", false, "only print the files that would be created")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s scaffold [flags]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args) // Exits on error
+
+	repoRoot, err := resolveRoot(*root, !*noDiscover)
+	if err != nil {
+		return err
+	}
+
+	if *packages != "" {
+		return scaffoldPackages(ctx, repoRoot, *packages, *since, *maxOwners, *dryRun)
+	}
+
+	manifest, err := codeowners.LoadTeamsManifest(repoRoot, *teams)
+	if err != nil {
+		return err
+	}
+
+	created, err := codeowners.ScaffoldCodeownersFiles(repoRoot, manifest, *dryRun)
+	for _, file := range created {
+		fmt.Println(file)
+	}
+
+	return err
+}
+
+// scaffoldPackages scaffolds the packages of the kinds and prints the files
+// with their owners.
+func scaffoldPackages(ctx context.Context, root, kinds, since string, maxOwners int, dryRun bool) error {
+	parsedKinds, err := codeowners.ParsePackageKinds(kinds)
+	if err != nil {
+		return err
+	}
+
+	files, err := codeowners.ListFiles(ctx, root)
+	if err != nil {
+		return err
+	}
+
+	packages, err := codeowners.FindPackages(root, files, parsedKinds)
+	if err != nil {
+		return err
+	}
+
+	scaffolds, err := codeowners.ScaffoldPackages(ctx, root, packages, since, maxOwners, dryRun)
+	for _, scaffold := range scaffolds {
+		fmt.Printf("%s %s (%s)\n", scaffold.File, strings.Join(scaffold.Owners, " "), scaffold.Package.Label())
+	}
+
+	return err
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gmolau/codeowners"
+)
+
+// runServe implements the serve command which answers ownership queries over
+// HTTP and keeps the rules up to date by polling the repo.
+func runServe(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	root := flags.String("root", ".", "dir inside the repo whose CODEOWNERS files are served")
+	noDiscover := flags.Bool("no-discover", false, "use the given root as is instead of the enclosing git repository")
+	addr := flags.String("addr", ":8080", "address to listen on")
+	interval := flags.Duration("interval", time.Minute, "interval in which the rules are rebuilt, 0 disables polling")
+	tokenFile := flags.String("token-file", "", "file with the accepted bearer tokens, one per line, in addition to $"+codeowners.ServerTokenEnv)
+	tlsCert := flags.String("tls-cert", "", "serve HTTPS with this PEM certificate")
+	tlsKey := flags.String("tls-key", "", "PEM key of --tls-cert")
+	pull := flags.Bool("pull", false, "fast-forward the checkout with git pull before every build")
+	webhookSecretFile := flags.String("webhook-secret-file", "", "file with the secret of the GitHub webhook at /-/webhook (default $"+codeowners.WebhookSecretEnv+"), which triggers a build on push")
+	clientCA := flags.String("client-ca", "", "require client certificates signed by the CAs in this PEM file (mTLS), requires --tls-cert")
+	ownership := addOwnershipFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s serve [flags]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args) // Exits on error
+
+	repoRoot, err := resolveRoot(*root, !*noDiscover)
+	if err != nil {
+		return err
+	}
+
+	if (*tlsCert == "") != (*tlsKey == "") {
+		return fmt.Errorf("--tls-cert and --tls-key must be given together")
+	}
+	if *clientCA != "" && *tlsCert == "" {
+		return fmt.Errorf("--client-ca requires --tls-cert")
+	}
+
+	tokens, err := codeowners.LoadServerTokens(*tokenFile)
+	if err != nil {
+		return err
+	}
+
+	webhookSecret, err := codeowners.LoadWebhookSecret(*webhookSecretFile)
+	if err != nil {
+		return err
+	}
+
+	serverOpts := codeowners.ServerOptions{Pull: *pull, ConfigFile: *ownership.configFile, WebhookSecret: webhookSecret}
+	server := codeowners.NewServer(repoRoot, ownership.options(), serverOpts)
+	handler := server.Handler()
+	if len(tokens) > 0 {
+		handler = codeowners.RequireBearerToken(tokens, handler)
+	}
+
+	httpServer := &http.Server{Addr: *addr, Handler: handler}
+	if *clientCA != "" {
+		httpServer.TLSConfig, err = codeowners.ClientCATLSConfig(*clientCA)
+		if err != nil {
+			return err
+		}
+		httpServer.Handler = codeowners.RequireClientCert(handler)
+	}
+	if len(tokens) == 0 && *clientCA == "" {
+		log.Printf("warning: serving without authentication, set $%s, --token-file or --client-ca", codeowners.ServerTokenEnv)
+	}
+
+	// The first build runs in the background, /readyz reports when it's done
+	go func() {
+		if err := server.Reload(ctx); err != nil && ctx.Err() == nil {
+			log.Print(err)
+		}
+		server.Poll(ctx, *interval)
+	}()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("serving the owners of %s on %s", repoRoot, *addr)
+	if *tlsCert != "" {
+		err = httpServer.ListenAndServeTLS(*tlsCert, *tlsKey)
+	} else {
+		err = httpServer.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gmolau/codeowners"
+)
+
+// runSimulate implements the simulate command which prints the review
+// requests a pull request from head into base would trigger.
+func runSimulate(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+	root := flags.String("root", ".", "dir inside the repo")
+	base := flags.String("base", "origin/main", "base revision of the simulated pull request")
+	head := flags.String("head", "HEAD", "head revision of the simulated pull request")
+	format := flags.String("format", FormatText, "output format: text, json, yaml or csv")
+	jsonOutput := flags.Bool("json", false, "shorthand for --format json")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s simulate [flags]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args) // Exits on error
+
+	if *jsonOutput {
+		*format = FormatJSON
+	}
+	if err := validFormat(*format); err != nil {
+		return err
+	}
+
+	repoRoot, err := resolveRoot(*root, true)
+	if err != nil {
+		return err
+	}
+
+	files, err := codeowners.GitChangedFiles(ctx, repoRoot, *base, *head)
+	if err != nil {
+		return fmt.Errorf("can't determine changed files: %w", err)
+	}
+
+	rules, err := loadRules(ctx, repoRoot, false, codeowners.Options{})
+	if err != nil {
+		return err
+	}
+
+	requests := codeowners.ResolveReviewRequests(rules, files)
+
+	if *format != FormatText {
+		return writeFormatted(os.Stdout, *format, requests)
+	}
+
+	return writeReviewRequestsText(os.Stdout, requests)
+}
+
+// writeReviewRequestsText writes the changed files grouped by owner, followed
+// by the files without owner.
+func writeReviewRequestsText(w io.Writer, requests codeowners.ReviewRequests) error {
+	for _, owner := range requests.Owners {
+		fmt.Fprintf(w, "%s (%s)\n", owner.Owner, codeowners.Pluralize(len(owner.Files), "file"))
+		for _, file := range owner.Files {
+			fmt.Fprintf(w, "  %s\n", file)
+		}
+	}
+
+	if len(requests.Unowned) > 0 {
+		fmt.Fprintf(w, "No reviewers (%s)\n", codeowners.Pluralize(len(requests.Unowned), "file"))
+		for _, file := range requests.Unowned {
+			fmt.Fprintf(w, "  %s\n", file)
+		}
+	}
+
+	return nil
+}
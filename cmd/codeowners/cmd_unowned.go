@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/gmolau/codeowners"
+)
+
+// runUnowned implements the unowned command which lists the files of the repo
+// without owner and optionally fails if there are any.
+func runUnowned(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("unowned", flag.ExitOnError)
+	root := flags.String("root", ".", "dir inside the repo whose CODEOWNERS files are evaluated")
+	noDiscover := flags.Bool("no-discover", false, "use the given root as is instead of the enclosing git repository")
+	configFile := flags.String("config", "", "config file (default "+codeowners.ConfigFileName+" in the repo root)")
+	errorOnUnowned := flags.Bool("error-on-unowned", false, "exit with code 2 if any file is unowned, e.g. to enforce full coverage in CI")
+	format := flags.String("format", FormatText, "output format: text (one file per line), json, yaml or csv")
+	jsonOutput := flags.Bool("json", false, "shorthand for --format json")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s unowned [flags]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args) // Exits on error
+
+	if *jsonOutput {
+		*format = FormatJSON
+	}
+	if err := validFormat(*format); err != nil {
+		return err
+	}
+
+	repoRoot, err := resolveRoot(*root, !*noDiscover)
+	if err != nil {
+		return err
+	}
+
+	rules, files, err := loadCoverageInputs(ctx, repoRoot, *configFile)
+	if err != nil {
+		return err
+	}
+
+	coverage := codeowners.ComputeCoverage(rules, files)
+	if *format == FormatText {
+		for _, file := range coverage.Unowned {
+			_, err = fmt.Println(file)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		err = writeFormatted(os.Stdout, *format, unownedFiles(coverage.Unowned))
+		if err != nil {
+			return err
+		}
+	}
+
+	if *errorOnUnowned && len(coverage.Unowned) > 0 {
+		log.Printf("%d of %s unowned", len(coverage.Unowned), codeowners.Pluralize(coverage.Files, "file"))
+		os.Exit(exitCodeFindings)
+	}
+
+	return nil
+}
+
+// unownedFiles is the structured output of the unowned command.
+type unownedFiles []string
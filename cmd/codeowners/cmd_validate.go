@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gmolau/codeowners"
+)
+
+// runValidate implements the validate command which parses the nested CO
+// files like generate does, without generating anything, and reports the
+// rules that would be dropped and optionally the owners unknown to GitHub.
+func runValidate(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	root := flags.String("root", ".", "dir inside the repo to validate")
+	noDiscover := flags.Bool("no-discover", false, "use the given root as is instead of the enclosing git repository")
+	configFile := flags.String("config", "", "config file (default "+codeowners.ConfigFileName+" in the repo root)")
+	teams := flags.String("teams", "", "teams manifest relative to the repo root whose rules are validated too")
+	readmes := flags.Bool("readme-owners", false, "also validate the owners declared in the front matter of "+codeowners.ReadmeFileName+" files")
+	format := flags.String("format", FormatText, "output format: text, json, yaml, csv or sarif")
+	github := flags.Bool("github", false, "check that the teams exist and the users are org members via the GitHub API")
+	token := flags.String("token", "", "GitHub token for --github with read access to the orgs (default $GITHUB_TOKEN)")
+	orgs := flags.String("org", "", "with --github, comma separated orgs whose members may own code besides the orgs of the teams")
+	strict := flags.Bool("strict", false, "report all warnings as errors, like strict in the config")
+	flags.BoolVar(strict, "Werror", false, "alias for --strict")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s validate [flags]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args) // Exits on error
+
+	if err := validFormat(*format, FormatSARIF); err != nil {
+		return err
+	}
+
+	repoRoot, err := resolveRoot(*root, !*noDiscover)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := codeowners.LoadConfig(repoRoot, *configFile)
+	if err != nil {
+		return err
+	}
+
+	result := validateResult{Findings: []codeowners.Finding{}}
+	rules, err := codeowners.RewriteCodeownersRules(ctx, repoRoot, codeowners.Options{
+		TeamsManifest: *teams,
+		ReadmeOwners:  *readmes,
+		Diagnostics: func(finding codeowners.Finding) {
+			result.Findings = append(result.Findings, finding)
+		},
+		Inputs: func(string) {
+			result.Files++
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error while validating codeowner rules in %s: %w", repoRoot, err)
+	}
+	result.Rules = len(rules)
+
+	if *github {
+		var orgList []string
+		if *orgs != "" {
+			orgList = strings.Split(*orgs, ",")
+		}
+
+		findings, err := codeowners.CheckGitHubOwners(ctx, codeowners.NewGitHubClient(codeowners.GitHubToken(*token)), rules, orgList)
+		if err != nil {
+			return fmt.Errorf("error while checking owners on GitHub: %w", err)
+		}
+		result.Findings = append(result.Findings, findings...)
+	}
+
+	result.Findings = codeowners.ApplySeverities(result.Findings, cfg.Severities, cfg.Strict || *strict)
+	codeowners.SortFindings(result.Findings)
+
+	switch *format {
+	case FormatText:
+		err = writeValidateText(os.Stdout, result)
+	case FormatSARIF:
+		err = writeJSON(os.Stdout, toSARIF(result.Findings))
+	default:
+		err = writeFormatted(os.Stdout, *format, result)
+	}
+	if err != nil {
+		return err
+	}
+
+	if codeowners.HasErrors(result.Findings) {
+		os.Exit(exitCodeFindings)
+	}
+
+	return nil
+}
+
+// validateResult is the structured output of the validate command, the CSV
+// output only lists the findings.
+type validateResult struct {
+	Files    int                  `json:"files"`
+	Rules    int                  `json:"rules"`
+	Findings []codeowners.Finding `json:"findings"`
+}
+
+// writeValidateText writes the findings followed by a summary.
+func writeValidateText(w io.Writer, result validateResult) error {
+	for _, finding := range result.Findings {
+		_, err := fmt.Fprintln(w, finding)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%s with %s, %s\n", codeowners.Pluralize(result.Files, "CODEOWNERS file"), codeowners.Pluralize(result.Rules, "rule"), codeowners.Pluralize(len(result.Findings), "finding"))
+	return err
+}
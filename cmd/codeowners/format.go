@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gmolau/codeowners"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats of the --format flag shared by the commands. Every command
+// supports text and the structured formats, YAML uses the same schema as
+// JSON, CSV the rows of tableRows.
+const (
+	FormatText  = "text"
+	FormatJSON  = "json"
+	FormatYAML  = "yaml"
+	FormatCSV   = "csv"
+	FormatSARIF = "sarif"
+)
+
+// structuredFormats are the formats writeFormatted supports.
+var structuredFormats = []string{FormatJSON, FormatYAML, FormatCSV}
+
+// validFormat checks whether format is text, a structured format or one of
+// the extra formats of a command.
+func validFormat(format string, extra ...string) error {
+	formats := append(append([]string{FormatText}, structuredFormats...), extra...)
+	if codeowners.ContainsString(formats, format) {
+		return nil
+	}
+
+	return fmt.Errorf("unknown format %s, must be one of %s", format, strings.Join(formats, ", "))
+}
+
+// reportFileFormat derives the format of a report file from its extension,
+// JSON unless it is .yaml, .yml or .csv.
+func reportFileFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".csv":
+		return FormatCSV
+	default:
+		return FormatJSON
+	}
+}
+
+// writeFormatted writes v in one of the structured formats.
+func writeFormatted(w io.Writer, format string, v interface{}) error {
+	switch format {
+	case FormatJSON:
+		return writeJSON(w, v)
+	case FormatYAML:
+		return writeYAML(w, v)
+	case FormatCSV:
+		return writeCSV(w, tableRows(v), true)
+	default:
+		return fmt.Errorf("unknown format %s", format)
+	}
+}
+
+// writeYAML writes v as YAML with the field names of its JSON encoding.
+func writeYAML(w io.Writer, v interface{}) error {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	// JSON is YAML, only its flow style and quotes have to be dropped
+	var doc yaml.Node
+	err = yaml.Unmarshal(content, &doc)
+	if err != nil {
+		return err
+	}
+	resetYAMLStyle(&doc)
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	err = encoder.Encode(&doc)
+	if err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// resetYAMLStyle switches node and its children to the default block style.
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetYAMLStyle(child)
+	}
+}
+
+// writeCSV writes the rows as CSV, the first row is the header, which is
+// skipped unless header is set.
+func writeCSV(w io.Writer, rows [][]string, header bool) error {
+	if !header {
+		rows = rows[1:]
+	}
+
+	writer := csv.NewWriter(w)
+	err := writer.WriteAll(rows)
+	if err != nil {
+		return err
+	}
+
+	return writer.Error()
+}
+
+// tableRows returns the CSV rows of the outputs of the commands, starting with
+// the header. Nested lists like owners are joined by spaces.
+func tableRows(v interface{}) [][]string {
+	switch v := v.(type) {
+	case []codeowners.Finding:
+		rows := [][]string{{"id", "check", "severity", "file", "line", "column", "message", "fixable"}}
+		for _, f := range v {
+			rows = append(rows, []string{f.ID(), f.Check, string(f.Severity), f.File, formatInt(f.Line), formatInt(f.Column), f.Message, strconv.FormatBool(f.Fixable)})
+		}
+		return rows
+	case codeowners.AuditReport:
+		return tableRows(v.Findings)
+	case lintResult:
+		return tableRows(v.Findings)
+	case validateResult:
+		return tableRows(v.Findings)
+	case unownedFiles:
+		rows := [][]string{{"file"}}
+		for _, file := range v {
+			rows = append(rows, []string{file})
+		}
+		return rows
+	case codeowners.Coverage:
+		return [][]string{{"files", "owned", "percent"}, {strconv.Itoa(v.Files), strconv.Itoa(v.Owned), formatPercent(v.Percent())}}
+	case []codeowners.DirCoverage:
+		rows := [][]string{{"dir", "files", "owned", "unowned", "percent"}}
+		for _, d := range v {
+			rows = append(rows, []string{d.Dir, strconv.Itoa(d.Files), strconv.Itoa(d.Owned), strconv.Itoa(d.Unowned), formatPercent(d.Percent)})
+		}
+		return rows
+	case []codeowners.OwnerSummary:
+		rows := [][]string{{"owner", "rules", "sources"}}
+		for _, o := range v {
+			rows = append(rows, []string{o.Owner, strconv.Itoa(o.Rules), strings.Join(o.Sources, " ")})
+		}
+		return rows
+	case ownedResult:
+		if v.Files != nil {
+			rows := [][]string{{"file"}}
+			for _, file := range v.Files {
+				rows = append(rows, []string{file})
+			}
+			return rows
+		}
+
+		rows := [][]string{{"pattern", "owners", "source"}}
+		for _, p := range v.Patterns {
+			rows = append(rows, []string{p.Pattern, strings.Join(p.Owners, " "), p.Source})
+		}
+		return rows
+	case []blameResult:
+		rows := [][]string{{"rule", "source", "commit", "author", "email", "time", "summary"}}
+		for _, b := range v {
+			rows = append(rows, []string{b.Rule, b.Source, b.Blame.Commit, b.Blame.Author, b.Blame.Email, b.Blame.Time.Format(time.RFC3339), b.Blame.Summary})
+		}
+		return rows
+	case codeowners.ReviewRequests:
+		// Unowned files have an empty owner
+		rows := [][]string{{"owner", "file"}}
+		for _, o := range v.Owners {
+			for _, file := range o.Files {
+				rows = append(rows, []string{o.Owner, file})
+			}
+		}
+		for _, file := range v.Unowned {
+			rows = append(rows, []string{"", file})
+		}
+		return rows
+	case codeowners.QueryResult:
+		return [][]string{{"path", "owners", "rule", "source", "labels"}, {v.Path, strings.Join(v.Owners, " "), v.Rule, v.Source, strings.Join(v.Labels, " ")}}
+	case *RunReport:
+		rows := [][]string{{"pattern", "owners", "dir", "source", "line", "labels"}}
+		for _, r := range v.Rules {
+			rows = append(rows, []string{r.Pattern, strings.Join(r.Owners, " "), strconv.FormatBool(r.Dir), r.Source, formatInt(r.Line), strings.Join(r.Labels, " ")})
+		}
+		return rows
+	default:
+		panic(fmt.Sprintf("no CSV representation of %T", v))
+	}
+}
+
+// formatInt formats n, leaving unknown values, i.e. 0, empty.
+func formatInt(n int) string {
+	if n == 0 {
+		return ""
+	}
+
+	return strconv.Itoa(n)
+}
+
+// formatPercent formats a percentage with one decimal.
+func formatPercent(p float64) string {
+	return strconv.FormatFloat(p, 'f', 1, 64)
+}
+
+// formattedString is writeFormatted into a string.
+func formattedString(format string, v interface{}) (string, error) {
+	var b bytes.Buffer
+	err := writeFormatted(&b, format, v)
+	return b.String(), err
+}
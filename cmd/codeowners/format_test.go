@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gmolau/codeowners"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFormatted(t *testing.T) {
+	findings := []codeowners.Finding{
+		{Check: "stale-pattern", Severity: codeowners.SeverityWarning, Message: "pattern /gone doesn't match any file", File: "CODEOWNERS", Line: 3},
+		{Check: "min-coverage", Severity: codeowners.SeverityError, Message: "50.0% of files are owned, at least 90.0%, are required"},
+	}
+
+	var b bytes.Buffer
+	require.NoError(t, writeFormatted(&b, FormatYAML, lintResult{Findings: findings}))
+	require.Equal(t, `findings:
+  - id: CO001
+    check: stale-pattern
+    severity: warning
+    message: pattern /gone doesn't match any file
+    file: CODEOWNERS
+    line: 3
+  - id: CO008
+    check: min-coverage
+    severity: error
+    message: 50.0% of files are owned, at least 90.0%, are required
+`, b.String())
+
+	b.Reset()
+	require.NoError(t, writeFormatted(&b, FormatCSV, findings))
+	require.Equal(t, `id,check,severity,file,line,column,message,fixable
+CO001,stale-pattern,warning,CODEOWNERS,3,,pattern /gone doesn't match any file,false
+CO008,min-coverage,error,,,,"50.0% of files are owned, at least 90.0%, are required",false
+`, b.String())
+
+	b.Reset()
+	require.NoError(t, writeFormatted(&b, FormatJSON, codeowners.Coverage{Files: 2, Owned: 1, Unowned: []string{"a"}}))
+	require.JSONEq(t, `{"files": 2, "owned": 1, "unowned": ["a"]}`, b.String())
+
+	b.Reset()
+	require.NoError(t, writeFormatted(&b, FormatCSV, unownedFiles{"README", "docs/x.md"}))
+	require.Equal(t, "file\nREADME\ndocs/x.md\n", b.String())
+
+	b.Reset()
+	requests := codeowners.ReviewRequests{Owners: []codeowners.OwnerFiles{{Owner: "@org/dev", Files: []string{"src/a.go", "src/b.go"}}}, Unowned: []string{"README"}}
+	require.NoError(t, writeFormatted(&b, FormatCSV, requests))
+	require.Equal(t, "owner,file\n@org/dev,src/a.go\n@org/dev,src/b.go\n,README\n", b.String())
+
+	b.Reset()
+	require.NoError(t, writeFormatted(&b, FormatCSV, []codeowners.OwnerSummary{{Owner: "@org/dev", Rules: 2, Sources: []string{"CODEOWNERS", "src/CODEOWNERS"}}}))
+	require.Equal(t, "owner,rules,sources\n@org/dev,2,CODEOWNERS src/CODEOWNERS\n", b.String())
+
+	b.Reset()
+	require.NoError(t, writeFormatted(&b, FormatYAML, ownedResult{Owner: "@org/dev", Patterns: []ownedPattern{{Pattern: "/src", Owners: []string{"@org/dev"}, Source: "src/CODEOWNERS:1"}}}))
+	require.Equal(t, `owner: '@org/dev'
+patterns:
+  - pattern: /src
+    owners:
+      - '@org/dev'
+    source: src/CODEOWNERS:1
+`, b.String())
+
+	require.NoError(t, validFormat(FormatCSV))
+	require.NoError(t, validFormat(FormatSARIF, FormatSARIF))
+	err := validFormat(FormatSARIF)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unknown format sarif, must be one of text, json, yaml, csv")
+
+	require.Equal(t, FormatYAML, reportFileFormat("out/report.YML"))
+	require.Equal(t, FormatCSV, reportFileFormat("report.csv"))
+	require.Equal(t, FormatJSON, reportFileFormat("report"))
+}
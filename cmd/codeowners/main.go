@@ -0,0 +1,933 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gmolau/codeowners"
+)
+
+var (
+	noDiscover    = flag.Bool("no-discover", false, "use the given dir as root instead of the enclosing git repository")
+	pathPrefix    = flag.String("path-prefix", "", "prefix prepended to every generated pattern, e.g. /services/checkout")
+	unanchored    = flag.Bool("unanchored", false, "emit patterns without the leading / so that they match anywhere in the repo")
+	skipRoot      = flag.Bool("skip-root-codeowners", false, "don't process the CODEOWNERS file in the root dir")
+	timeout       = flag.Duration("timeout", 0, "abort with exit code 124 if generation takes longer than this, e.g. 30s (0 means no timeout)")
+	header        = flag.String("header", "", "custom comment block replacing the default warning at the top of the generated file")
+	noHeader      = flag.Bool("no-header", false, "omit the warning at the top of the generated file")
+	keepComments  = flag.Bool("preserve-comments", false, "emit the comment lines above the rules of the nested CODEOWNERS files above the rewritten rules")
+	provenance    = flag.Bool("provenance", false, "emit a \"# from path/to/CODEOWNERS\" comment before every block of rules from the same nested CODEOWNERS file")
+	annotate      = flag.Bool("annotate-source", false, "append the nested CODEOWNERS file and line each rule came from as trailing comment")
+	metadata      = flag.Bool("header-metadata", false, "include the generation time, tool version and source commit in the header")
+	tmplFile      = flag.String("template", "", "render the output with this Go text/template file instead of the CODEOWNERS format")
+	check         = flag.Bool("check", false, "compare the output with "+codeowners.GeneratedFileName+" (or the file of the --target) in the repo like --compare, e.g. in CI")
+	compare       = flag.String("compare", "", "compare the output with this file instead of printing it, print a diff and exit with code 3 if they differ")
+	filesFrom     = flag.String("files", "", "process only the CODEOWNERS files listed in this file (one per line, - for stdin) instead of walking the repo")
+	write         = flag.Bool("write", false, "write "+codeowners.GeneratedFileName+" (or the file of the --target) instead of printing it, missing dirs are created")
+	commit        = flag.Bool("commit", false, "write "+codeowners.GeneratedFileName+" (or the file of the --target) and commit it if it changed")
+	commitMsg     = flag.String("commit-message", defaultCommitMessage, "message of the commit created by --commit, a Go template receiving the same data as --template")
+	push          = flag.Bool("push", false, "push the commit created by --commit")
+	gitLabFile    = flag.String("gitlab-file", codeowners.DefaultGitLabFileName, "location of the generated file for the gitlab target: "+strings.Join(codeowners.GitLabFileNames, ", "))
+	target        = flag.String("target", codeowners.TargetGitHub, "platform to generate the file for: "+strings.Join(codeowners.Targets, ", "))
+	format        = flag.String("format", "", "alias of --target, e.g. --format=gitlab, unlike the --format of the other commands it doesn't select json, yaml or csv")
+	topSections   = flag.Bool("section-per-top-level-dir", false, "for the gitlab target, put the rules of nested CODEOWNERS files without section pragma into a section per top-level dir")
+	materialize   = flag.Bool("materialize", false, "emit explicit rules for dirs without CODEOWNERS file with the owners they inherit")
+	remote        = flag.String("remote", "", "read the CODEOWNERS files of a GitHub repo (host/owner/repo) via the API instead of a local checkout, the token is read from $GITHUB_TOKEN")
+	ref           = flag.String("ref", "HEAD", "branch, tag or commit of the --remote repo")
+	asOf          = flag.String("as-of", "", "generate the file as it would have been at a past date (YYYY-MM-DD) or commit, read from git without checkout")
+	strict        = flag.Bool("strict", false, "fail on anything that is otherwise skipped with a warning, e.g. file rules without owners, invalid owners and unknown pragmas")
+	owner         = flag.String("owner", "", "only emit the rules involving these owners, a comma separated list of owners or globs like @org/*")
+	onlyDirs      = flag.Bool("only-dir-rules", false, "only emit the rules that assign whole dirs, i.e. the owners-only lines of the CODEOWNERS files")
+	onlyFiles     = flag.Bool("only-file-rules", false, "only emit the file and glob rules")
+	layout        = flag.String("layout", codeowners.LayoutSource, "order of the generated rules: "+strings.Join(codeowners.Layouts, ", ")+", owner groups the rules by their owners")
+	ownerDocs     = flag.Bool("ownership-docs", false, "write an "+codeowners.OwnershipDocFileName+" summary into the top dir of every team")
+	skipGenerated = flag.Bool("skip-generated", false, "skip CODEOWNERS files in subtrees marked linguist-generated in .gitattributes")
+	maxFileSize   = flag.Int64("max-file-size", codeowners.DefaultMaxFileSize, "reject CODEOWNERS files larger than this many bytes, which are usually generated or binary files")
+	maxRules      = flag.Int("max-rules", 0, "warn if more rules are generated, naming the CODEOWNERS files contributing the most, fail with --strict (0 means no limit)")
+	allowLarge    = flag.Bool("allow-large-files", false, "process CODEOWNERS files of any size, overrides --max-file-size")
+	reportFile    = flag.String("report-file", "", "write a report of the run (inputs, rules, diagnostics, coverage, timing and drift) to this file, YAML or CSV for .yaml, .yml or .csv files, JSON otherwise")
+	appendMode    = flag.Bool("append", false, "merge the generated rules into the managed region of the existing "+codeowners.GeneratedFileName+" (or the file of the --target) instead of printing them")
+	rootDir       = flag.String("root", "", "dir inside the repo to generate the file for, alternative to the dir argument")
+	outputPath    = flag.String("output", "", "write the generated file to this path instead of printing it, missing dirs are created")
+	dedupe        = flag.Bool("dedupe", false, "drop owners listed twice in a rule and rules repeated later with the same pattern and owners")
+	normalize     = flag.Bool("normalize", false, "spell every owner the way most rules do, e.g. @Org/Team as @org/team, GitHub compares owners case-insensitively")
+	minify        = flag.Bool("minify", false, "drop the rules that assign the owners an enclosing rule already assigns, e.g. /src/foo after /src with the same owners")
+	failConflicts = flag.Bool("fail-on-conflicts", false, "fail if rules of different CODEOWNERS files claim the same files for different owners or a rule never takes effect")
+	ownership     = addOwnershipFlags(flag.CommandLine)
+)
+
+// commands are the subcommands of the CLI, without subcommand the CODEOWNERS
+// file is generated like with generate.
+var commands = map[string]func(ctx context.Context, args []string) error{
+	"query":           runQuery,
+	"who-owns":        runWhoOwns,
+	"validate":        runValidate,
+	"files-owned-by":  runFilesOwnedBy,
+	"list-owners":     runListOwners,
+	"audit":           runAudit,
+	"lint":            runLint,
+	"blame":           runBlame,
+	"simulate":        runSimulate,
+	"request-reviews": runRequestReviews,
+	"pr-comment":      runPRComment,
+	"merge-driver":    runMergeDriver,
+	"azure-policies":  runAzurePolicies,
+	"scaffold":        runScaffold,
+	"prune":           runPrune,
+	"mv":              runMv,
+	"renames":         runRenames,
+	"mine":            runMine,
+	"coverage":        runCoverage,
+	"unowned":         runUnowned,
+	"serve":           runServe,
+	"known-owners":    runKnownOwners,
+	"github-errors":   runGitHubErrors,
+}
+
+// generateCommands are the subcommands that generate the CODEOWNERS file with
+// the global flags, check is generate with --check.
+var generateCommands = []string{"generate", "check"}
+
+func main() {
+	args := os.Args[1:]
+	subcommand := ""
+	if len(args) > 0 {
+		if run, ok := commands[args[0]]; ok {
+			runCommand(run, args[1:])
+			return
+		}
+
+		if codeowners.ContainsString(generateCommands, args[0]) {
+			subcommand, args = args[0], args[1:]
+		}
+	}
+
+	flag.Usage = usage
+	flag.BoolVar(write, "w", false, "shorthand for --write")
+	flag.StringVar(outputPath, "o", "", "shorthand for --output")
+	_ = flag.CommandLine.Parse(args) // Exits on error
+	if subcommand == "check" {
+		*check = true
+	}
+	if *format != "" {
+		if validFormat(*format) == nil {
+			log.Fatal(fmt.Errorf("generate doesn't support --format %s, its --format only selects the platform like --target (%s), use --report-file for structured output", *format, strings.Join(codeowners.Targets, ", ")))
+		}
+		if err := codeowners.ValidTarget(*format); err != nil {
+			log.Fatal(fmt.Errorf("unknown --format %s, generate only accepts platforms like --target: %s", *format, strings.Join(codeowners.Targets, ", ")))
+		}
+
+		targetSet := false
+		flag.Visit(func(f *flag.Flag) {
+			targetSet = targetSet || f.Name == "target"
+		})
+		if targetSet && *target != *format {
+			log.Fatal(fmt.Errorf("--format %s contradicts --target %s", *format, *target))
+		}
+		*target = *format
+	}
+
+	root, err := parseDir()
+	if err != nil {
+		log.Fatal(fmt.Errorf("error while parsing root dir: %w", err))
+	}
+
+	if *remote != "" && (flag.NArg() > 0 || *rootDir != "" || *filesFrom != "" || *materialize || *appendMode || *write || *commit || *ownership.teams != "" || *ownership.readmes) {
+		log.Fatal(fmt.Errorf("--remote can't be combined with a dir, --files, --materialize, --append, --write, --commit, --teams or --readme-owners"))
+	}
+	if *asOf != "" && (*remote != "" || *filesFrom != "" || *materialize || *appendMode || *write || *commit || *ownership.teams != "" || *ownership.readmes) {
+		log.Fatal(fmt.Errorf("--as-of can't be combined with --remote, --files, --materialize, --append, --write, --commit, --teams or --readme-owners"))
+	}
+
+	if *outputPath != "" && (*remote != "" || *check || *compare != "" || *appendMode || *write || *commit) {
+		log.Fatal(fmt.Errorf("--output can't be combined with --remote, --check, --compare, --append, --write or --commit"))
+	}
+
+	if *owner != "" && (*appendMode || *write || *commit) {
+		log.Fatal(fmt.Errorf("--owner can't be combined with --append, --write or --commit"))
+	}
+	if *ownerDocs && (*remote != "" || *asOf != "" || *pathPrefix != "" || *unanchored || *compare != "") {
+		log.Fatal(fmt.Errorf("--ownership-docs can't be combined with --remote, --as-of, --path-prefix, --unanchored or --compare"))
+	}
+	if *minify && *materialize {
+		log.Fatal(fmt.Errorf("--minify can't be combined with --materialize"))
+	}
+	if *failConflicts && (*remote != "" || *asOf != "" || *pathPrefix != "" || *unanchored) {
+		log.Fatal(fmt.Errorf("--fail-on-conflicts can't be combined with --remote, --as-of, --path-prefix or --unanchored"))
+	}
+	if *onlyDirs && *onlyFiles {
+		log.Fatal(fmt.Errorf("--only-dir-rules can't be combined with --only-file-rules"))
+	}
+	if (*onlyDirs || *onlyFiles) && (*appendMode || *write || *commit) {
+		log.Fatal(fmt.Errorf("--only-dir-rules and --only-file-rules can't be combined with --append, --write or --commit"))
+	}
+	ownerFilter, err := codeowners.ParseOwnerFilter(*owner)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if !*noDiscover && *remote == "" {
+		root, err = codeowners.DiscoverRoot(root)
+		if err != nil {
+			log.Fatal(fmt.Errorf("error while discovering repository root: %w", err))
+		}
+	}
+
+	// The config of a remote repo isn't read
+	var cfg codeowners.Config
+	if *remote == "" {
+		cfg, err = ownership.config(root)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var report *RunReport
+	if *reportFile != "" {
+		report = newRunReport(root)
+	}
+
+	opts := ownership.options()
+	opts.PathPrefix = *pathPrefix
+	opts.Unanchored = *unanchored
+	opts.SkipRootCodeowners = *skipRoot
+	opts.SkipGenerated = *skipGenerated
+	opts.MaxFileSize = *maxFileSize
+	opts.Strict = *strict
+	opts.Diagnostics = func(finding codeowners.Finding) {
+		log.Print(finding)
+		if report != nil {
+			report.Diagnostics = append(report.Diagnostics, finding)
+		}
+	}
+
+	if report != nil {
+		opts.Inputs = func(source string) {
+			report.Inputs = append(report.Inputs, source)
+		}
+	}
+
+	if *allowLarge {
+		opts.MaxFileSize = -1
+	}
+
+	if *target == codeowners.TargetGitLab {
+		err = codeowners.ValidGitLabFileName(*gitLabFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts.Output = *gitLabFile
+	}
+
+	if *filesFrom != "" {
+		opts.Files, err = readFileList(*filesFrom)
+		if err != nil {
+			log.Fatal(fmt.Errorf("error while reading CODEOWNERS file list: %w", err))
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	rewrite := func(ctx context.Context) ([]codeowners.Rule, error) {
+		return codeowners.RewriteCodeownersRules(ctx, root, opts)
+	}
+
+	if *remote != "" {
+		baseURL, repo, err := codeowners.ParseRemote(*remote)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		client := codeowners.NewGitHubClient(codeowners.GitHubToken(""))
+		if baseURL != "" && os.Getenv("GITHUB_API_URL") == "" {
+			client.BaseURL = baseURL
+		}
+
+		root = fmt.Sprintf("%s@%s", *remote, *ref)
+		rewrite = func(ctx context.Context) ([]codeowners.Rule, error) {
+			return codeowners.RewriteRemoteCodeownersRules(ctx, client, repo, *ref, opts)
+		}
+	}
+
+	var asOfCommit string
+	if *asOf != "" {
+		asOfCommit, err = codeowners.GitResolveAsOf(ctx, root, *asOf)
+		if err != nil {
+			log.Fatal(fmt.Errorf("error while resolving --as-of %s: %w", *asOf, err))
+		}
+
+		repoRoot := root
+		root = fmt.Sprintf("%s@%s", root, asOfCommit)
+		rewrite = func(ctx context.Context) ([]codeowners.Rule, error) {
+			return codeowners.RewriteCodeownersRulesAt(ctx, repoRoot, asOfCommit, opts)
+		}
+	}
+
+	rewrittenCodeownerRules, err := rewriteWithDeadline(ctx, rewrite)
+	exitIfCancelled(ctx, err)
+	if err != nil {
+		log.Fatal(fmt.Errorf("error while rewriting codeowner rules in %s: %w", root, err))
+	}
+
+	if len(rewrittenCodeownerRules) == 0 {
+		log.Fatal(fmt.Errorf("no CODEOWNER rules found in %s", root))
+	}
+
+	if *materialize {
+		files, err := codeowners.ListFiles(ctx, root)
+		exitIfCancelled(ctx, err)
+		if err != nil {
+			log.Fatal(fmt.Errorf("error while materializing inherited ownership: %w", err))
+		}
+
+		rewrittenCodeownerRules = codeowners.MaterializeInheritedRules(rewrittenCodeownerRules, files, opts)
+	}
+
+	if *target == codeowners.TargetGitLab {
+		cfg.GitLab.SectionPerTopLevelDir = cfg.GitLab.SectionPerTopLevelDir || *topSections
+		rewrittenCodeownerRules = codeowners.ApplyGitLabSections(rewrittenCodeownerRules, cfg.GitLab)
+	}
+
+	if *normalize {
+		rewrittenCodeownerRules = codeowners.NormalizeOwners(rewrittenCodeownerRules)
+	}
+	if *dedupe {
+		rewrittenCodeownerRules = codeowners.DedupeRules(rewrittenCodeownerRules)
+	}
+	if *minify {
+		rewrittenCodeownerRules = codeowners.MinifyRules(rewrittenCodeownerRules)
+	}
+
+	// Gitea applies all matching rules, so ownership can't be removed
+	if *target != codeowners.TargetGitea {
+		rewrittenCodeownerRules = append(rewrittenCodeownerRules, codeowners.NeverOwnedRules(cfg.Policy.NeverOwned, opts)...)
+	}
+
+	// Coverage is computed from all rules since the rules of other owners
+	// override the filtered ones
+	allRules := rewrittenCodeownerRules
+	rewrittenCodeownerRules = ownerFilter.Rules(rewrittenCodeownerRules)
+	if len(rewrittenCodeownerRules) == 0 {
+		log.Fatal(fmt.Errorf("no CODEOWNER rules of %s found in %s", *owner, root))
+	}
+	if *onlyDirs || *onlyFiles {
+		rewrittenCodeownerRules = codeowners.FilterRuleKind(rewrittenCodeownerRules, *onlyDirs)
+	}
+
+	for _, finding := range codeowners.CheckMaxRules(rewrittenCodeownerRules, *maxRules) {
+		if *strict {
+			finding.Severity = codeowners.SeverityError
+			log.Fatal(finding)
+		}
+		opts.Diagnostics(finding)
+	}
+
+	// Conflicts are judged on the files of the checkout the rules apply to
+	if *failConflicts {
+		files, err := codeowners.ListFiles(ctx, root)
+		exitIfCancelled(ctx, err)
+		if err != nil {
+			log.Fatal(fmt.Errorf("error while detecting conflicts: %w", err))
+		}
+
+		suppressions, _, err := codeowners.FindSuppressions(ctx, root)
+		if err != nil {
+			log.Fatal(fmt.Errorf("error while detecting conflicts: %w", err))
+		}
+
+		// Filtered like in audit, all remaining conflicts are errors
+		conflicts := append(codeowners.FindConflicts(allRules, files), codeowners.FindShadowedRules(allRules, files)...)
+		conflicts = codeowners.ApplySeverities(conflicts, cfg.Severities, true)
+		conflicts = codeowners.ApplySuppressions(conflicts, suppressions)
+		for _, finding := range conflicts {
+			log.Print(finding)
+		}
+		if len(conflicts) > 0 {
+			log.Printf("%s between the nested CODEOWNERS files", codeowners.Pluralize(len(conflicts), "conflict"))
+			os.Exit(exitCodeFindings)
+		}
+	}
+
+	// Coverage needs the files of a local checkout the rules apply to as is
+	outputsPath := os.Getenv(githubOutputEnv)
+	var coverage *codeowners.Coverage
+	if (report != nil || outputsPath != "") && *remote == "" && *asOf == "" && *pathPrefix == "" {
+		files, err := codeowners.ListFiles(ctx, root)
+		exitIfCancelled(ctx, err)
+		if err != nil {
+			log.Fatal(fmt.Errorf("error while computing coverage: %w", err))
+		}
+
+		computed := codeowners.ComputeCoverage(allRules, codeowners.ExcludeNeverOwned(codeowners.ExcludeGenerated(files, codeowners.NewGeneratedPaths(root)), cfg.Policy.NeverOwned))
+		coverage = &computed
+	}
+
+	if report != nil {
+		report.setRules(rewrittenCodeownerRules)
+		report.Diagnostics = ownerFilter.Findings(report.Diagnostics, allRules)
+		report.Coverage = coverage
+	}
+
+	if *ownerDocs {
+		files, err := codeowners.ListFiles(ctx, root)
+		exitIfCancelled(ctx, err)
+		if err == nil {
+			_, err = codeowners.WriteOwnershipDocs(root, codeowners.BuildOwnershipDocs(allRules, files))
+		}
+		if err != nil {
+			log.Fatal(fmt.Errorf("error while writing ownership docs: %w", err))
+		}
+	}
+
+	// finish writes the --report-file and the GitHub Actions step outputs, if
+	// requested, at the end of the run. changed is nil if unknown, drift is
+	// only set with --compare.
+	finish := func(changed, drift *bool) {
+		if report != nil {
+			report.Root = root
+			report.Drift = drift
+			err := report.write(*reportFile)
+			if err != nil {
+				log.Fatal(fmt.Errorf("error while writing report: %w", err))
+			}
+		}
+
+		if outputsPath != "" {
+			err := writeStepOutputs(outputsPath, StepOutputs{
+				Changed:    changed,
+				RuleCount:  len(rewrittenCodeownerRules),
+				Coverage:   coverage,
+				ReportPath: *reportFile,
+			})
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+
+	// Don't emit anything if we got interrupted after the walk
+	exitIfCancelled(ctx, ctx.Err())
+
+	if *compare != "" && (*appendMode || *write || *commit) {
+		log.Fatal(fmt.Errorf("--compare can't be combined with --append, --write or --commit"))
+	}
+	if *push && !*commit {
+		log.Fatal(fmt.Errorf("--push requires --commit"))
+	}
+	if err := codeowners.ValidTarget(*target); err != nil {
+		log.Fatal(err)
+	}
+	if err := codeowners.ValidLayout(*layout); err != nil {
+		log.Fatal(err)
+	}
+	if *layout == codeowners.LayoutOwner && (*target == codeowners.TargetGitLab || *tmplFile != "" || *appendMode) {
+		log.Fatal(fmt.Errorf("--layout %s can't be combined with the %s target, --template or --append", codeowners.LayoutOwner, codeowners.TargetGitLab))
+	}
+	outputFile, supported := codeowners.TargetFileName(*target, *gitLabFile)
+	if !supported && (*appendMode || *write || *commit) {
+		log.Fatal(fmt.Errorf("--append, --write and --commit don't support the %s target", *target))
+	}
+	if *check {
+		if *compare != "" || *remote != "" || *asOf != "" || *appendMode || *write || *commit {
+			log.Fatal(fmt.Errorf("--check can't be combined with --compare, --remote, --as-of, --append, --write or --commit"))
+		}
+		if !supported {
+			log.Fatal(fmt.Errorf("--check doesn't support the %s target", *target))
+		}
+		*compare = filepath.Join(root, outputFile)
+	}
+	if *target == codeowners.TargetGitLab && *remote == "" && *asOf == "" {
+		err = codeowners.CheckGitLabLocation(root, outputFile)
+		if err != nil && (*appendMode || *write || *commit) {
+			log.Fatal(err)
+		}
+		if err != nil {
+			log.Printf("warning: %s", err)
+		}
+	}
+	if *target == codeowners.TargetGitea {
+		if *annotate {
+			log.Fatal(fmt.Errorf("--annotate-source isn't supported by the %s target", codeowners.TargetGitea))
+		}
+
+		err = codeowners.ValidateGiteaRules(rewrittenCodeownerRules)
+		if err != nil {
+			log.Fatal(fmt.Errorf("error while validating rules for %s: %w", codeowners.TargetGitea, err))
+		}
+	}
+
+	if *appendMode {
+		outputPath := filepath.Join(root, outputFile)
+		existing, _ := os.ReadFile(outputPath)
+		err = appendToCodeownersFile(outputPath, codeowners.TargetRules(rewrittenCodeownerRules, *target))
+		if err != nil {
+			log.Fatal(fmt.Errorf("error while appending generated rules: %w", err))
+		}
+		changed := fileDiffers(outputPath, string(existing))
+
+		if *commit {
+			commitGeneratedFile(ctx, root, outputFile, rewrittenCodeownerRules)
+		}
+
+		finish(&changed, nil)
+		return
+	}
+
+	generateOpts := codeowners.GenerateOptions{
+		Header:   *header,
+		NoHeader: *noHeader,
+
+		AnnotateSource:   *annotate,
+		PreserveComments: *keepComments,
+		Provenance:       *provenance,
+
+		Target: *target,
+		Layout: *layout,
+	}
+
+	switch {
+	case *metadata && *remote != "":
+		generateOpts.Metadata = &codeowners.Metadata{GeneratedAt: time.Now(), ToolVersion: codeowners.ToolVersion()}
+	case *metadata && asOfCommit != "":
+		generateOpts.Metadata = &codeowners.Metadata{GeneratedAt: time.Now(), ToolVersion: codeowners.ToolVersion(), SourceCommit: asOfCommit}
+	case *metadata:
+		generateOpts.Metadata = collectMetadata(ctx, root)
+	}
+
+	var output string
+	if *tmplFile != "" {
+		output, err = renderTemplateFile(*tmplFile, rewrittenCodeownerRules, generateOpts)
+		if err != nil {
+			log.Fatal(fmt.Errorf("error while rendering template: %w", err))
+		}
+	} else {
+		output = codeowners.GenerateCodeownersFile(rewrittenCodeownerRules, generateOpts)
+	}
+
+	if *compare != "" {
+		// Drift can only be explained for rules in the GitHub format
+		var explainRules []codeowners.Rule
+		if *tmplFile == "" && *target == codeowners.TargetGitHub {
+			explainRules = rewrittenCodeownerRules
+		}
+
+		drift, err := compareWithFile(*compare, output, explainRules)
+		if err != nil {
+			log.Fatal(fmt.Errorf("error while comparing output: %w", err))
+		}
+
+		finish(&drift, &drift)
+		if drift && *check {
+			log.Print("regenerate it with the same flags and --write instead of --check")
+		}
+		if drift {
+			os.Exit(exitCodeDrift)
+		}
+
+		return
+	}
+
+	if *write || *commit {
+		outputPath := filepath.Join(root, outputFile)
+		changed := fileDiffers(outputPath, output)
+		err = codeowners.WriteIfChanged(outputPath, output)
+		if err != nil {
+			log.Fatal(fmt.Errorf("error while writing generated file: %w", err))
+		}
+
+		if *commit {
+			commitGeneratedFile(ctx, root, outputFile, rewrittenCodeownerRules)
+		}
+		finish(&changed, nil)
+		return
+	}
+
+	if *outputPath != "" {
+		changed := fileDiffers(*outputPath, output)
+		err = codeowners.WriteIfChanged(*outputPath, output)
+		if err != nil {
+			log.Fatal(fmt.Errorf("error while writing generated file: %w", err))
+		}
+
+		finish(&changed, nil)
+		return
+	}
+
+	_, err = os.Stdout.WriteString(output)
+	if err != nil {
+		log.Fatal(fmt.Errorf("error while printing generated filed: %w", err))
+	}
+
+	// Printed files are compared with the file of the target in the checkout
+	var changed *bool
+	if *remote == "" && *asOf == "" && *tmplFile == "" && supported {
+		differs := fileDiffers(filepath.Join(root, outputFile), output)
+		changed = &differs
+	}
+	finish(changed, nil)
+}
+
+const (
+	// exitCodeTimeout is the exit code used when --timeout expires, it follows
+	// the convention of the timeout(1) command.
+	exitCodeTimeout = 124
+
+	// exitCodeInterrupted is the conventional exit code after SIGINT.
+	exitCodeInterrupted = 130
+
+	// exitCodeDrift is the exit code used when the compared file is out of date.
+	exitCodeDrift = 3
+)
+
+// defaultCommitMessage is the default message of commits created by --commit.
+const defaultCommitMessage = "Update CODEOWNERS file"
+
+// commitGeneratedFile commits the generated file, given relative to root, and
+// pushes it if requested. Nothing happens if the file didn't change.
+func commitGeneratedFile(ctx context.Context, root, file string, rules []codeowners.Rule) {
+	changed, err := codeowners.GitFileChanged(ctx, root, file)
+	if err != nil {
+		log.Fatal(fmt.Errorf("error while checking for changes: %w", err))
+	}
+
+	if !changed {
+		log.Printf("%s is up to date, nothing to commit", file)
+		return
+	}
+
+	tmpl, err := codeowners.ParseOutputTemplate("commit-message", *commitMsg)
+	if err != nil {
+		log.Fatal(fmt.Errorf("error while parsing commit message: %w", err))
+	}
+
+	var message strings.Builder
+	err = codeowners.RenderTemplate(&message, tmpl, rules, codeowners.GenerateOptions{})
+	if err != nil {
+		log.Fatal(fmt.Errorf("error while rendering commit message: %w", err))
+	}
+
+	err = codeowners.GitCommitFile(ctx, root, file, message.String())
+	if err != nil {
+		log.Fatal(fmt.Errorf("error while committing %s: %w", file, err))
+	}
+
+	if *push {
+		err = codeowners.GitPush(ctx, root)
+		if err != nil {
+			log.Fatal(fmt.Errorf("error while pushing: %w", err))
+		}
+	}
+}
+
+// renderTemplateFile renders the rules with the template in path.
+func renderTemplateFile(path string, rules []codeowners.Rule, opts codeowners.GenerateOptions) (string, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("can't read template %s: %w", path, err)
+	}
+
+	tmpl, err := codeowners.ParseOutputTemplate(filepath.Base(path), string(text))
+	if err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	err = codeowners.RenderTemplate(&out, tmpl, rules, opts)
+	if err != nil {
+		return "", err
+	}
+
+	return out.String(), nil
+}
+
+// compareWithFile compares the output with the file in path and reports
+// whether they differ. If they do the diff is printed, followed by the source
+// level explanation of the drift if the rules are given. A missing file
+// counts as empty.
+func compareWithFile(path, output string, rules []codeowners.Rule) (bool, error) {
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return false, fmt.Errorf("can't read %s: %w", path, err)
+	}
+
+	diff := codeowners.CompareOutput(path, string(existing), output)
+	if diff == "" {
+		return false, nil
+	}
+
+	_, err = os.Stdout.WriteString(diff)
+	if err != nil {
+		return false, fmt.Errorf("error while printing diff: %w", err)
+	}
+
+	for _, explanation := range codeowners.ExplainDrift(string(existing), rules) {
+		log.Print(explanation)
+	}
+
+	log.Printf("%s is out of date", path)
+	return true, nil
+}
+
+// collectMetadata gathers the generation metadata for the header. A missing
+// source commit (e.g. when git isn't available) is reported but not fatal.
+func collectMetadata(ctx context.Context, root string) *codeowners.Metadata {
+	commit, err := codeowners.GitHeadCommit(ctx, root)
+	if err != nil {
+		commit = os.Getenv("GITHUB_SHA") // Set when running as GitHub Action
+	}
+	if commit == "" {
+		log.Printf("warning: can't determine source commit, omitting it from the header: %s", err)
+	}
+
+	return &codeowners.Metadata{
+		GeneratedAt:  time.Now(),
+		ToolVersion:  codeowners.ToolVersion(),
+		SourceCommit: commit,
+	}
+}
+
+// runCommand runs a subcommand with a context that is cancelled on SIGINT and
+// SIGTERM and exits on errors.
+func runCommand(run func(ctx context.Context, args []string) error, args []string) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := run(ctx, args)
+	exitIfCancelled(ctx, err)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// resolveRoot returns the root of the repo containing dir, or dir itself if
+// discover is false.
+func resolveRoot(dir string, discover bool) (string, error) {
+	if !discover {
+		return dir, nil
+	}
+
+	root, err := codeowners.DiscoverRoot(dir)
+	if err != nil {
+		return "", fmt.Errorf("error while discovering repository root: %w", err)
+	}
+
+	return root, nil
+}
+
+// loadRules rewrites the rules of all CODEOWNERS files in the repo containing
+// dir, or in dir itself if discover is false.
+func loadRules(ctx context.Context, dir string, discover bool, opts codeowners.Options) ([]codeowners.Rule, error) {
+	root, err := resolveRoot(dir, discover)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := codeowners.RewriteCodeownersRules(ctx, root, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error while rewriting codeowner rules in %s: %w", root, err)
+	}
+
+	return rules, nil
+}
+
+// loadRulesCached is loadRules followed by the rules of the never-owned
+// patterns using the rules cache, see LoadRulesCached.
+func loadRulesCached(ctx context.Context, dir string, discover bool, opts codeowners.Options, neverOwned []string) ([]codeowners.Rule, error) {
+	root, err := resolveRoot(dir, discover)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := codeowners.LoadRulesCached(ctx, root, opts, neverOwned)
+	if err != nil {
+		return nil, fmt.Errorf("error while rewriting codeowner rules in %s: %w", root, err)
+	}
+
+	return rules, nil
+}
+
+// loadRulesAsOf is loadRules for the state of the repo at asOf, a date
+// (YYYY-MM-DD) or commit.
+func loadRulesAsOf(ctx context.Context, dir string, discover bool, asOf string, opts codeowners.Options) ([]codeowners.Rule, error) {
+	root, err := resolveRoot(dir, discover)
+	if err != nil {
+		return nil, err
+	}
+
+	commit, err := codeowners.GitResolveAsOf(ctx, root, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("error while resolving %s: %w", asOf, err)
+	}
+
+	rules, err := codeowners.RewriteCodeownersRulesAt(ctx, root, commit, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error while rewriting codeowner rules in %s at %s: %w", root, commit, err)
+	}
+
+	return rules, nil
+}
+
+// ownershipFlags are the flags of generate that change the ownership of the
+// rules. The commands answering ownership queries share them, so that their
+// answers agree with the generated file.
+type ownershipFlags struct {
+	teams      *string
+	readmes    *bool
+	configFile *string
+}
+
+// addOwnershipFlags defines the ownership flags in flags.
+func addOwnershipFlags(flags *flag.FlagSet) ownershipFlags {
+	return ownershipFlags{
+		teams:      flags.String("teams", "", "teams manifest relative to the repo root, e.g. "+codeowners.TeamsManifestFileName+", whose rules are merged with the nested CODEOWNERS files"),
+		readmes:    flags.Bool("readme-owners", false, "also read the owners declared in the front matter of "+codeowners.ReadmeFileName+" files as owners of their dirs"),
+		configFile: flags.String("config", "", "config file (default "+codeowners.ConfigFileName+" in the repo root), whose never-owned paths are removed from the ownership"),
+	}
+}
+
+// options returns the options to rewrite the rules with.
+func (f ownershipFlags) options() codeowners.Options {
+	return codeowners.Options{TeamsManifest: *f.teams, ReadmeOwners: *f.readmes}
+}
+
+// config reads the config of the repo in root, whose never-owned paths are
+// removed from the ownership by appending their NeverOwnedRules.
+func (f ownershipFlags) config(root string) (codeowners.Config, error) {
+	return codeowners.LoadConfig(root, *f.configFile)
+}
+
+// exitIfCancelled exits without output if err was caused by ctx being
+// cancelled by a signal or its deadline.
+func exitIfCancelled(ctx context.Context, err error) {
+	switch {
+	case err == nil:
+		return
+	case errors.Is(err, context.DeadlineExceeded):
+		log.Printf("timed out after %s: %s", *timeout, err)
+		os.Exit(exitCodeTimeout)
+	case errors.Is(err, context.Canceled) && ctx.Err() != nil:
+		log.Printf("interrupted: %s", err)
+		os.Exit(exitCodeInterrupted)
+	}
+}
+
+// timeoutGracePeriod is how long rewriteWithDeadline waits for the walk to
+// report its progress after the deadline passed.
+const timeoutGracePeriod = time.Second
+
+// rewriteWithDeadline runs rewrite but returns once ctx is done even if the
+// rewrite itself is stuck, e.g. in a read from a hung network mount.
+func rewriteWithDeadline(ctx context.Context, rewrite func(ctx context.Context) ([]codeowners.Rule, error)) ([]codeowners.Rule, error) {
+	type result struct {
+		rules []codeowners.Rule
+		err   error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		rules, err := rewrite(ctx)
+		done <- result{rules, err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.rules, res.err
+	case <-ctx.Done():
+	}
+
+	select {
+	case res := <-done:
+		return res.rules, res.err
+	case <-time.After(timeoutGracePeriod):
+		return nil, fmt.Errorf("walk is blocked and did not stop: %w", ctx.Err())
+	}
+}
+
+func usage() {
+	_, err := fmt.Fprintf(flag.CommandLine.Output(), "usage: %[1]s [generate] [flags] [dir]\n       %[1]s check [flags] [dir]\n       %[1]s validate [flags]\n       %[1]s who-owns [flags] path... | -\n       %[1]s query [flags] path... | -\n       %[1]s files-owned-by [flags] owner\n       %[1]s list-owners [flags]\n       %[1]s audit [flags]\n       %[1]s lint [flags]\n       %[1]s blame [flags] pattern|line\n       %[1]s simulate [flags]\n       %[1]s request-reviews [flags]\n       %[1]s pr-comment [flags]\n       %[1]s merge-driver %%O %%A %%B\n       %[1]s azure-policies [flags]\n       %[1]s scaffold [flags]\n       %[1]s prune [flags]\n       %[1]s mv [flags] old/path new/path\n       %[1]s renames [flags]\n       %[1]s mine [flags]\n       %[1]s coverage [flags]\n       %[1]s unowned [flags]\n       %[1]s serve [flags]\n       %[1]s known-owners --org org [flags]\n       %[1]s github-errors [flags]\n", os.Args[0])
+	if err != nil {
+		log.Fatal(fmt.Errorf("error while printing usage info: %w", err))
+	}
+
+	flag.PrintDefaults()
+}
+
+// readFileList reads a newline separated list of paths from the file in path,
+// or from stdin if path is "-". Empty lines are skipped.
+func readFileList(path string) ([]string, error) {
+	var content []byte
+	var err error
+	if path == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't read %s: %w", path, err)
+	}
+
+	files := []string{}
+	for _, line := range strings.Split(string(content), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+
+	return files, nil
+}
+
+// parseDir returns the dir given as argument or as --root, defaulting to the
+// current dir.
+func parseDir() (string, error) {
+	narg := flag.NArg()
+	switch {
+	case *rootDir != "" && narg > 0:
+		return "", fmt.Errorf("can't combine --root with the dir argument %s", flag.Arg(0))
+	case *rootDir != "":
+		return *rootDir, nil
+	case narg < 1:
+		return ".", nil
+	case narg > 1:
+		return "", fmt.Errorf("can only process one dir at a time, got %d: %s", narg, flag.Args())
+	default:
+		return flag.Arg(0), nil
+	}
+}
+
+// appendToCodeownersFile merges rules into the managed region of the CO file
+// in path and reports conflicts with manually maintained rules on stderr.
+func appendToCodeownersFile(path string, rules []codeowners.Rule) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("can't read %s: %w", path, err)
+	}
+
+	merged, conflicts, err := codeowners.AppendCodeownersRules(string(existing), rules)
+	if err != nil {
+		return fmt.Errorf("can't merge rules into %s: %w", path, err)
+	}
+
+	for _, conflict := range conflicts {
+		log.Printf("conflict: %s", conflict)
+	}
+
+	return codeowners.WriteFileAtomic(path, merged)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryPath(t *testing.T) {
+	// The current dir has no symlinks, e.g. of /tmp on macOS
+	repoPath, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	writeFile(t, repoPath, "src/main.go", "")
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(filepath.Join(repoPath, "src")))
+	defer func() { require.NoError(t, os.Chdir(wd)) }()
+
+	// Paths are relative to the current dir, not to the repo root
+	for arg, expected := range map[string]string{
+		"main.go":                              "src/main.go",
+		"./main.go":                            "src/main.go",
+		"../README.md":                         "README.md",
+		"../docs/":                             "docs/",
+		filepath.Join(repoPath, "src", "a.go"): "src/a.go",
+	} {
+		path, err := queryPath(repoPath, arg)
+		require.NoError(t, err)
+		require.Equal(t, expected, path, arg)
+	}
+
+	_, err = queryPath(repoPath, "../..")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is outside of the repo")
+}
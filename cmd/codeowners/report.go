@@ -0,0 +1,79 @@
+package main
+
+import (
+	"time"
+
+	"github.com/gmolau/codeowners"
+)
+
+// RunReport describes a generator run in a machine-readable form, e.g. to be
+// uploaded as CI artifact, see --report-file.
+type RunReport struct {
+	Root      string    `json:"root"`
+	StartedAt time.Time `json:"startedAt"`
+
+	// DurationMS is the wall time of the run in milliseconds.
+	DurationMS int64 `json:"durationMs"`
+
+	// Inputs are the processed CO files relative to the root.
+	Inputs []string `json:"inputs"`
+
+	Rules       []ReportRule         `json:"rules"`
+	Diagnostics []codeowners.Finding `json:"diagnostics"`
+
+	// Coverage is only computed for local checkouts.
+	Coverage *codeowners.Coverage `json:"coverage,omitempty"`
+
+	// Drift is set with --compare and reports whether the compared file is
+	// out of date.
+	Drift *bool `json:"drift,omitempty"`
+}
+
+// ReportRule is a generated rule together with its origin.
+type ReportRule struct {
+	Pattern string   `json:"pattern"`
+	Owners  []string `json:"owners"`
+	Dir     bool     `json:"dir,omitempty"`
+	Source  string   `json:"source,omitempty"`
+	Line    int      `json:"line,omitempty"`
+	Labels  []string `json:"labels,omitempty"`
+}
+
+// newRunReport starts the report of a run in root.
+func newRunReport(root string) *RunReport {
+	return &RunReport{
+		Root:        root,
+		StartedAt:   time.Now().UTC(),
+		Inputs:      []string{},
+		Rules:       []ReportRule{},
+		Diagnostics: []codeowners.Finding{},
+	}
+}
+
+// setRules records the generated rules.
+func (r *RunReport) setRules(rules []codeowners.Rule) {
+	r.Rules = make([]ReportRule, len(rules))
+	for i, rule := range rules {
+		r.Rules[i] = ReportRule{
+			Pattern: rule.Pattern,
+			Owners:  rule.Owners,
+			Dir:     rule.Dir,
+			Source:  rule.Source,
+			Line:    rule.Line,
+			Labels:  rule.Labels,
+		}
+	}
+}
+
+// write finishes the report and writes it to path, as YAML or CSV (the
+// rules) for the extensions .yaml, .yml or .csv and as JSON otherwise.
+func (r *RunReport) write(path string) error {
+	r.DurationMS = time.Since(r.StartedAt).Milliseconds()
+
+	content, err := formattedString(reportFileFormat(path), r)
+	if err != nil {
+		return err
+	}
+
+	return codeowners.WriteFileAtomic(path, content)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gmolau/codeowners"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunReport(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admin\n")
+	writeFile(t, repoPath, "docs/CODEOWNERS", "# No rules yet\n")
+	writeFile(t, repoPath, "src/CODEOWNERS", "main.go\n*.go @org/go # codeowners: label=go\n")
+
+	report := newRunReport(repoPath)
+	opts := codeowners.Options{
+		Diagnostics: func(finding codeowners.Finding) { report.Diagnostics = append(report.Diagnostics, finding) },
+		Inputs:      func(source string) { report.Inputs = append(report.Inputs, source) },
+	}
+
+	rules, err := codeowners.RewriteCodeownersRules(context.Background(), repoPath, opts)
+	require.NoError(t, err)
+	report.setRules(rules)
+
+	drift := true
+	report.Drift = &drift
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	require.NoError(t, report.write(path))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var written RunReport
+	require.NoError(t, json.Unmarshal(content, &written))
+	require.Equal(t, []string{"CODEOWNERS", "docs/CODEOWNERS", "src/CODEOWNERS"}, written.Inputs)
+	require.Equal(t, []ReportRule{
+		{Pattern: "*", Owners: []string{"@org/admin"}, Dir: true, Source: "CODEOWNERS", Line: 1},
+		{Pattern: "/src/*.go", Owners: []string{"@org/go"}, Source: "src/CODEOWNERS", Line: 2, Labels: []string{"go"}},
+	}, written.Rules)
+	require.Len(t, written.Diagnostics, 1)
+	require.Equal(t, "missing-owners", written.Diagnostics[0].Check)
+	require.Nil(t, written.Coverage)
+	require.True(t, *written.Drift)
+}
+
+func writeFile(t *testing.T, root, path, content string) {
+	// Construct the abspath to the file's dir first so that we can
+	// create the parent dirs
+	relDir := filepath.Dir(path)
+	absDir := filepath.Join(root, relDir)
+	err := os.MkdirAll(absDir, 0700)
+	require.NoError(t, err)
+
+	// Now create the file in that directory
+	fileName := filepath.Base(path)
+	file := filepath.Join(absDir, fileName)
+	err = os.WriteFile(file, []byte(content), 0600)
+	require.NoError(t, err)
+}
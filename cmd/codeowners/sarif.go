@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gmolau/codeowners"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	toolURI      = "https://github.com/gmolau/codeowners"
+)
+
+// sarifLog is the subset of the SARIF 2.1.0 format needed to report findings,
+// see https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html.
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+	Fixes     []sarifFix      `json:"fixes,omitempty"`
+}
+
+type sarifFix struct {
+	Description     sarifMessage          `json:"description"`
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []sarifReplacement    `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion           `json:"deletedRegion"`
+	InsertedContent *sarifArtifactContent `json:"insertedContent,omitempty"`
+}
+
+type sarifArtifactContent struct {
+	Text string `json:"text"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+// toSARIF converts findings into a SARIF log with a single run. The rules are
+// identified by the check IDs, checks without ID by their name.
+func toSARIF(findings []codeowners.Finding) sarifLog {
+	checks := map[string]string{}
+	results := []sarifResult{}
+
+	for _, finding := range findings {
+		ruleID := finding.ID()
+		if ruleID == "" {
+			ruleID = finding.Check
+		}
+		checks[ruleID] = finding.Check
+
+		result := sarifResult{
+			RuleID:  ruleID,
+			Level:   string(finding.Severity),
+			Message: sarifMessage{Text: finding.Message},
+		}
+
+		if finding.File != "" {
+			location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: finding.File},
+			}}
+			if finding.Line > 0 {
+				location.PhysicalLocation.Region = &sarifRegion{StartLine: finding.Line, StartColumn: finding.Column}
+			}
+			result.Locations = []sarifLocation{location}
+		}
+
+		if finding.Fix != nil {
+			result.Fixes = []sarifFix{toSARIFFix(*finding.Fix)}
+		}
+
+		results = append(results, result)
+	}
+
+	rules := []sarifRule{}
+	for id, check := range checks {
+		rules = append(rules, sarifRule{ID: id, Name: check})
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+
+	return sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "codeowners",
+				Version:        codeowners.ToolVersion(),
+				InformationURI: toolURI,
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+}
+
+// toSARIFFix converts a fix into a SARIF fix. The replaced lines are deleted
+// including their final newline, which the replacement then ends with, so
+// that deletions don't leave an empty line behind.
+func toSARIFFix(fix codeowners.Fix) sarifFix {
+	replacement := sarifReplacement{
+		DeletedRegion: sarifRegion{StartLine: fix.StartLine, StartColumn: 1, EndLine: fix.EndLine + 1, EndColumn: 1},
+	}
+	if fix.Replacement != "" {
+		replacement.InsertedContent = &sarifArtifactContent{Text: fix.Replacement + "\n"}
+	}
+
+	lines := fmt.Sprintf("line %d", fix.StartLine)
+	if fix.EndLine > fix.StartLine {
+		lines = fmt.Sprintf("lines %d to %d", fix.StartLine, fix.EndLine)
+	}
+	description := "Replace " + lines
+	if fix.Replacement == "" {
+		description = "Delete " + lines
+	}
+
+	return sarifFix{
+		Description: sarifMessage{Text: description},
+		ArtifactChanges: []sarifArtifactChange{{
+			ArtifactLocation: sarifArtifactLocation{URI: fix.File},
+			Replacements:     []sarifReplacement{replacement},
+		}},
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gmolau/codeowners"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToSARIFFixes(t *testing.T) {
+	findings := []codeowners.Finding{
+		{Check: "owner-casing", Severity: codeowners.SeverityWarning, Message: "@org/Docs is spelled @org/docs elsewhere", File: "src/CODEOWNERS", Line: 1, Fixable: true,
+			Fix: &codeowners.Fix{File: "src/CODEOWNERS", StartLine: 1, EndLine: 1, Replacement: "@org/docs @org/new"}},
+		{Check: "stale-pattern", Severity: codeowners.SeverityWarning, Message: "pattern /src/gone.go doesn't match any file", File: "src/CODEOWNERS", Line: 2,
+			Fix: &codeowners.Fix{File: "src/CODEOWNERS", StartLine: 2, EndLine: 2}},
+	}
+
+	sarif := toSARIF(findings)
+	require.Equal(t, []sarifFix{{
+		Description: sarifMessage{Text: "Replace line 1"},
+		ArtifactChanges: []sarifArtifactChange{{
+			ArtifactLocation: sarifArtifactLocation{URI: "src/CODEOWNERS"},
+			Replacements: []sarifReplacement{{
+				DeletedRegion:   sarifRegion{StartLine: 1, StartColumn: 1, EndLine: 2, EndColumn: 1},
+				InsertedContent: &sarifArtifactContent{Text: "@org/docs @org/new\n"},
+			}},
+		}},
+	}}, sarif.Runs[0].Results[0].Fixes)
+	require.Equal(t, "Delete line 2", sarif.Runs[0].Results[1].Fixes[0].Description.Text)
+	require.Nil(t, sarif.Runs[0].Results[1].Fixes[0].ArtifactChanges[0].Replacements[0].InsertedContent)
+	require.Equal(t, []sarifRule{{ID: "CO001", Name: "stale-pattern"}, {ID: "CO018", Name: "owner-casing"}}, sarif.Runs[0].Tool.Driver.Rules)
+}
@@ -0,0 +1,182 @@
+package codeowners
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// volatileMetadataPrefixes are the header lines that change between runs
+// without any change to the rules, they are ignored when comparing outputs.
+var volatileMetadataPrefixes = []string{
+	"# Generated at: ",
+	"# Source commit: ",
+}
+
+// CompareOutput compares an existing file with freshly generated output and
+// returns a unified diff from existing to generated, or an empty string if
+// they match. Volatile header metadata like the generation time is ignored.
+func CompareOutput(existingName, existing, generated string) string {
+	existing = stripVolatileMetadata(existing)
+	generated = stripVolatileMetadata(generated)
+
+	return UnifiedDiff(existingName, "generated", existing, generated)
+}
+
+// stripVolatileMetadata removes the header metadata lines that change between
+// runs from text.
+func stripVolatileMetadata(text string) string {
+	lines := strings.Split(text, "\n")
+
+	kept := lines[:0]
+	for _, line := range lines {
+		if !hasAnyPrefix(line, volatileMetadataPrefixes) {
+			kept = append(kept, line)
+		}
+	}
+
+	return strings.Join(kept, "\n")
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseCodeownersRules parses the rules of a root CO file as they are, e.g.
+// of an existing generated file. Comments and blank lines are skipped.
+func ParseCodeownersRules(content string) []Rule {
+	var rules []Rule
+	for i, line := range strings.Split(content, "\n") {
+		if !isCodeownersRule(strings.TrimSpace(line)) {
+			continue
+		}
+
+		tokens, comment := tokenizeCodeownersRule(line)
+		if len(tokens) == 0 {
+			continue
+		}
+		rules = append(rules, Rule{Pattern: tokens[0], Owners: tokens[1:], Comment: comment, Line: i + 1})
+	}
+
+	return rules
+}
+
+// SameOwnership checks whether two sets of rules assign the same owners to
+// every file, compared case-insensitively and regardless of their order.
+// Unlike CompareOutput it ignores the header, comments, the layout and rules
+// without effect, so files generated with other options still match.
+func SameOwnership(a, b []Rule, files []string) bool {
+	matcherA, matcherB := NewMatcher(a), NewMatcher(b)
+	for _, file := range files {
+		ruleA, _ := matcherA.Match(file)
+		ruleB, _ := matcherB.Match(file)
+		if ownerSetKey(ruleA.Owners) != ownerSetKey(ruleB.Owners) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ownerSetKey identifies a set of owners independent of order, duplicates and
+// casing.
+func ownerSetKey(owners []string) string {
+	keys := make([]string, 0, len(owners))
+	seen := map[string]bool{}
+	for _, owner := range owners {
+		key := strings.ToLower(owner)
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	return strings.Join(keys, " ")
+}
+
+// ExplainDrift explains at the source level how the rules of an existing
+// root CO file differ from the generated rules, using the nested CO file and
+// line each generated rule came from, e.g. "owners for /src changed in
+// src/CODEOWNERS:1: @a removed, @b added". Rules are matched by pattern,
+// changes of the order alone aren't explained.
+func ExplainDrift(existing string, rules []Rule) []string {
+	existingOwners := map[string][]string{}
+	var existingPatterns []string
+	for _, line := range strings.Split(existing, "\n") {
+		if !isCodeownersRule(line) {
+			continue
+		}
+
+		tokens, _ := tokenizeCodeownersRule(line)
+		if len(tokens) == 0 {
+			continue
+		}
+		if _, ok := existingOwners[tokens[0]]; !ok {
+			existingPatterns = append(existingPatterns, tokens[0])
+		}
+		existingOwners[tokens[0]] = tokens[1:]
+	}
+
+	var explanations []string
+	generated := map[string]bool{}
+	for _, rule := range rules {
+		generated[rule.Pattern] = true
+
+		owners, ok := existingOwners[rule.Pattern]
+		if !ok {
+			explanations = append(explanations, fmt.Sprintf("rule for %s added in %s: %s", rule.Pattern, rule.Location(), strings.Join(rule.Owners, " ")))
+			continue
+		}
+
+		removed := missingOwners(owners, rule.Owners)
+		added := missingOwners(rule.Owners, owners)
+		if len(removed) == 0 && len(added) == 0 {
+			continue
+		}
+
+		var changes []string
+		if len(removed) > 0 {
+			changes = append(changes, strings.Join(removed, " ")+" removed")
+		}
+		if len(added) > 0 {
+			changes = append(changes, strings.Join(added, " ")+" added")
+		}
+		explanations = append(explanations, fmt.Sprintf("owners for %s changed in %s: %s", rule.Pattern, rule.Location(), strings.Join(changes, ", ")))
+	}
+
+	for _, pattern := range existingPatterns {
+		if !generated[pattern] {
+			explanations = append(explanations, fmt.Sprintf("rule for %s removed, no CODEOWNERS file declares it anymore", pattern))
+		}
+	}
+
+	return explanations
+}
+
+// missingOwners returns the owners of a that aren't owners of b, compared
+// case-insensitively.
+func missingOwners(a, b []string) []string {
+	var missing []string
+	for _, owner := range a {
+		found := false
+		for _, other := range b {
+			if strings.EqualFold(owner, other) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			missing = append(missing, owner)
+		}
+	}
+
+	return missing
+}
@@ -0,0 +1,62 @@
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareOutput(t *testing.T) {
+	existing := "# Generated at: 2021-08-01T10:00:00Z\n# Tool version: v0.1.5\n\n* @org/admin\n"
+	generated := "# Generated at: 2021-08-02T10:00:00Z\n# Tool version: v0.1.5\n\n* @org/admin\n"
+	require.Equal(t, "", CompareOutput("CODEOWNERS", existing, generated))
+
+	generated = "# Generated at: 2021-08-02T10:00:00Z\n# Tool version: v0.1.5\n\n* @org/platform\n"
+	require.Equal(t, `--- CODEOWNERS
++++ generated
+@@ -1,3 +1,3 @@
+ # Tool version: v0.1.5
+ 
+-* @org/admin
++* @org/platform
+`, CompareOutput("CODEOWNERS", existing, generated))
+}
+
+func TestExplainDrift(t *testing.T) {
+	existing := "# Header\n\n* @org/admin\n/src/payments @a @c\n/docs @org/docs\n"
+	rules := []Rule{
+		{Pattern: "*", Owners: []string{"@org/admin"}, Source: "CODEOWNERS", Line: 1},
+		{Pattern: "/src/payments", Owners: []string{"@C", "@b"}, Source: "src/payments/CODEOWNERS", Line: 7},
+		{Pattern: "/web", Owners: []string{"@org/web"}, Source: "web/CODEOWNERS", Line: 1},
+	}
+
+	require.Equal(t, []string{
+		"owners for /src/payments changed in src/payments/CODEOWNERS:7: @a removed, @b added",
+		"rule for /web added in web/CODEOWNERS:1: @org/web",
+		"rule for /docs removed, no CODEOWNERS file declares it anymore",
+	}, ExplainDrift(existing, rules))
+
+	require.Empty(t, ExplainDrift("* @org/admin\n", rules[:1]))
+}
+
+func TestSameOwnership(t *testing.T) {
+	rules := []Rule{
+		{Pattern: "*", Owners: []string{"@org/admin"}},
+		{Pattern: "/src", Owners: []string{"@org/dev", "@alice"}},
+		{Pattern: "/src/foo", Owners: []string{"@org/dev", "@alice"}},
+		{Pattern: "/src/*.md", Owners: []string{"@org/docs"}},
+	}
+	files := []string{"README.md", "src/main.go", "src/foo/a.go", "src/README.md"}
+
+	// Header, comments, casing, duplicates and redundant rules don't matter
+	existing := "# GENERATED FILE\n\n/* @org/admin\n# from src/CODEOWNERS\n/src @Alice @org/dev @alice\n/src/*.md @org/docs # src/CODEOWNERS:2\n"
+	require.Equal(t, []Rule{
+		{Pattern: "/*", Owners: []string{"@org/admin"}, Line: 3},
+		{Pattern: "/src", Owners: []string{"@Alice", "@org/dev", "@alice"}, Line: 5},
+		{Pattern: "/src/*.md", Owners: []string{"@org/docs"}, Comment: "src/CODEOWNERS:2", Line: 6},
+	}, ParseCodeownersRules(existing))
+	require.True(t, SameOwnership(ParseCodeownersRules(existing), rules, files))
+
+	outdated := "/* @org/admin\n/src @org/dev\n/src/*.md @org/docs\n"
+	require.False(t, SameOwnership(ParseCodeownersRules(outdated), rules, files))
+}
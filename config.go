@@ -0,0 +1,218 @@
+package codeowners
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileName is the name of the optional config file in the repo root.
+const ConfigFileName = ".codeowners.yaml"
+
+// Config is the repo-level configuration of the checks run on the CODEOWNERS
+// files, read from .codeowners.yaml.
+type Config struct {
+	Policy PolicyConfig `yaml:"policy"`
+	Lint   LintConfig   `yaml:"lint"`
+
+	// Severities override the severity of checks, given by ID or name, with
+	// warning, error or off, which drops their findings.
+	Severities map[string]string `yaml:"severities"`
+
+	// Strict turns all warnings into errors, after the Severities.
+	Strict bool `yaml:"strict"`
+
+	GitLab GitLabConfig `yaml:"gitlab"`
+}
+
+// GitLabConfig configures the sections of the file generated for GitLab.
+type GitLabConfig struct {
+	// SectionPerTopLevelDir puts the rules of nested CO files without section
+	// pragma into a section named after the top-level dir of the file. The
+	// rules of the root CO file stay outside of sections.
+	SectionPerTopLevelDir bool `yaml:"section-per-top-level-dir"`
+
+	// Sections override the approvals and the optional marker of sections by
+	// name, e.g. to require two approvals in the Documentation section without
+	// a pragma in every file of the section.
+	Sections map[string]SectionConfig `yaml:"sections"`
+}
+
+// SectionConfig overrides the settings of a GitLab section, nil fields keep
+// the value of the pragmas.
+type SectionConfig struct {
+	Approvals *int  `yaml:"approvals"`
+	Optional  *bool `yaml:"optional"`
+}
+
+// PolicyConfig configures the policy checks. The zero value disables them.
+type PolicyConfig struct {
+	// MinOwners is the minimum number of owners every rule must have.
+	MinOwners int `yaml:"min-owners"`
+
+	// RequireTeams only allows teams (@org/team) as owners, no users or emails.
+	RequireTeams bool `yaml:"require-teams"`
+
+	// MinCoverage is the minimum percentage of files that must have an owner.
+	MinCoverage float64 `yaml:"min-coverage"`
+
+	// RequireCodeowners requires a nested CO file in or below every dir
+	// matching one of RequireCodeownersDirs, which default to every first-level
+	// dir. Setting RequireCodeownersDirs implies RequireCodeowners.
+	RequireCodeowners     bool     `yaml:"require-codeowners"`
+	RequireCodeownersDirs []string `yaml:"require-codeowners-dirs"`
+
+	// NeverOwned are patterns of paths that must never be owned, e.g.
+	// generated or vendored code. Rules pointing into them are errors, they
+	// are excluded from the coverage and the generated file removes their
+	// ownership.
+	NeverOwned []string `yaml:"never-owned"`
+
+	// Labels override the rule policies for rules with the label, e.g. to
+	// allow a single owner for provisional rules.
+	Labels map[string]LabelPolicy `yaml:"labels"`
+}
+
+// LabelPolicy overrides the rule policies of PolicyConfig, nil fields keep the
+// value of PolicyConfig.
+type LabelPolicy struct {
+	MinOwners    *int  `yaml:"min-owners"`
+	RequireTeams *bool `yaml:"require-teams"`
+}
+
+// requiredCodeownersDirs returns the patterns of the dirs that require a CO
+// file, nil if the policy is disabled.
+func (c PolicyConfig) requiredCodeownersDirs() []string {
+	if len(c.RequireCodeownersDirs) > 0 {
+		return c.RequireCodeownersDirs
+	}
+	if c.RequireCodeowners {
+		return []string{"*"}
+	}
+
+	return nil
+}
+
+// forRule returns the policy for a rule. If several labels of the rule have an
+// override, the one of the first label wins.
+func (c PolicyConfig) forRule(rule Rule) PolicyConfig {
+	for _, label := range rule.Labels {
+		override, ok := c.Labels[label]
+		if !ok {
+			continue
+		}
+
+		if override.MinOwners != nil {
+			c.MinOwners = *override.MinOwners
+		}
+		if override.RequireTeams != nil {
+			c.RequireTeams = *override.RequireTeams
+		}
+		break
+	}
+
+	return c
+}
+
+// LintConfig configures the lint checks.
+type LintConfig struct {
+	// RenamedOwners maps deprecated owners, e.g. teams that have been renamed,
+	// to their replacement.
+	RenamedOwners map[string]string `yaml:"renamed-owners"`
+
+	// ExpiryWarningDays is the number of days before the expiry date of a
+	// rule from which on it is reported, defaultExpiryWarningDays if 0.
+	ExpiryWarningDays int `yaml:"expiry-warning-days"`
+
+	// ResolvedEmails maps lowercased email owners to the @login of their
+	// GitHub account, or "" if they have none. It is set by lint
+	// --resolve-emails, emails missing from it aren't checked.
+	ResolvedEmails map[string]string `yaml:"-"`
+
+	// RewriteEmails makes resolvable email owners fixable findings that are
+	// replaced by the @login of their account.
+	RewriteEmails bool `yaml:"rewrite-emails"`
+
+	// KnownOwners are the valid users and teams, e.g. of the GitHub org.
+	// Other owners are errors with the closest known owners as suggestions,
+	// emails aren't checked. Empty disables the check.
+	KnownOwners []string `yaml:"known-owners"`
+
+	// KnownOwnersFile is a file relative to the repo root with more known
+	// owners, one per line, usually exported with the known-owners command
+	// so that they can be validated offline.
+	KnownOwnersFile string `yaml:"known-owners-file"`
+}
+
+// defaultExpiryWarningDays is the default of LintConfig.ExpiryWarningDays.
+const defaultExpiryWarningDays = 30
+
+// EffectiveExpiryWarningDays returns ExpiryWarningDays or its default.
+func (c LintConfig) EffectiveExpiryWarningDays() int {
+	if c.ExpiryWarningDays == 0 {
+		return defaultExpiryWarningDays
+	}
+
+	return c.ExpiryWarningDays
+}
+
+// LoadConfig reads the config from path. If path is empty, the config file in
+// root is read if it exists, otherwise the zero config is returned.
+func LoadConfig(root, path string) (Config, error) {
+	var cfg Config
+
+	explicit := path != ""
+	if !explicit {
+		path = filepath.Join(root, ConfigFileName)
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("can't read config file %s: %w", path, err)
+	}
+
+	err = yaml.Unmarshal(content, &cfg)
+	if err != nil {
+		return cfg, fmt.Errorf("can't parse config file %s: %w", path, err)
+	}
+
+	severities := map[string]string{}
+	for check, severity := range cfg.Severities {
+		id, ok := lookupCheckID(check)
+		if !ok {
+			return cfg, fmt.Errorf("config file %s sets the severity of unknown check %s", path, check)
+		}
+		if !ContainsString(severityOverrides, severity) {
+			return cfg, fmt.Errorf("config file %s sets invalid severity %q for %s, expected one of %s", path, severity, check, strings.Join(severityOverrides, ", "))
+		}
+		severities[id] = severity
+	}
+	cfg.Severities = severities
+
+	for name, section := range cfg.GitLab.Sections {
+		if section.Approvals != nil && *section.Approvals < 1 {
+			return cfg, fmt.Errorf("config file %s sets invalid number of approvals %d for section %s", path, *section.Approvals, name)
+		}
+		if section.Approvals != nil && section.Optional != nil && *section.Optional {
+			return cfg, fmt.Errorf("config file %s sets approvals for optional section %s", path, name)
+		}
+	}
+
+	if cfg.Lint.KnownOwnersFile != "" {
+		owners, err := readKnownOwnersFile(filepath.Join(root, cfg.Lint.KnownOwnersFile))
+		if err != nil {
+			return cfg, err
+		}
+		cfg.Lint.KnownOwners = append(cfg.Lint.KnownOwners, owners...)
+	}
+
+	return cfg, nil
+}
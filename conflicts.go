@@ -0,0 +1,137 @@
+package codeowners
+
+import (
+	"fmt"
+)
+
+// claimConflict is a rule whose files are, at least partly, claimed by a rule
+// of another CO file with different owners.
+type claimConflict struct {
+	loser, winner int
+
+	// files is the number of files both rules match, example the first one.
+	files   int
+	example string
+}
+
+// FindConflicts reports the rules of a CO file whose patterns reach into
+// files that a rule of another CO file claims for different owners, e.g.
+// "*.md" in src/CODEOWNERS and the dir rule of src/api/CODEOWNERS. The
+// reported rule loses for these files under last-match-wins. Dir rules are
+// skipped since being overridden by nested files is their purpose.
+func FindConflicts(rules []Rule, files []string) []Finding {
+	patterns := make([]pattern, len(rules))
+	for i, rule := range rules {
+		patterns[i] = compilePattern(rule.Pattern)
+	}
+
+	conflicts := map[[2]int]*claimConflict{}
+	var order []*claimConflict
+	for _, file := range files {
+		segments := pathSegments(file)
+
+		winner := -1
+		for i := len(patterns) - 1; i >= 0; i-- {
+			if patterns[i].match(segments) {
+				winner = i
+				break
+			}
+		}
+
+		for i := 0; i < winner; i++ {
+			loser := rules[i]
+			if loser.Dir || loser.Source == rules[winner].Source || ownersKey(loser.Owners) == ownersKey(rules[winner].Owners) {
+				continue
+			}
+			if !patterns[i].match(segments) {
+				continue
+			}
+
+			key := [2]int{i, winner}
+			conflict, ok := conflicts[key]
+			if !ok {
+				conflict = &claimConflict{loser: i, winner: winner, example: file}
+				conflicts[key] = conflict
+				order = append(order, conflict)
+			}
+			conflict.files++
+		}
+	}
+
+	findings := make([]Finding, 0, len(order))
+	for _, conflict := range order {
+		loser, winner := rules[conflict.loser], rules[conflict.winner]
+		findings = append(findings, Finding{
+			Check:    "overlapping-claim",
+			Severity: SeverityWarning,
+			Message: fmt.Sprintf("pattern %s claims %s that %s from %s assigns to other owners and wins, e.g. %s",
+				loser.Pattern, Pluralize(conflict.files, "file"), winner.Pattern, winner.Location(), conflict.example),
+			File: loser.Source,
+			Line: loser.Line,
+		})
+	}
+
+	return findings
+}
+
+// FindShadowedRules reports the dir rules that match files but take effect
+// for none of them, since later rules override all of their matches, at
+// least one of them a rule of another CO file with different owners, e.g. the
+// dir rule of src/CODEOWNERS if all files of src are in dirs with own CO
+// files. Other rules overridden this way are reported by FindConflicts.
+func FindShadowedRules(rules []Rule, files []string) []Finding {
+	patterns := make([]pattern, len(rules))
+	for i, rule := range rules {
+		patterns[i] = compilePattern(rule.Pattern)
+	}
+
+	matched := make([]int, len(rules))
+	wins := make([]int, len(rules))
+	shadowedBy := make([]int, len(rules))
+	examples := make([]string, len(rules))
+	for i := range shadowedBy {
+		shadowedBy[i] = -1
+	}
+
+	for _, file := range files {
+		segments := pathSegments(file)
+
+		winner := -1
+		for i := len(patterns) - 1; i >= 0; i-- {
+			if !patterns[i].match(segments) {
+				continue
+			}
+
+			matched[i]++
+			if winner == -1 {
+				winner = i
+				wins[i]++
+				continue
+			}
+
+			if shadowedBy[i] == -1 && rules[i].Source != rules[winner].Source && ownersKey(rules[i].Owners) != ownersKey(rules[winner].Owners) {
+				shadowedBy[i] = winner
+				examples[i] = file
+			}
+		}
+	}
+
+	var findings []Finding
+	for i, rule := range rules {
+		if !rule.Dir || rule.Source == "" || wins[i] > 0 || shadowedBy[i] == -1 {
+			continue
+		}
+
+		winner := rules[shadowedBy[i]]
+		findings = append(findings, Finding{
+			Check:    "shadowed-rule",
+			Severity: SeverityWarning,
+			Message: fmt.Sprintf("pattern %s never takes effect, later rules claim the %s it matches, e.g. %s by %s from %s for other owners",
+				rule.Pattern, Pluralize(matched[i], "file"), examples[i], winner.Pattern, winner.Location()),
+			File: rule.Source,
+			Line: rule.Line,
+		})
+	}
+
+	return findings
+}
@@ -0,0 +1,51 @@
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindConflicts(t *testing.T) {
+	rules := []Rule{
+		{Pattern: "*", Owners: []string{"@org/admin"}, Source: "CODEOWNERS", Line: 1, Dir: true},
+		{Pattern: "/src", Owners: []string{"@org/dev"}, Source: "src/CODEOWNERS", Line: 1, Dir: true},
+		{Pattern: "/src/**/*.md", Owners: []string{"@org/docs"}, Source: "src/CODEOWNERS", Line: 2},
+		{Pattern: "/src/*.go", Owners: []string{"@org/go"}, Source: "src/CODEOWNERS", Line: 3},
+		{Pattern: "/src/api", Owners: []string{"@org/api"}, Source: "src/api/CODEOWNERS", Line: 1, Dir: true},
+		{Pattern: "/src/web", Owners: []string{"@org/docs"}, Source: "src/web/CODEOWNERS", Line: 1, Dir: true},
+	}
+	files := []string{"README.md", "src/README.md", "src/main.go", "src/api/README.md", "src/api/v1/CHANGES.md", "src/api/api.go", "src/web/README.md"}
+
+	findings := FindConflicts(rules, files)
+	require.Equal(t, []Finding{{
+		Check:    "overlapping-claim",
+		Severity: SeverityWarning,
+		Message:  "pattern /src/**/*.md claims 2 files that /src/api from src/api/CODEOWNERS:1 assigns to other owners and wins, e.g. src/api/README.md",
+		File:     "src/CODEOWNERS",
+		Line:     2,
+	}}, findings)
+}
+
+func TestFindShadowedRules(t *testing.T) {
+	rules := []Rule{
+		{Pattern: "*", Owners: []string{"@org/admin"}, Source: "CODEOWNERS", Line: 1, Dir: true},
+		{Pattern: "/src", Owners: []string{"@org/dev"}, Source: "src/CODEOWNERS", Line: 1, Dir: true},
+		{Pattern: "/src/*.go", Owners: []string{"@org/go"}, Source: "src/CODEOWNERS", Line: 2},
+		{Pattern: "/src/main.go", Owners: []string{"@org/go"}, Source: "src/CODEOWNERS", Line: 3},
+		{Pattern: "/src/api/*.go", Owners: []string{"@org/go"}, Source: "src/CODEOWNERS", Line: 4}, // Reported by FindConflicts
+		{Pattern: "/src/api", Owners: []string{"@org/api"}, Source: "src/api/CODEOWNERS", Line: 1, Dir: true},
+		{Pattern: "/src/web", Owners: []string{"@org/web"}, Source: "src/web/CODEOWNERS", Line: 1, Dir: true},
+		{Pattern: "/docs", Owners: []string{"@org/admin"}, Source: "docs/CODEOWNERS", Line: 1, Dir: true},
+	}
+	files := []string{"README.md", "src/main.go", "src/api/api.go", "src/web/index.html", "docs/index.md"}
+
+	findings := FindShadowedRules(rules, files)
+	require.Equal(t, []Finding{{
+		Check:    "shadowed-rule",
+		Severity: SeverityWarning,
+		Message:  "pattern /src never takes effect, later rules claim the 3 files it matches, e.g. src/api/api.go by /src/api from src/api/CODEOWNERS:1 for other owners",
+		File:     "src/CODEOWNERS",
+		Line:     1,
+	}}, findings)
+}
@@ -0,0 +1,138 @@
+package codeowners
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Coverage describes how many files are owned by the rules.
+type Coverage struct {
+	Files   int      `json:"files"`
+	Owned   int      `json:"owned"`
+	Unowned []string `json:"unowned"`
+}
+
+// Percent returns the percentage of owned files, 100 if there are no files.
+func (c Coverage) Percent() float64 {
+	if c.Files == 0 {
+		return 100
+	}
+
+	return 100 * float64(c.Owned) / float64(c.Files)
+}
+
+// ComputeCoverage matches every file against the rules and collects the
+// files without owner.
+func ComputeCoverage(rules []Rule, files []string) Coverage {
+	matcher := NewMatcher(rules)
+
+	coverage := Coverage{Files: len(files), Unowned: []string{}}
+	for _, file := range files {
+		if _, ok := matcher.Match(file); ok {
+			coverage.Owned++
+		} else {
+			coverage.Unowned = append(coverage.Unowned, file)
+		}
+	}
+
+	return coverage
+}
+
+// DirCoverage is the coverage of the files in a top-level dir of the repo.
+type DirCoverage struct {
+	// Dir is the top-level dir, "." for the files in the root.
+	Dir string `json:"dir"`
+
+	Files   int     `json:"files"`
+	Owned   int     `json:"owned"`
+	Unowned int     `json:"unowned"`
+	Percent float64 `json:"percent"`
+}
+
+// Sort orders of ComputeCoverageByDir.
+const (
+	SortByDir      = "dir"
+	SortByCoverage = "coverage"
+	SortByUnowned  = "unowned"
+)
+
+// ComputeCoverageByDir computes the coverage per top-level dir. The dirs are
+// sorted by name, by coverage with the worst first or by the number of
+// unowned files with the most first.
+func ComputeCoverageByDir(rules []Rule, files []string, sortBy string) ([]DirCoverage, error) {
+	return computeCoverageBy(rules, files, sortBy, func(file string) string {
+		if i := strings.Index(file, "/"); i >= 0 {
+			return file[:i]
+		}
+		return "."
+	})
+}
+
+// ComputeCoverageByPackage computes the coverage per package, e.g. as found by
+// FindPackages, sorted like ComputeCoverageByDir. The files count for the
+// deepest package containing them, the files outside of any package for ".".
+func ComputeCoverageByPackage(rules []Rule, files []string, packages []Package, sortBy string) ([]DirCoverage, error) {
+	return computeCoverageBy(rules, files, sortBy, func(file string) string {
+		dir := "."
+		for _, pkg := range packages {
+			if pkg.Dir != "." && strings.HasPrefix(file, pkg.Dir+"/") && (dir == "." || len(pkg.Dir) > len(dir)) {
+				dir = pkg.Dir
+			}
+		}
+		return dir
+	})
+}
+
+// computeCoverageBy computes the coverage per dir returned by dirOf.
+func computeCoverageBy(rules []Rule, files []string, sortBy string, dirOf func(file string) string) ([]DirCoverage, error) {
+	matcher := NewMatcher(rules)
+
+	byDir := map[string]*DirCoverage{}
+	for _, file := range files {
+		dir := dirOf(file)
+
+		coverage, ok := byDir[dir]
+		if !ok {
+			coverage = &DirCoverage{Dir: dir}
+			byDir[dir] = coverage
+		}
+
+		coverage.Files++
+		if _, ok := matcher.Match(file); ok {
+			coverage.Owned++
+		} else {
+			coverage.Unowned++
+		}
+	}
+
+	dirs := make([]DirCoverage, 0, len(byDir))
+	for _, coverage := range byDir {
+		coverage.Percent = Coverage{Files: coverage.Files, Owned: coverage.Owned}.Percent()
+		dirs = append(dirs, *coverage)
+	}
+
+	var less func(a, b DirCoverage) bool
+	switch sortBy {
+	case SortByDir, "":
+		less = func(a, b DirCoverage) bool { return false }
+	case SortByCoverage:
+		less = func(a, b DirCoverage) bool { return a.Percent < b.Percent }
+	case SortByUnowned:
+		less = func(a, b DirCoverage) bool { return a.Unowned > b.Unowned }
+	default:
+		return nil, fmt.Errorf("unknown sort order %s, must be one of %s, %s or %s", sortBy, SortByDir, SortByCoverage, SortByUnowned)
+	}
+
+	sort.Slice(dirs, func(i, j int) bool {
+		if less(dirs[i], dirs[j]) {
+			return true
+		}
+		if less(dirs[j], dirs[i]) {
+			return false
+		}
+		return dirs[i].Dir < dirs[j].Dir
+	})
+
+	return dirs, nil
+}
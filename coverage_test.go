@@ -0,0 +1,63 @@
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeCoverageByDir(t *testing.T) {
+	rules := []Rule{
+		{Pattern: "/src", Owners: []string{"@org/dev"}},
+		{Pattern: "/docs/guide.md", Owners: []string{"@org/docs"}},
+	}
+	files := []string{"README.md", "src/main.go", "src/lib/lib.go", "docs/guide.md", "docs/api.md", "docs/faq.md", "tools/gen.go"}
+
+	dirs, err := ComputeCoverageByDir(rules, files, SortByDir)
+	require.NoError(t, err)
+	require.Equal(t, []DirCoverage{
+		{Dir: ".", Files: 1, Owned: 0, Unowned: 1, Percent: 0},
+		{Dir: "docs", Files: 3, Owned: 1, Unowned: 2, Percent: 100.0 / 3},
+		{Dir: "src", Files: 2, Owned: 2, Unowned: 0, Percent: 100},
+		{Dir: "tools", Files: 1, Owned: 0, Unowned: 1, Percent: 0},
+	}, dirs)
+
+	dirs, err = ComputeCoverageByDir(rules, files, SortByCoverage)
+	require.NoError(t, err)
+	require.Equal(t, []string{".", "tools", "docs", "src"}, dirNames(dirs))
+
+	dirs, err = ComputeCoverageByDir(rules, files, SortByUnowned)
+	require.NoError(t, err)
+	require.Equal(t, []string{"docs", ".", "tools", "src"}, dirNames(dirs))
+
+	_, err = ComputeCoverageByDir(rules, files, "size")
+	require.Error(t, err)
+}
+
+func TestComputeCoverageByPackage(t *testing.T) {
+	rules := []Rule{{Pattern: "/apps", Owners: []string{"@org/web"}}}
+	files := []string{"README.md", "apps/web/index.js", "apps/web/admin/index.js", "apps/web/admin/users.js", "libs/ui/button.js", "libs/ui/input.js"}
+	packages := []Package{
+		{Dir: "apps/web", Name: "@org/web", Kind: PackageKindJS},
+		{Dir: "apps/web/admin", Name: "@org/admin", Kind: PackageKindJS},
+		{Dir: "libs/ui", Name: "@org/ui", Kind: PackageKindJS},
+	}
+
+	dirs, err := ComputeCoverageByPackage(rules, files, packages, SortByUnowned)
+	require.NoError(t, err)
+	require.Equal(t, []DirCoverage{
+		{Dir: "libs/ui", Files: 2, Owned: 0, Unowned: 2, Percent: 0},
+		{Dir: ".", Files: 1, Owned: 0, Unowned: 1, Percent: 0},
+		{Dir: "apps/web", Files: 1, Owned: 1, Unowned: 0, Percent: 100},
+		{Dir: "apps/web/admin", Files: 2, Owned: 2, Unowned: 0, Percent: 100},
+	}, dirs)
+}
+
+func dirNames(dirs []DirCoverage) []string {
+	names := make([]string, len(dirs))
+	for i, dir := range dirs {
+		names[i] = dir.Dir
+	}
+
+	return names
+}
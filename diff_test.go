@@ -0,0 +1,120 @@
+package codeowners
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	require.Equal(t, "", UnifiedDiff("a", "b", "x\ny\n", "x\ny\n"))
+
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	b := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n"
+
+	expected := `--- a
++++ b
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+@@ -10,3 +10,4 @@
+ 10
+ 11
+ 12
++13
+`
+	require.Equal(t, expected, UnifiedDiff("a", "b", a, b))
+
+	expected = `--- a
++++ b
+@@ -0,0 +1,2 @@
++x
++y
+`
+	require.Equal(t, expected, UnifiedDiff("a", "b", "", "x\ny\n"))
+}
+
+func TestDiffLines(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	randomLines := func() []string {
+		lines := make([]string, random.Intn(12))
+		for i := range lines {
+			lines[i] = fmt.Sprint(random.Intn(4))
+		}
+		return lines
+	}
+
+	for i := 0; i < 2000; i++ {
+		a, b := randomLines(), randomLines()
+		ops := diffLines(a, b)
+
+		// The script turns a into b with a minimal number of edits
+		var fromA, fromB []string
+		edits := 0
+		for _, op := range ops {
+			if op.kind != '+' {
+				fromA = append(fromA, op.line)
+			}
+			if op.kind != '-' {
+				fromB = append(fromB, op.line)
+			}
+			if op.kind != ' ' {
+				edits++
+			}
+		}
+		require.Equal(t, fmt.Sprint(a), fmt.Sprint(fromA))
+		require.Equal(t, fmt.Sprint(b), fmt.Sprint(fromB))
+		require.Equal(t, len(a)+len(b)-2*lcsLength(a, b), edits, "%v %v", a, b)
+	}
+}
+
+func TestDiffLinesLarge(t *testing.T) {
+	a := make([]string, 20000)
+	for i := range a {
+		a[i] = fmt.Sprintf("/src/dir%d @org/team%d", i, i%50)
+	}
+	b := append([]string(nil), a...)
+	for i := 0; i < len(b); i += 100 {
+		b[i] = b[i] + " @org/new"
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	require.Len(t, diffLines(nil, a), len(a))
+	require.Len(t, diffLines(a, b), len(a)+len(a)/100)
+	runtime.ReadMemStats(&after)
+
+	// The memory is linear in the input, not in the number of edits times it
+	require.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(64<<20))
+}
+
+// lcsLength returns the length of the longest common subsequence of a and b.
+func lcsLength(a, b []string) int {
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			case lengths[i+1][j] > lengths[i][j+1]:
+				lengths[i][j] = lengths[i+1][j]
+			default:
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	return lengths[0][0]
+}
@@ -0,0 +1,15 @@
+// Package codeowners generates a root CODEOWNERS file from the CODEOWNERS
+// files nested in the dirs of a repo.
+//
+// The nested files are found by WalkCodeowners, their rules are parsed and
+// rewritten relative to the repo root by RewriteCodeownersRules and rendered
+// by GenerateCodeownersFile. Generator bundles these steps:
+//
+//	rules, err := codeowners.Generator{}.Rules(ctx, root)
+//	if err != nil {
+//		return err
+//	}
+//	owners, _ := rules.Owners("src/main.go")
+//
+// The command line tool is in cmd/codeowners.
+package codeowners
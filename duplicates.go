@@ -0,0 +1,54 @@
+package codeowners
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FindDuplicatePatterns reports every pattern that rules of more than one CO
+// file produce, even with different owners, listing all of them. Usually a
+// CODEOWNERS file was copy-pasted and should be consolidated, otherwise only
+// the last rule takes effect, which surfaces as confusing review requests.
+// The trailing "/" of dir patterns is ignored for the comparison.
+func FindDuplicatePatterns(rules []Rule) []Finding {
+	byPattern := map[string][]Rule{}
+	var patterns []string
+	for _, rule := range rules {
+		if rule.Source == "" { // Skip the rules without CO file, e.g. never-owned rules
+			continue
+		}
+
+		key := strings.TrimSuffix(rule.Pattern, "/")
+		if _, ok := byPattern[key]; !ok {
+			patterns = append(patterns, key)
+		}
+		byPattern[key] = append(byPattern[key], rule)
+	}
+
+	var findings []Finding
+	for _, pattern := range patterns {
+		duplicates := byPattern[pattern]
+
+		sources := map[string]bool{}
+		locations := make([]string, len(duplicates))
+		for i, rule := range duplicates {
+			sources[rule.Source] = true
+			locations[i] = rule.Location()
+		}
+		if len(sources) < 2 {
+			continue
+		}
+
+		last := duplicates[len(duplicates)-1]
+		findings = append(findings, Finding{
+			Check:    "duplicate-pattern",
+			Severity: SeverityWarning,
+			Message: fmt.Sprintf("pattern %s is produced by %d CODEOWNERS files (%s), only %s takes effect, consider consolidating them",
+				pattern, len(sources), strings.Join(locations, ", "), last.Location()),
+			File: last.Source,
+			Line: last.Line,
+		})
+	}
+
+	return findings
+}
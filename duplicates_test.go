@@ -0,0 +1,27 @@
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindDuplicatePatterns(t *testing.T) {
+	rules := []Rule{
+		{Pattern: "*", Owners: []string{"@org/admin"}, Source: "CODEOWNERS", Line: 1},
+		{Pattern: "/src/api/", Owners: []string{"@org/admin"}, Source: "CODEOWNERS", Line: 2},
+		{Pattern: "/src/**/*.go", Owners: []string{"@org/go"}, Source: "src/CODEOWNERS", Line: 1},
+		{Pattern: "/src/**/*.go", Owners: []string{"@org/go"}, Source: "src/CODEOWNERS", Line: 5},
+		{Pattern: "/src/api", Owners: []string{"@org/api"}, Source: "src/api/CODEOWNERS", Line: 1, Dir: true},
+		{Pattern: "/gen/**", Comment: neverOwnedComment},
+		{Pattern: "/gen/**", Comment: neverOwnedComment},
+	}
+
+	require.Equal(t, []Finding{{
+		Check:    "duplicate-pattern",
+		Severity: SeverityWarning,
+		Message:  "pattern /src/api is produced by 2 CODEOWNERS files (CODEOWNERS:2, src/api/CODEOWNERS:1), only src/api/CODEOWNERS:1 takes effect, consider consolidating them",
+		File:     "src/api/CODEOWNERS",
+		Line:     1,
+	}}, FindDuplicatePatterns(rules))
+}
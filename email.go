@@ -0,0 +1,53 @@
+package codeowners
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+// isEmailOwner checks whether owner is a syntactically valid email address
+// (RFC 5322 addr-spec) with a dotted domain, without display name or angle
+// brackets.
+func isEmailOwner(owner string) bool {
+	if strings.HasPrefix(owner, "@") {
+		return false
+	}
+
+	addr, err := mail.ParseAddress(owner)
+	if err != nil || addr.Name != "" || addr.Address != owner {
+		return false
+	}
+
+	domain := owner[strings.LastIndex(owner, "@")+1:]
+	return strings.Contains(domain, ".") && !strings.HasPrefix(domain, ".") && !strings.HasSuffix(domain, ".")
+}
+
+// ResolveEmailOwners looks up the GitHub accounts of the email owners of the
+// rules. GitHub only requests reviews from emails tied to an account, so the
+// result maps every lowercased email owner to the @login of its account or ""
+// if it has none.
+func ResolveEmailOwners(ctx context.Context, client *GitHubClient, rules []Rule) (map[string]string, error) {
+	resolved := map[string]string{}
+	for _, rule := range rules {
+		for _, owner := range rule.Owners {
+			key := strings.ToLower(owner)
+			if _, ok := resolved[key]; ok || !isEmailOwner(owner) {
+				continue
+			}
+
+			login, err := client.UserByEmail(ctx, owner)
+			if err != nil {
+				return nil, fmt.Errorf("can't resolve email owner %s: %w", owner, err)
+			}
+
+			resolved[key] = ""
+			if login != "" {
+				resolved[key] = "@" + login
+			}
+		}
+	}
+
+	return resolved, nil
+}
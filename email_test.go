@@ -0,0 +1,66 @@
+package codeowners
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsEmailOwner(t *testing.T) {
+	for _, owner := range []string{"dev@example.com", "first.last+tag@mail.example.org"} {
+		require.True(t, isEmailOwner(owner), owner)
+	}
+
+	for _, owner := range []string{"@user", "@org/team", "dev@example", "dev@@example.com", "Dev <dev@example.com>", "dev@.example.com", "dev@example.com."} {
+		require.False(t, isEmailOwner(owner), owner)
+	}
+}
+
+func TestResolveEmailOwners(t *testing.T) {
+	requests := 0
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search/users", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		result := map[string]interface{}{"total_count": 0, "items": []interface{}{}}
+		if r.URL.Query().Get("q") == "dev@example.com in:email" {
+			result = map[string]interface{}{"total_count": 1, "items": []map[string]string{{"login": "dev"}}}
+		}
+		_ = json.NewEncoder(w).Encode(result)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := &GitHubClient{BaseURL: server.URL, HTTPClient: server.Client()}
+
+	rules := []Rule{
+		{Pattern: "/src", Owners: []string{"dev@example.com", "@org/team"}},
+		{Pattern: "/docs", Owners: []string{"DEV@example.com", "old@example.com"}},
+	}
+
+	resolved, err := ResolveEmailOwners(context.Background(), client, rules)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"dev@example.com": "@dev", "old@example.com": ""}, resolved)
+	require.Equal(t, 2, requests)
+
+	repoPath := t.TempDir()
+	writeFile(t, repoPath, "src/CODEOWNERS", "dev@example.com old@example.com\n")
+
+	cfg := LintConfig{ResolvedEmails: resolved, RewriteEmails: true}
+	fixed, remaining, err := FixCodeownersFiles(context.Background(), repoPath, cfg)
+	require.NoError(t, err)
+	require.Len(t, fixed, 1)
+	require.Equal(t, "src/CODEOWNERS:1: warning: dev@example.com can be replaced by its account @dev [CO020 email-owner]", fixed[0].String())
+	require.Len(t, remaining, 1)
+	require.Equal(t, "src/CODEOWNERS:1: warning: old@example.com isn't tied to a GitHub account and won't be requested for review [CO021 unresolved-email]", remaining[0].String())
+
+	rewrittenRules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"/src @dev old@example.com"}, ruleStrings(rewrittenRules))
+}
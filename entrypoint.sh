@@ -2,5 +2,9 @@
 #
 # Entrypoint for the Dockerfile for use as GitHub Action
 
+# The workspace is owned by the runner user, which git refuses to read
+# without marking it as safe
+git config --global --add safe.directory /github/workspace
+
 # /github/workspace is to where GitHub maps the repo and sets the workdir
 /codeowners /github/workspace > /github/workspace/.github/CODEOWNERS
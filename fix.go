@@ -0,0 +1,336 @@
+package codeowners
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// lintedFile is a nested CO file together with its findings and the content
+// with all fixable findings fixed.
+type lintedFile struct {
+	path     string
+	source   string
+	lines    []string
+	fixed    []string
+	findings []Finding
+}
+
+// changed checks whether fixing the findings changes the file.
+func (f lintedFile) changed() bool {
+	return strings.Join(f.lines, "\n") != strings.Join(f.fixed, "\n")
+}
+
+// linter holds the repo-wide state needed to lint single lines.
+type linter struct {
+	// renames maps lowercased deprecated owners to their replacement.
+	renames map[string]string
+
+	// spellings maps lowercased owners to their most common spelling.
+	spellings map[string]string
+
+	// emails maps lowercased email owners to their account, see
+	// LintConfig.ResolvedEmails.
+	emails        map[string]string
+	rewriteEmails bool
+
+	// known are the lowercased known owners, see LintConfig.KnownOwners.
+	known      map[string]bool
+	knownNames []string
+}
+
+// lintCodeownersFiles reads and lints every nested CO file under root.
+func lintCodeownersFiles(ctx context.Context, root string, cfg LintConfig) ([]lintedFile, error) {
+	var files []lintedFile
+
+	err := walkCodeownersFiles(ctx, root, func(coPath string) error {
+		source, err := relativeSourcePath(root, coPath)
+		if err != nil {
+			return err
+		}
+
+		lines, err := readCodeownersFile(coPath)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, lintedFile{path: coPath, source: source, lines: lines})
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("error while linting CODEOWNERS files: %w", err)
+	}
+
+	l := newLinter(files, cfg)
+	for i := range files {
+		file := &files[i]
+		file.fixed = make([]string, len(file.lines))
+		for j, line := range file.lines {
+			fixed, findings := l.lintLine(file.source, j+1, line)
+			if fixed != line {
+				// The fix of every fixable finding fixes the whole line
+				for k := range findings {
+					if findings[k].Fixable {
+						findings[k].Fix = &Fix{File: file.source, StartLine: j + 1, EndLine: j + 1, Replacement: fixed}
+					}
+				}
+			}
+			file.fixed[j] = fixed
+			file.findings = append(file.findings, findings...)
+		}
+		file.findings = append(file.findings, lintEffectiveRules(root, *file)...)
+	}
+
+	return files, nil
+}
+
+// lintEffectiveRules reports CO files that yield no rules, either because they
+// contain only comments and blank lines or because all of their rules are
+// dropped. Teams easily believe to have declared ownership with such a file.
+func lintEffectiveRules(root string, file lintedFile) []Finding {
+	rewrittenPath, err := rewriteCodeownersPath(root, file.path, "")
+	if err != nil {
+		return nil
+	}
+
+	// Pragma errors are reported when the rules are rewritten
+	rules, err := processCodeownersLines(file.source, rewrittenPath, file.lines, Options{})
+	if err != nil || len(rules) > 0 {
+		return nil
+	}
+
+	reason := "it contains only comments and blank lines"
+	for _, line := range file.lines {
+		if isCodeownersRule(line) {
+			reason = "all of its rules are dropped"
+			break
+		}
+	}
+
+	return []Finding{{
+		Check:    "no-effective-rules",
+		Severity: SeverityWarning,
+		Message:  fmt.Sprintf("CODEOWNERS file declares no ownership, %s", reason),
+		File:     file.source,
+	}}
+}
+
+// newLinter creates a linter for the given files. The most common spelling of
+// every owner (after renames) is used as its canonical spelling, ties are
+// broken by the first occurrence.
+func newLinter(files []lintedFile, cfg LintConfig) *linter {
+	l := &linter{
+		renames:   map[string]string{},
+		spellings: map[string]string{},
+
+		emails:        cfg.ResolvedEmails,
+		rewriteEmails: cfg.RewriteEmails,
+	}
+
+	for owner, replacement := range cfg.RenamedOwners {
+		l.renames[strings.ToLower(owner)] = replacement
+	}
+
+	if len(cfg.KnownOwners) > 0 {
+		l.known = map[string]bool{}
+		l.knownNames = cfg.KnownOwners
+		for _, owner := range cfg.KnownOwners {
+			l.known[strings.ToLower(owner)] = true
+		}
+	}
+
+	counts := map[string]int{}
+	for _, file := range files {
+		for _, line := range file.lines {
+			if !isCodeownersRule(line) {
+				continue
+			}
+
+			_, owners, _ := splitCodeownersRule(line)
+			for _, owner := range owners {
+				owner = l.rename(owner)
+				counts[owner]++
+
+				key := strings.ToLower(owner)
+				best, ok := l.spellings[key]
+				if !ok || counts[owner] > counts[best] {
+					l.spellings[key] = owner
+				}
+			}
+		}
+	}
+
+	return l
+}
+
+// rename returns the replacement of a deprecated owner, the account of an
+// email owner if emails are rewritten, or the owner itself.
+func (l *linter) rename(owner string) string {
+	if replacement, ok := l.renames[strings.ToLower(owner)]; ok {
+		return replacement
+	}
+
+	if account := l.emails[strings.ToLower(owner)]; l.rewriteEmails && account != "" {
+		return account
+	}
+
+	return owner
+}
+
+// lintLine lints a single line of a nested CO file and returns the line with
+// all fixable findings fixed.
+func (l *linter) lintLine(source string, line int, text string) (string, []Finding) {
+	var findings []Finding
+	report := func(check, message string) {
+		findings = append(findings, Finding{
+			Check:    check,
+			Severity: SeverityWarning,
+			Message:  message,
+			File:     source,
+			Line:     line,
+			Fixable:  true,
+		})
+	}
+
+	fixed := strings.TrimRight(text, " \t")
+	if fixed != text {
+		report("trailing-whitespace", "line has trailing whitespace")
+	}
+
+	if !isCodeownersRule(fixed) {
+		return fixed, findings
+	}
+
+	if strings.Contains(fixed, "\t") {
+		report("tab-separator", "rule is separated by tabs instead of spaces")
+		fixed = strings.ReplaceAll(fixed, "\t", " ")
+	}
+
+	findings = append(findings, lintCodeownersRule(source, line, fixed)...)
+
+	pattern, owners, comment := splitCodeownersRule(fixed)
+	seen := map[string]bool{}
+	var fixedOwners []string
+	for _, owner := range owners {
+		renamed := l.rename(owner)
+		switch {
+		case renamed == owner:
+		case l.emails[strings.ToLower(owner)] == renamed:
+			report("email-owner", fmt.Sprintf("%s can be replaced by its account %s", owner, renamed))
+		default:
+			report("renamed-owner", fmt.Sprintf("%s has been renamed to %s", owner, renamed))
+		}
+
+		if account, ok := l.emails[strings.ToLower(renamed)]; ok && account == "" {
+			findings = append(findings, Finding{
+				Check:    "unresolved-email",
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("%s isn't tied to a GitHub account and won't be requested for review", renamed),
+				File:     source,
+				Line:     line,
+			})
+		}
+
+		if l.known != nil && isValidOwner(renamed) && !isEmailOwner(renamed) && !l.known[strings.ToLower(renamed)] {
+			findings = append(findings, l.unknownOwner(source, line, renamed))
+		}
+
+		spelling := l.spellings[strings.ToLower(renamed)]
+		if spelling != renamed {
+			report("owner-casing", fmt.Sprintf("%s is spelled %s elsewhere", renamed, spelling))
+		}
+
+		if seen[strings.ToLower(spelling)] {
+			report("duplicate-owner", fmt.Sprintf("%s is listed more than once", spelling))
+			continue
+		}
+
+		seen[strings.ToLower(spelling)] = true
+		fixedOwners = append(fixedOwners, spelling)
+	}
+
+	if strings.Join(fixedOwners, " ") != strings.Join(owners, " ") {
+		fixed = joinCodeownersRule(pattern, fixedOwners, comment)
+	}
+
+	return fixed, findings
+}
+
+// unknownOwner reports an owner that isn't known, suggesting the closest
+// known owners since most unknown owners are typos.
+func (l *linter) unknownOwner(source string, line int, owner string) Finding {
+	message := fmt.Sprintf("%s isn't a known owner", owner)
+	if suggestions := suggestOwners(owner, l.knownNames); len(suggestions) > 0 {
+		message += fmt.Sprintf(", did you mean %s?", strings.Join(suggestions, " or "))
+	}
+
+	return Finding{
+		Check:    "unknown-owner",
+		Severity: SeverityError,
+		Message:  message,
+		File:     source,
+		Line:     line,
+	}
+}
+
+// splitCodeownersRule splits a rule of a nested CO file into its pattern
+// (empty for dir rules), its owners and its comment.
+func splitCodeownersRule(rule string) (string, []string, string) {
+	tokens, comment := tokenizeCodeownersRule(rule)
+	if len(tokens) == 0 || isDirRule(tokens) {
+		return "", tokens, comment
+	}
+
+	return tokens[0], tokens[1:], comment
+}
+
+// joinCodeownersRule is the inverse of splitCodeownersRule.
+func joinCodeownersRule(pattern string, owners []string, comment string) string {
+	var tokens []string
+	if pattern != "" {
+		tokens = append(tokens, pattern)
+	}
+	tokens = append(tokens, owners...)
+	if comment != "" {
+		tokens = append(tokens, codeownersCommentPrefix, comment)
+	}
+
+	return strings.Join(tokens, " ")
+}
+
+// FixCodeownersFiles rewrites the nested CO files under root in place to fix
+// all fixable findings. It returns the findings that were fixed and the ones
+// that remain.
+func FixCodeownersFiles(ctx context.Context, root string, cfg LintConfig) ([]Finding, []Finding, error) {
+	files, err := lintCodeownersFiles(ctx, root, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var fixed, remaining []Finding
+	for _, file := range files {
+		if file.changed() {
+			// Write through symlinks instead of replacing them
+			path, err := resolveCodeownersFile(file.path)
+			if err != nil {
+				return fixed, nil, fmt.Errorf("can't fix %s: %w", file.source, err)
+			}
+
+			err = WriteFileAtomic(path, strings.Join(file.fixed, "\n"))
+			if err != nil {
+				return fixed, nil, fmt.Errorf("can't fix %s: %w", file.source, err)
+			}
+		}
+
+		for _, finding := range file.findings {
+			if finding.Fixable {
+				fixed = append(fixed, finding)
+			} else {
+				remaining = append(remaining, finding)
+			}
+		}
+	}
+
+	return fixed, remaining, nil
+}
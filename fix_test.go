@@ -0,0 +1,149 @@
+package codeowners
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixCodeownersFiles(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "CODEOWNERS", "@org/Docs @org/old  \n")
+	writeFile(t, repoPath, "src/CODEOWNERS", "# Comment  \n@org/docs @org/docs # Keep me\nmain.go\t@org/docs\n*.go @not_valid\n")
+
+	cfg := LintConfig{RenamedOwners: map[string]string{"@org/OLD": "@org/new"}}
+
+	findings, err := LintCodeownersFiles(context.Background(), repoPath, cfg)
+	require.NoError(t, err)
+	require.Len(t, findings, 7)
+
+	fixed, remaining, err := FixCodeownersFiles(context.Background(), repoPath, cfg)
+	require.NoError(t, err)
+
+	var fixedStrings []string
+	for _, finding := range fixed {
+		fixedStrings = append(fixedStrings, finding.String())
+	}
+	require.Equal(t, []string{
+		"CODEOWNERS:1: warning: line has trailing whitespace [CO015 trailing-whitespace]",
+		"CODEOWNERS:1: warning: @org/Docs is spelled @org/docs elsewhere [CO018 owner-casing]",
+		"CODEOWNERS:1: warning: @org/old has been renamed to @org/new [CO017 renamed-owner]",
+		"src/CODEOWNERS:1: warning: line has trailing whitespace [CO015 trailing-whitespace]",
+		"src/CODEOWNERS:2: warning: @org/docs is listed more than once [CO019 duplicate-owner]",
+		"src/CODEOWNERS:3: warning: rule is separated by tabs instead of spaces [CO016 tab-separator]",
+	}, fixedStrings)
+
+	require.Len(t, remaining, 1)
+	require.Equal(t, "invalid-owner", remaining[0].Check)
+
+	content, err := os.ReadFile(filepath.Join(repoPath, "CODEOWNERS"))
+	require.NoError(t, err)
+	require.Equal(t, "@org/docs @org/new\n", string(content))
+
+	content, err = os.ReadFile(filepath.Join(repoPath, "src/CODEOWNERS"))
+	require.NoError(t, err)
+	require.Equal(t, "# Comment\n@org/docs # Keep me\nmain.go @org/docs\n*.go @not_valid\n", string(content))
+
+	// Fixing is idempotent
+	findings, err = LintCodeownersFiles(context.Background(), repoPath, cfg)
+	require.NoError(t, err)
+	require.Equal(t, remaining, findings)
+}
+
+func TestFixSuggestions(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "CODEOWNERS", "@org/docs\n")
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/Docs @org/old\ngone.go @org/docs\n")
+	writeFile(t, repoPath, "src/main.go", "")
+
+	cfg := LintConfig{RenamedOwners: map[string]string{"@org/old": "@org/new"}}
+	findings, err := LintCodeownersFiles(context.Background(), repoPath, cfg)
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+
+	fix := &Fix{File: "src/CODEOWNERS", StartLine: 1, EndLine: 1, Replacement: "@org/docs @org/new"}
+	for _, finding := range findings {
+		require.Equal(t, fix, finding.Fix, finding.Check)
+	}
+
+	rules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.NoError(t, err)
+	stale := FindStaleRules(rules, []string{"CODEOWNERS", "src/CODEOWNERS", "src/main.go"})
+	require.Len(t, stale, 1)
+	require.Equal(t, &Fix{File: "src/CODEOWNERS", StartLine: 2, EndLine: 2}, stale[0].Fix)
+}
+
+func TestLintEffectiveRules(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admin\n")
+	writeFile(t, repoPath, "docs/CODEOWNERS", "# TODO: add owners\n\n")
+	writeFile(t, repoPath, "src/CODEOWNERS", "main.go\n")
+
+	findings, err := LintCodeownersFiles(context.Background(), repoPath, LintConfig{})
+	require.NoError(t, err)
+
+	var messages []string
+	for _, finding := range findings {
+		if finding.Check == "no-effective-rules" {
+			messages = append(messages, finding.String())
+		}
+	}
+	require.Equal(t, []string{
+		"docs/CODEOWNERS: warning: CODEOWNERS file declares no ownership, it contains only comments and blank lines [CO014 no-effective-rules]",
+		"src/CODEOWNERS: warning: CODEOWNERS file declares no ownership, all of its rules are dropped [CO014 no-effective-rules]",
+	}, messages)
+}
+
+func TestLintUnknownOwners(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admin @org/paymnets\n*.md @org/docs dev@example.com @org/Admin\n")
+
+	cfg := LintConfig{KnownOwners: []string{"@org/admin", "@org/payments", "@org/platform"}}
+	findings, err := LintCodeownersFiles(context.Background(), repoPath, cfg)
+	require.NoError(t, err)
+
+	var messages []string
+	for _, finding := range findings {
+		if finding.Check == "unknown-owner" {
+			messages = append(messages, finding.String())
+		}
+	}
+	require.Equal(t, []string{
+		"CODEOWNERS:1: error: @org/paymnets isn't a known owner, did you mean @org/payments? [CO022 unknown-owner]",
+		"CODEOWNERS:2: error: @org/docs isn't a known owner [CO022 unknown-owner]",
+	}, messages)
+}
+
+func TestLintUnsupportedPatterns(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admin\n!vendor @org/admin\n*.[ch] @org/c\nfile\\[1].txt @org/docs\n[abc @org/docs\n")
+
+	findings, err := LintCodeownersFiles(context.Background(), repoPath, LintConfig{})
+	require.NoError(t, err)
+
+	var messages []string
+	for _, finding := range findings {
+		messages = append(messages, finding.String())
+	}
+	require.Equal(t, []string{
+		"CODEOWNERS:2:1: error: pattern !vendor uses ! negation, which GitHub doesn't support [CO031 unsupported-pattern]",
+		"CODEOWNERS:3:1: error: pattern *.[ch] uses a [ ] character range, which GitHub doesn't support [CO031 unsupported-pattern]",
+	}, messages)
+
+	// The generation keeps the rules with a warning
+	var diagnostics []string
+	rules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{Diagnostics: func(finding Finding) {
+		diagnostics = append(diagnostics, finding.Check)
+	}})
+	require.NoError(t, err)
+	require.Len(t, rules, 5)
+	require.Equal(t, []string{"unsupported-pattern", "unsupported-pattern"}, diagnostics)
+}
@@ -0,0 +1,145 @@
+package codeowners
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ReadmeFileName is the name of the READMEs whose front matter can declare
+// the owners of their dir, e.g.
+//
+//	---
+//	owners: ["@org/docs", "@jane"]
+//	---
+//
+// The owners may also be given as one whitespace separated string.
+const ReadmeFileName = "README.md"
+
+// frontMatterDelimiter opens and closes the YAML front matter of a README.
+const frontMatterDelimiter = "---"
+
+// parseReadmeOwners returns the owners declared in the front matter of the
+// README source, which is relative to the root, and the 1-based line of the
+// owners key. Without front matter or owners key, no owners are returned.
+func parseReadmeOwners(source string, content []byte) ([]string, int, error) {
+	lines := strings.Split(string(content), "\n")
+	if strings.TrimRight(lines[0], " \r") != frontMatterDelimiter {
+		return nil, 0, nil
+	}
+
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if line := strings.TrimRight(lines[i], " \r"); line == frontMatterDelimiter || line == "..." {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return nil, 0, &ParseError{Source: source, Line: 1, Err: fmt.Errorf("front matter isn't closed")}
+	}
+
+	var doc yaml.Node
+	err := yaml.Unmarshal([]byte(strings.Join(lines[1:end], "\n")), &doc)
+	if err != nil {
+		return nil, 0, &ParseError{Source: source, Line: 1, Err: fmt.Errorf("can't parse front matter: %w", err)}
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, 0, nil
+	}
+
+	fields := doc.Content[0].Content
+	for i := 0; i+1 < len(fields); i += 2 {
+		if fields[i].Value != "owners" {
+			continue
+		}
+
+		// The front matter starts in the second line
+		line := fields[i].Line + 1
+		value := fields[i+1]
+
+		var owners []string
+		switch value.Kind {
+		case yaml.ScalarNode:
+			owners = strings.Fields(value.Value)
+		case yaml.SequenceNode:
+			for _, node := range value.Content {
+				owners = append(owners, node.Value)
+			}
+		default:
+			return nil, 0, &ParseError{Source: source, Line: line, Err: fmt.Errorf("owners must be a string or a list")}
+		}
+
+		for _, owner := range owners {
+			if !isValidOwner(owner) {
+				return nil, 0, &ParseError{Source: source, Line: line, Err: fmt.Errorf("%s is not a valid user, team or email address", owner)}
+			}
+		}
+
+		return owners, line, nil
+	}
+
+	return nil, 0, nil
+}
+
+// rewriteReadmeRules visits every README under root and rewrites the owners
+// declared in their front matter as dir rules. It returns the rules in BFS
+// order together with their slash separated dirs relative to the root.
+func rewriteReadmeRules(ctx context.Context, root string, opts Options) ([]string, []Rule, error) {
+	var dirs []string
+	var rules []Rule
+	err := walkTree(ctx, root, func(readmePath string, dirEntry fs.DirEntry) error {
+		if dirEntry.Name() != ReadmeFileName {
+			return nil
+		}
+
+		source, err := relativeSourcePath(root, readmePath)
+		if err != nil {
+			return err
+		}
+		if opts.SkipRootCodeowners && source == ReadmeFileName {
+			return nil
+		}
+
+		content, err := os.ReadFile(longPath(readmePath))
+		if err != nil {
+			return fmt.Errorf("can't read %s: %w", source, err)
+		}
+
+		owners, line, err := parseReadmeOwners(source, content)
+		if err != nil || len(owners) == 0 {
+			return err
+		}
+
+		rewrittenPath, err := rewriteCodeownersPath(root, readmePath, opts.PathPrefix)
+		if err != nil {
+			return err
+		}
+
+		// Place the owners at their line so that the rule points to it
+		lines := make([]string, line)
+		lines[line-1] = strings.Join(owners, " ")
+
+		readmeRules, err := processCodeownersLines(source, rewrittenPath, lines, opts)
+		if err != nil {
+			return err
+		}
+
+		for range readmeRules {
+			dirs = append(dirs, path.Dir(source))
+		}
+		rules = append(rules, readmeRules...)
+		return nil
+	})
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while processing %s front matter: %w", ReadmeFileName, err)
+	}
+
+	return dirs, rules, nil
+}
@@ -0,0 +1,54 @@
+package codeowners
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadmeOwners(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admin\n")
+	writeFile(t, repoPath, "docs/README.md", "---\ntitle: Docs\nowners: [\"@org/docs\", \"@jane\"]\n---\n# Docs\n")
+	writeFile(t, repoPath, "docs/api/README.md", "---\nowners: \"@org/api\"\n---\n")
+	writeFile(t, repoPath, "docs/api/CODEOWNERS", "*.yaml @org/schemas\n")
+	writeFile(t, repoPath, "src/README.md", "# No front matter\n\nowners: @org/nobody\n")
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/dev\n")
+
+	rules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{ReadmeOwners: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"* @org/admin",
+		"/docs @org/docs @jane",
+		"/src @org/dev",
+		"/docs/api @org/api",
+		"/docs/api/*.yaml @org/schemas",
+	}, ruleStrings(rules))
+	require.Equal(t, "docs/README.md:3", rules[1].Location())
+	require.True(t, rules[1].Dir)
+
+	rules, err = RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.NoError(t, err)
+	require.Len(t, rules, 3)
+}
+
+func TestParseReadmeOwners(t *testing.T) {
+	owners, line, err := parseReadmeOwners("README.md", []byte("---\nowners:\n  - \"@org/docs\"\n  - docs@example.com\n---\n"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"@org/docs", "docs@example.com"}, owners)
+	require.Equal(t, 2, line)
+
+	_, _, err = parseReadmeOwners("README.md", []byte("---\ntitle: x\nowners: \"@org/docs nobody\"\n---\n"))
+	require.Error(t, err)
+	require.Equal(t, "README.md:3: nobody is not a valid user, team or email address", err.Error())
+
+	_, _, err = parseReadmeOwners("README.md", []byte("---\nowners: \"@org/docs\"\n"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "front matter isn't closed")
+
+	owners, _, err = parseReadmeOwners("README.md", []byte("---\ntitle: x\n---\n"))
+	require.NoError(t, err)
+	require.Empty(t, owners)
+}
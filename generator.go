@@ -0,0 +1,55 @@
+package codeowners
+
+import "context"
+
+// Ruleset is the rewritten rules of a repo in the order of the generated file,
+// later rules take precedence.
+type Ruleset []Rule
+
+// Owners returns the owners of path, relative to the repo root and slash
+// separated, and the rule they come from. The rule is empty if path is
+// unowned.
+func (rs Ruleset) Owners(path string) ([]string, Rule) {
+	rule, ok := NewMatcher(rs).Match(path)
+	if !ok {
+		return nil, Rule{}
+	}
+
+	return rule.Owners, rule
+}
+
+// String renders the rules as root CO file with the default options.
+func (rs Ruleset) String() string {
+	return GenerateCodeownersFile(rs, GenerateOptions{})
+}
+
+// Generator generates the root CO file of a repo from its nested CO files. It
+// bundles the options of RewriteCodeownersRules and GenerateCodeownersFile for
+// tools that embed the generator.
+type Generator struct {
+	// Options configures how the nested CO files are read and rewritten.
+	Options Options
+
+	// File configures how the generated file is rendered.
+	File GenerateOptions
+}
+
+// Rules reads and rewrites the rules of the repo at root.
+func (g Generator) Rules(ctx context.Context, root string) (Ruleset, error) {
+	rules, err := RewriteCodeownersRules(ctx, root, g.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	return Ruleset(rules), nil
+}
+
+// Generate returns the content of the root CO file of the repo at root.
+func (g Generator) Generate(ctx context.Context, root string) (string, error) {
+	rules, err := g.Rules(ctx, root)
+	if err != nil {
+		return "", err
+	}
+
+	return GenerateCodeownersFile(rules, g.File), nil
+}
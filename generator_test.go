@@ -0,0 +1,31 @@
+package codeowners
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator(t *testing.T) {
+	repoPath := t.TempDir()
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admin\n")
+	writeFile(t, repoPath, "src/CODEOWNERS", "*.go @org/go\n")
+
+	generator := Generator{File: GenerateOptions{NoHeader: true}}
+	rules, err := generator.Rules(context.Background(), repoPath)
+	require.NoError(t, err)
+	require.Equal(t, []string{"* @org/admin", "/src/*.go @org/go"}, ruleStrings(rules))
+
+	owners, rule := rules.Owners("src/main.go")
+	require.Equal(t, []string{"@org/go"}, owners)
+	require.Equal(t, "src/CODEOWNERS", rule.Source)
+
+	owners, _ = rules.Owners("README.md")
+	require.Equal(t, []string{"@org/admin"}, owners)
+
+	content, err := generator.Generate(context.Background(), repoPath)
+	require.NoError(t, err)
+	require.Equal(t, GenerateCodeownersFile(rules, GenerateOptions{NoHeader: true}), content)
+	require.Contains(t, rules.String(), "/src/*.go @org/go\n")
+}
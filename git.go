@@ -0,0 +1,376 @@
+package codeowners
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// runGit runs git with args in dir and returns its stdout with surrounding
+// whitespace removed. Stderr is included in the error if git fails.
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	out, err := runGitRaw(ctx, dir, args...)
+	return strings.TrimSpace(out), err
+}
+
+// runGitRaw is runGit without removing whitespace from the output.
+func runGitRaw(ctx context.Context, dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if errors.Is(err, exec.ErrNotFound) {
+		return "", fmt.Errorf("can't run git %s, git isn't installed: %w", strings.Join(args, " "), err)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error while running git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
+
+// GitHeadCommit returns the hash of the commit checked out in dir.
+func GitHeadCommit(ctx context.Context, dir string) (string, error) {
+	return runGit(ctx, dir, "rev-parse", "HEAD")
+}
+
+// BlameInfo describes the commit that last changed a line of a file.
+type BlameInfo struct {
+	Commit  string    `json:"commit"`
+	Author  string    `json:"author"`
+	Email   string    `json:"email"`
+	Time    time.Time `json:"time"`
+	Summary string    `json:"summary"`
+}
+
+// GitBlameLine runs git blame on a single line of file, which is relative to
+// the repo in dir.
+func GitBlameLine(ctx context.Context, dir, file string, line int) (BlameInfo, error) {
+	out, err := runGit(ctx, dir, "blame", "--porcelain", "-L", fmt.Sprintf("%d,%d", line, line), "--", file)
+	if err != nil {
+		return BlameInfo{}, err
+	}
+
+	return parseBlamePorcelain(out)
+}
+
+// parseBlamePorcelain parses the output of git blame --porcelain for a single
+// line.
+func parseBlamePorcelain(out string) (BlameInfo, error) {
+	var info BlameInfo
+
+	lines := strings.Split(out, "\n")
+	if len(lines) == 0 || lines[0] == "" {
+		return info, fmt.Errorf("empty git blame output")
+	}
+	info.Commit = strings.Fields(lines[0])[0]
+
+	for _, line := range lines[1:] {
+		key, value := line, ""
+		if i := strings.IndexByte(line, ' '); i >= 0 {
+			key, value = line[:i], line[i+1:]
+		}
+
+		switch key {
+		case "author":
+			info.Author = value
+		case "author-mail":
+			info.Email = strings.Trim(value, "<>")
+		case "author-time":
+			seconds, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return info, fmt.Errorf("invalid author-time %q in git blame output: %w", value, err)
+			}
+			info.Time = time.Unix(seconds, 0).UTC()
+		case "summary":
+			info.Summary = value
+		}
+	}
+
+	return info, nil
+}
+
+// GitChangedFiles returns the paths of the files changed between the merge
+// base of base and head, and head, like the file list of a pull request.
+func GitChangedFiles(ctx context.Context, dir, base, head string) ([]string, error) {
+	out, err := runGit(ctx, dir, "diff", "--name-only", "--no-renames", "-z", fmt.Sprintf("%s...%s", base, head))
+	if err != nil {
+		return nil, err
+	}
+
+	return splitNulSeparated(out), nil
+}
+
+// splitNulSeparated splits the NUL separated output of a git command run with
+// -z, ignoring empty entries.
+func splitNulSeparated(out string) []string {
+	files := []string{}
+	for _, file := range strings.Split(out, "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+
+	return files
+}
+
+// GitFileChanged checks whether file, relative to the repo in dir, has
+// uncommitted changes or is untracked.
+func GitFileChanged(ctx context.Context, dir, file string) (bool, error) {
+	out, err := runGit(ctx, dir, "status", "--porcelain", "--", file)
+	if err != nil {
+		return false, err
+	}
+
+	return out != "", nil
+}
+
+// GitCommitFile commits the current content of file, relative to the repo in
+// dir, and nothing else.
+func GitCommitFile(ctx context.Context, dir, file, message string) error {
+	_, err := runGit(ctx, dir, "add", "--", file)
+	if err != nil {
+		return err
+	}
+
+	_, err = runGit(ctx, dir, "commit", "--message", message, "--", file)
+	return err
+}
+
+// GitPush pushes the current branch to its upstream.
+func GitPush(ctx context.Context, dir string) error {
+	_, err := runGit(ctx, dir, "push")
+	return err
+}
+
+// gitPull fast-forwards the current branch to its upstream.
+func gitPull(ctx context.Context, dir string) error {
+	_, err := runGit(ctx, dir, "pull", "--ff-only", "--quiet")
+	return err
+}
+
+// GitResolveAsOf resolves asOf, a date (YYYY-MM-DD) or any revision, to a
+// commit hash. For a date the last commit of HEAD's first-parent history up to
+// the end of that day is used.
+func GitResolveAsOf(ctx context.Context, dir, asOf string) (string, error) {
+	if _, err := time.Parse("2006-01-02", asOf); err == nil {
+		commit, err := runGit(ctx, dir, "rev-list", "-1", "--first-parent", "--before="+asOf+" 23:59:59", "HEAD")
+		if err != nil {
+			return "", err
+		}
+		if commit == "" {
+			return "", fmt.Errorf("no commit before %s", asOf)
+		}
+
+		return commit, nil
+	}
+
+	return runGit(ctx, dir, "rev-parse", "--verify", "--quiet", asOf+"^{commit}")
+}
+
+// gitListFiles lists the files of commit, relative to the repo root.
+func gitListFiles(ctx context.Context, dir, commit string) ([]string, error) {
+	out, err := runGitRaw(ctx, dir, "ls-tree", "-r", "-z", "--full-tree", "--name-only", commit)
+	if err != nil {
+		return nil, err
+	}
+
+	return splitNulSeparated(out), nil
+}
+
+// gitListTree lists the files of commit like gitListFiles and returns which
+// of them are symlinks.
+func gitListTree(ctx context.Context, dir, commit string) ([]string, map[string]bool, error) {
+	out, err := runGitRaw(ctx, dir, "ls-tree", "-r", "-z", "--full-tree", commit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	files := []string{}
+	links := map[string]bool{}
+	for _, entry := range splitNulSeparated(out) {
+		// Entries are "<mode> <type> <object>\t<path>"
+		tab := strings.IndexByte(entry, '\t')
+		if tab < 0 {
+			continue
+		}
+
+		file := entry[tab+1:]
+		files = append(files, file)
+		if strings.HasPrefix(entry, symlinkMode+" ") {
+			links[file] = true
+		}
+	}
+
+	return files, links, nil
+}
+
+// gitShowFile returns the content of file, relative to the repo root, at
+// commit.
+func gitShowFile(ctx context.Context, dir, commit, file string) ([]byte, error) {
+	out, err := runGitRaw(ctx, dir, "cat-file", "blob", commit+":"+file)
+	return []byte(out), err
+}
+
+// RewriteCodeownersRulesAt is RewriteCodeownersRules for the state of the repo
+// in root at a past commit, which is read from git without a checkout.
+// opts.Files isn't supported.
+func RewriteCodeownersRulesAt(ctx context.Context, root, commit string, opts Options) ([]Rule, error) {
+	files, links, err := gitListTree(ctx, root, commit)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing files at %s: %w", commit, err)
+	}
+
+	return rewriteCodeownersTree(ctx, files, followTreeSymlinks(links, func(file string) ([]byte, error) {
+		return gitShowFile(ctx, root, commit, file)
+	}), opts)
+}
+
+// gitLastCommitOf returns the last commit of HEAD's history that changed
+// file, relative to the repo root, or "" if there is none.
+func gitLastCommitOf(ctx context.Context, dir, file string) (string, error) {
+	return runGit(ctx, dir, "log", "-1", "--format=%H", "HEAD", "--", file)
+}
+
+// Rename is a file renamed between two commits as detected by git.
+type Rename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// gitRenames returns the files renamed between base and head.
+func gitRenames(ctx context.Context, dir, base, head string) ([]Rename, error) {
+	out, err := runGitRaw(ctx, dir, "diff", "--name-status", "-M", "-z", base, head)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseNameStatus(out), nil
+}
+
+// parseNameStatus extracts the renames from the output of git diff
+// --name-status -z, where renames and copies are followed by two paths and
+// all other changes by one.
+func parseNameStatus(out string) []Rename {
+	fields := strings.Split(out, "\x00")
+
+	var renames []Rename
+	for i := 0; i < len(fields); {
+		status := fields[i]
+		if status == "" {
+			i++
+			continue
+		}
+
+		if (status[0] == 'R' || status[0] == 'C') && i+2 < len(fields) {
+			if status[0] == 'R' {
+				renames = append(renames, Rename{From: fields[i+1], To: fields[i+2]})
+			}
+			i += 3
+			continue
+		}
+
+		i += 2
+	}
+
+	return renames
+}
+
+// GitWorkingTreeChanges returns the paths of the files with uncommitted
+// changes in the repo in dir, staged or not, including untracked files. With
+// stagedOnly only staged changes are returned. For renames both paths are
+// returned since both are part of the change.
+func GitWorkingTreeChanges(ctx context.Context, dir string, stagedOnly bool) ([]string, error) {
+	out, err := runGitRaw(ctx, dir, "status", "--porcelain=v1", "-z", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseStatusPorcelain(out, stagedOnly), nil
+}
+
+// parseStatusPorcelain extracts the changed files from the output of git
+// status --porcelain=v1 -z, where every entry is "XY path" and renames and
+// copies are followed by their original path.
+func parseStatusPorcelain(out string, stagedOnly bool) []string {
+	fields := strings.Split(out, "\x00")
+
+	files := []string{}
+	for i := 0; i < len(fields); i++ {
+		entry := fields[i]
+		if len(entry) < 4 {
+			continue
+		}
+
+		staged, file := entry[0], entry[3:]
+		copied := staged == 'R' || staged == 'C'
+		if !stagedOnly || (staged != ' ' && staged != '?') {
+			files = append(files, file)
+		}
+
+		if copied && i+1 < len(fields) {
+			i++
+			if staged == 'R' {
+				files = append(files, fields[i])
+			}
+		}
+	}
+
+	return files
+}
+
+// gitNoreplyEmailRegexp matches the noreply emails of GitHub accounts, e.g.
+// 123+login@users.noreply.github.com.
+var gitNoreplyEmailRegexp = regexp.MustCompile(`^(?:\d+\+)?([A-Za-z0-9-]+)@users\.noreply\.github\.com$`)
+
+// gitHistoryOwners infers owners of dir, relative to the repo in root, from
+// its git history since the given date, e.g. "1 year ago": the authors of the
+// most commits, at most max of them. GitHub noreply emails become @logins,
+// other emails are kept, bots are skipped.
+func gitHistoryOwners(ctx context.Context, root, dir, since string, max int) ([]string, error) {
+	out, err := runGit(ctx, root, "log", "--since="+since, "--format=%ae", "--", dir)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{}
+	var owners []string
+	for _, email := range strings.Split(out, "\n") {
+		email = strings.TrimSpace(email)
+		if email == "" || strings.Contains(email, "[bot]") {
+			continue
+		}
+
+		owner := strings.ToLower(email)
+		if match := gitNoreplyEmailRegexp.FindStringSubmatch(email); match != nil {
+			owner = "@" + match[1]
+		}
+		if !isValidOwner(owner) {
+			continue
+		}
+
+		if counts[owner] == 0 {
+			owners = append(owners, owner)
+		}
+		counts[owner]++
+	}
+
+	// Most commits first, ties keep the order of the most recent commit
+	sort.SliceStable(owners, func(i, j int) bool { return counts[owners[i]] > counts[owners[j]] })
+	if len(owners) > max {
+		owners = owners[:max]
+	}
+
+	return owners, nil
+}
@@ -0,0 +1,129 @@
+package codeowners
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBlamePorcelain(t *testing.T) {
+	out := `4a5d1c8e3f0b2a7d9c6e1f3a5b7d9e1f3a5c7e9b 3 3 1
+author Jane Doe
+author-mail <jane@example.com>
+author-time 1627819200
+author-tz +0200
+committer Jane Doe
+committer-mail <jane@example.com>
+committer-time 1627819200
+committer-tz +0200
+summary Move payments ownership to the payments team
+filename src/payments/CODEOWNERS
+	@org/payments`
+
+	info, err := parseBlamePorcelain(out)
+	require.NoError(t, err)
+	require.Equal(t, BlameInfo{
+		Commit:  "4a5d1c8e3f0b2a7d9c6e1f3a5b7d9e1f3a5c7e9b",
+		Author:  "Jane Doe",
+		Email:   "jane@example.com",
+		Time:    time.Date(2021, 8, 1, 12, 0, 0, 0, time.UTC),
+		Summary: "Move payments ownership to the payments team",
+	}, info)
+}
+
+func TestParseStatusPorcelain(t *testing.T) {
+	out := " M src/main.go\x00M  src/api.go\x00R  src/new.go\x00src/old.go\x00?? docs/guide.md\x00D  gone.go\x00"
+
+	require.Equal(t, []string{"src/main.go", "src/api.go", "src/new.go", "src/old.go", "docs/guide.md", "gone.go"}, parseStatusPorcelain(out, false))
+	require.Equal(t, []string{"src/api.go", "src/new.go", "src/old.go", "gone.go"}, parseStatusPorcelain(out, true))
+	require.Equal(t, []string{}, parseStatusPorcelain("", false))
+}
+
+func TestRewriteCodeownersRulesAt(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repoPath := t.TempDir()
+	git := func(date string, args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoPath
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_AUTHOR_DATE="+date,
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com", "GIT_COMMITTER_DATE="+date)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	git("", "init", "--quiet")
+	writeFile(t, repoPath, "CODEOWNERS", "\n@org/admins\n")
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/old\n")
+	git("", "add", "--all")
+	git("2024-05-10T12:00:00Z", "commit", "--quiet", "--message", "Initial ownership")
+
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/new\n")
+	git("", "add", "--all")
+	git("2024-06-15T12:00:00Z", "commit", "--quiet", "--message", "Move src")
+
+	ctx := context.Background()
+	commit, err := GitResolveAsOf(ctx, repoPath, "2024-06-01")
+	require.NoError(t, err)
+
+	rules, err := RewriteCodeownersRulesAt(ctx, repoPath, commit, Options{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"* @org/admins", "/src @org/old"}, ruleStrings(rules))
+	require.Equal(t, "CODEOWNERS:2", rules[0].Location())
+
+	commit, err = GitResolveAsOf(ctx, repoPath, "HEAD")
+	require.NoError(t, err)
+	rules, err = RewriteCodeownersRulesAt(ctx, repoPath, commit, Options{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"* @org/admins", "/src @org/new"}, ruleStrings(rules))
+
+	_, err = GitResolveAsOf(ctx, repoPath, "2024-01-01")
+	require.EqualError(t, err, "no commit before 2024-01-01")
+}
+
+func TestRewriteCodeownersRulesAtSymlinks(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repoPath := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoPath
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	git("init", "--quiet")
+	writeFile(t, repoPath, "shared/CODEOWNERS", "@org/shared\n")
+	writeFile(t, repoPath, "svc/main.go", "")
+	require.NoError(t, os.Symlink("../shared/CODEOWNERS", filepath.Join(repoPath, "svc/CODEOWNERS")))
+	git("add", "--all")
+	git("commit", "--quiet", "--message", "Share ownership")
+
+	ctx := context.Background()
+	rules, err := RewriteCodeownersRulesAt(ctx, repoPath, "HEAD", Options{Strict: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{"/shared @org/shared", "/svc @org/shared"}, ruleStrings(rules))
+
+	// Cycles are reported instead of followed
+	require.NoError(t, os.Mkdir(filepath.Join(repoPath, "loop"), 0700))
+	require.NoError(t, os.Symlink("CODEOWNERS", filepath.Join(repoPath, "loop/CODEOWNERS")))
+	git("add", "--all")
+	git("commit", "--quiet", "--message", "Add loop")
+
+	_, err = RewriteCodeownersRulesAt(ctx, repoPath, "HEAD", Options{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "symlink cycle at loop/CODEOWNERS")
+}
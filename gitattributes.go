@@ -0,0 +1,135 @@
+package codeowners
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	gitattributesFileName = ".gitattributes"
+	linguistGenerated     = "linguist-generated"
+)
+
+// generatedAttribute is a line of a .gitattributes file that sets or unsets
+// linguist-generated.
+type generatedAttribute struct {
+	pattern   pattern
+	generated bool
+}
+
+// GeneratedPaths checks whether paths are marked linguist-generated by the
+// .gitattributes files of a repo, which are read on demand. Patterns are
+// matched like CODEOWNERS patterns, so a pattern matching a dir marks the
+// whole subtree. As in git, deeper files and later lines take precedence.
+type GeneratedPaths struct {
+	// read returns the content of a file relative to the root, errors are
+	// treated as missing file since the attributes are an optional feature.
+	read func(file string) ([]byte, error)
+
+	// attributes caches the attributes per dir, "." for the root.
+	attributes map[string][]generatedAttribute
+}
+
+// NewGeneratedPaths creates a GeneratedPaths reading the .gitattributes files
+// from the checkout in root.
+func NewGeneratedPaths(root string) *GeneratedPaths {
+	return newGeneratedPathsFrom(func(file string) ([]byte, error) {
+		return os.ReadFile(longPath(filepath.Join(root, filepath.FromSlash(file))))
+	})
+}
+
+// newGeneratedPathsFrom creates a GeneratedPaths reading the .gitattributes
+// files with read, e.g. from a git tree.
+func newGeneratedPathsFrom(read func(file string) ([]byte, error)) *GeneratedPaths {
+	return &GeneratedPaths{read: read, attributes: map[string][]generatedAttribute{}}
+}
+
+// isGenerated checks whether a file, relative to the root and slash
+// separated, is marked linguist-generated.
+func (g *GeneratedPaths) isGenerated(file string) bool {
+	var dirs []string
+	for dir := path.Dir(file); ; dir = path.Dir(dir) {
+		dirs = append(dirs, dir)
+		if dir == "." {
+			break
+		}
+	}
+
+	generated := false
+	for i := len(dirs) - 1; i >= 0; i-- {
+		rel := file
+		if dirs[i] != "." {
+			rel = strings.TrimPrefix(file, dirs[i]+"/")
+		}
+
+		segments := pathSegments(rel)
+		for _, attribute := range g.load(dirs[i]) {
+			if attribute.pattern.match(segments) {
+				generated = attribute.generated
+			}
+		}
+	}
+
+	return generated
+}
+
+// load returns the attributes of the .gitattributes file in dir.
+func (g *GeneratedPaths) load(dir string) []generatedAttribute {
+	attributes, ok := g.attributes[dir]
+	if ok {
+		return attributes
+	}
+
+	content, err := g.read(path.Join(dir, gitattributesFileName))
+	if err == nil {
+		attributes = parseGeneratedAttributes(string(content))
+	}
+	g.attributes[dir] = attributes
+
+	return attributes
+}
+
+// parseGeneratedAttributes returns the lines of a .gitattributes file that set
+// linguist-generated, "linguist-generated" and "linguist-generated=true", or
+// unset it, "-linguist-generated", "!linguist-generated" and
+// "linguist-generated=false".
+func parseGeneratedAttributes(content string) []generatedAttribute {
+	var attributes []generatedAttribute
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "[attr]") {
+			continue
+		}
+
+		for _, attribute := range fields[1:] {
+			var generated bool
+			switch attribute {
+			case linguistGenerated, linguistGenerated + "=true":
+				generated = true
+			case "-" + linguistGenerated, "!" + linguistGenerated, linguistGenerated + "=false":
+				generated = false
+			default:
+				continue
+			}
+
+			attributes = append(attributes, generatedAttribute{pattern: compilePattern(fields[0]), generated: generated})
+		}
+	}
+
+	return attributes
+}
+
+// ExcludeGenerated removes the files marked linguist-generated, e.g. before
+// computing the coverage.
+func ExcludeGenerated(files []string, generated *GeneratedPaths) []string {
+	kept := make([]string, 0, len(files))
+	for _, file := range files {
+		if !generated.isGenerated(file) {
+			kept = append(kept, file)
+		}
+	}
+
+	return kept
+}
@@ -0,0 +1,44 @@
+package codeowners
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratedPaths(t *testing.T) {
+	repoPath := t.TempDir()
+	writeFile(t, repoPath, ".gitattributes", "# Generated code\n/gen/** linguist-generated=true\n*.pb.go linguist-generated\n/gen/handwritten/** -linguist-generated\n*.go text eol=lf\n")
+	writeFile(t, repoPath, "api/.gitattributes", "client/** linguist-generated\n")
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admin\n")
+	writeFile(t, repoPath, "gen/CODEOWNERS", "@org/stray\n")
+	writeFile(t, repoPath, "gen/handwritten/CODEOWNERS", "@org/dev\n")
+	writeFile(t, repoPath, "api/client/CODEOWNERS", "@org/stray\n")
+	writeFile(t, repoPath, "api/server/CODEOWNERS", "@org/api\n")
+
+	generated := NewGeneratedPaths(repoPath)
+	for file, expected := range map[string]bool{
+		"main.go":                 false,
+		"gen/api.go":              true,
+		"gen/handwritten/util.go": false,
+		"api/api.pb.go":           true,
+		"api/client/client.go":    true,
+		"api/server/server.go":    false,
+		"client/client.go":        false,
+		"gen/handwritten/x.pb.go": false, // The later line wins
+	} {
+		require.Equal(t, expected, generated.isGenerated(file), file)
+	}
+
+	files := []string{"main.go", "gen/api.go", "api/api.pb.go", "api/server/server.go"}
+	require.Equal(t, []string{"main.go", "api/server/server.go"}, ExcludeGenerated(files, generated))
+
+	rules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{SkipGenerated: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{"* @org/admin", "/api/server @org/api", "/gen/handwritten @org/dev"}, ruleStrings(rules))
+
+	rules, err = RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.NoError(t, err)
+	require.Len(t, rules, 5)
+}
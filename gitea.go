@@ -0,0 +1,118 @@
+package codeowners
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Gitea matches the patterns of its CODEOWNERS file as Go regexps against the
+// whole path, and requests reviews from the owners of every matching rule
+// instead of only the last one.
+
+// giteaRules converts the patterns of the rules to Gitea regexps. Trailing
+// comments are dropped, Gitea would parse them as owners.
+func giteaRules(rules []Rule) []Rule {
+	converted := make([]Rule, len(rules))
+	for i, rule := range rules {
+		rule.Pattern, _ = giteaPattern(rule.Pattern)
+		rule.Comment = ""
+		converted[i] = rule
+	}
+
+	return converted
+}
+
+// ValidateGiteaRules checks whether the rules can be expressed in Gitea's
+// CODEOWNERS syntax. Gitea only supports users and teams as owners.
+func ValidateGiteaRules(rules []Rule) error {
+	for _, rule := range rules {
+		pattern, err := giteaPattern(rule.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s: %w", rule.Location(), err)
+		}
+
+		_, err = regexp.Compile(fmt.Sprintf("^%s$", pattern))
+		if err != nil {
+			return fmt.Errorf("%s: pattern %s can't be converted to a Gitea regexp: %w", rule.Location(), rule.Pattern, err)
+		}
+
+		for _, owner := range rule.Owners {
+			if !userOwnerRegexp.MatchString(owner) && !isTeamOwner(owner) {
+				return fmt.Errorf("%s: owner %s isn't supported by Gitea, only users and teams are", rule.Location(), owner)
+			}
+		}
+	}
+
+	return nil
+}
+
+// giteaPattern converts a CODEOWNERS pattern to an equivalent regexp, without
+// the ^ and $ anchors which Gitea adds itself.
+func giteaPattern(p string) (string, error) {
+	compiled := compilePattern(p)
+	if len(compiled.segments) == 0 {
+		return "", fmt.Errorf("pattern %s never matches", p)
+	}
+
+	var b strings.Builder
+	if !compiled.anchored {
+		b.WriteString("(?:.*/)?")
+	}
+
+	last := len(compiled.segments) - 1
+	for i, segment := range compiled.segments {
+		switch {
+		case segment == "**" && i == last:
+			// A trailing "**" matches everything inside a dir, but not the
+			// dir itself
+			b.WriteString("[^/]+")
+		case segment == "**":
+			// Matches zero or more dirs including their trailing "/"
+			b.WriteString("(?:[^/]+/)*")
+			continue
+		default:
+			b.WriteString(giteaSegment(segment))
+		}
+
+		if i < last {
+			b.WriteString("/")
+		}
+	}
+
+	matchesNested := compiled.segments[last] != "*"
+	switch {
+	case compiled.dirOnly && !matchesNested:
+		return "", fmt.Errorf("pattern %s never matches", p)
+	case compiled.dirOnly:
+		b.WriteString("/.+")
+	case matchesNested:
+		b.WriteString("(?:/.*)?")
+	}
+
+	return b.String(), nil
+}
+
+// giteaSegment converts the wildcards of a single pattern segment to a regexp.
+func giteaSegment(segment string) string {
+	var b strings.Builder
+
+	escaped := false
+	for _, c := range segment {
+		switch {
+		case escaped:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,39 @@
+package codeowners
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGiteaPattern(t *testing.T) {
+	patterns := []string{"*", "/src", "/src/", "/docs/*", "*.go", "/src/*.go", "/a/**", "/a/**/b", "**/test", "build/", "/file\\*.txt", "/dir/a?c"}
+	paths := []string{"README.md", "main.go", "src", "src/main.go", "src/lib/lib.go", "docs/a.md", "docs/sub/b.md",
+		"a", "a/b", "a/x/y/b", "a/x/b/c", "test", "lib/test/x.go", "build/out", "x/build/out", "file*.txt", "fileX.txt", "dir/abc", "dir/ac"}
+
+	for _, p := range patterns {
+		converted, err := giteaPattern(p)
+		require.NoError(t, err, p)
+		re := regexp.MustCompile("^" + converted + "$")
+		compiled := compilePattern(p)
+
+		for _, path := range paths {
+			require.Equal(t, compiled.match(pathSegments(path)), re.MatchString(path), "pattern %s (%s), path %s", p, converted, path)
+		}
+	}
+
+	_, err := giteaPattern("/docs/*/")
+	require.EqualError(t, err, "pattern /docs/*/ never matches")
+}
+
+func TestValidateGiteaRules(t *testing.T) {
+	rules := []Rule{{Pattern: "/src", Owners: []string{"@someone", "@org/team"}, Source: "src/CODEOWNERS", Line: 1}}
+	require.NoError(t, ValidateGiteaRules(rules))
+
+	rules[0].Owners = append(rules[0].Owners, "dev@example.com")
+	require.EqualError(t, ValidateGiteaRules(rules), "src/CODEOWNERS:1: owner dev@example.com isn't supported by Gitea, only users and teams are")
+
+	output := GenerateCodeownersFile([]Rule{{Pattern: "/src/*.go", Owners: []string{"@someone"}, Comment: "Go"}}, GenerateOptions{NoHeader: true, Target: TargetGitea})
+	require.Equal(t, "src/[^/]*\\.go(?:/.*)? @someone\n", output)
+}
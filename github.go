@@ -0,0 +1,329 @@
+package codeowners
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	defaultGitHubAPIURL = "https://api.github.com"
+
+	// gitHubPageSize is the maximum page size of the GitHub REST API.
+	gitHubPageSize = 100
+)
+
+// GitHubClient is a minimal client for the GitHub REST API.
+type GitHubClient struct {
+	// BaseURL is the API root, e.g. https://api.github.com or
+	// https://github.example.com/api/v3 for GitHub Enterprise.
+	BaseURL string
+
+	// Token is used for authentication if not empty.
+	Token string
+
+	HTTPClient *http.Client
+}
+
+// NewGitHubClient creates a client for the API at $GITHUB_API_URL (set in
+// GitHub Actions), falling back to api.github.com.
+func NewGitHubClient(token string) *GitHubClient {
+	baseURL := os.Getenv("GITHUB_API_URL")
+	if baseURL == "" {
+		baseURL = defaultGitHubAPIURL
+	}
+
+	return &GitHubClient{BaseURL: baseURL, Token: token, HTTPClient: http.DefaultClient}
+}
+
+// GitHubToken returns the token given as flag, falling back to $GITHUB_TOKEN.
+func GitHubToken(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+
+	return os.Getenv("GITHUB_TOKEN")
+}
+
+// GitHubError is returned for unsuccessful API responses.
+type GitHubError struct {
+	StatusCode int
+	Method     string
+	Path       string
+	Message    string
+}
+
+func (e *GitHubError) Error() string {
+	return fmt.Sprintf("GitHub API %s %s returned %d: %s", e.Method, e.Path, e.StatusCode, e.Message)
+}
+
+// do sends a request with an optional JSON body and decodes the JSON response
+// into out, if not nil.
+func (c *GitHubClient) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("can't encode request body: %w", err)
+		}
+		reqBody = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.BaseURL, "/")+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("can't create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("GitHub API %s %s failed: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr) // Best effort, the status is reported anyway
+
+		return &GitHubError{StatusCode: resp.StatusCode, Method: method, Path: path, Message: apiErr.Message}
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(out)
+	if err != nil {
+		return fmt.Errorf("can't decode response of GitHub API %s %s: %w", method, path, err)
+	}
+
+	return nil
+}
+
+// PullRequest is the subset of a GitHub pull request used by this tool.
+type PullRequest struct {
+	Number int `json:"number"`
+	User   struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+}
+
+// PullRequest fetches a pull request of repo ("owner/name").
+func (c *GitHubClient) PullRequest(ctx context.Context, repo string, number int) (PullRequest, error) {
+	var pr PullRequest
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/pulls/%d", repo, number), nil, &pr)
+	return pr, err
+}
+
+// PullRequestFiles lists the paths of the files changed by a pull request.
+func (c *GitHubClient) PullRequestFiles(ctx context.Context, repo string, number int) ([]string, error) {
+	var files []string
+	for page := 1; ; page++ {
+		var pageFiles []struct {
+			Filename string `json:"filename"`
+		}
+
+		path := fmt.Sprintf("/repos/%s/pulls/%d/files?per_page=%d&page=%d", repo, number, gitHubPageSize, page)
+		err := c.do(ctx, http.MethodGet, path, nil, &pageFiles)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range pageFiles {
+			files = append(files, file.Filename)
+		}
+
+		if len(pageFiles) < gitHubPageSize {
+			return files, nil
+		}
+	}
+}
+
+// RequestReviewers requests reviews of a pull request from users (logins
+// without "@") and teams (slugs without org).
+func (c *GitHubClient) RequestReviewers(ctx context.Context, repo string, number int, users, teams []string) error {
+	body := struct {
+		Reviewers     []string `json:"reviewers"`
+		TeamReviewers []string `json:"team_reviewers"`
+	}{Reviewers: users, TeamReviewers: teams}
+
+	return c.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/pulls/%d/requested_reviewers", repo, number), body, nil)
+}
+
+// CreateIssueComment adds a comment to an issue or pull request.
+func (c *GitHubClient) CreateIssueComment(ctx context.Context, repo string, number int, body string) error {
+	comment := struct {
+		Body string `json:"body"`
+	}{Body: body}
+
+	return c.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/issues/%d/comments", repo, number), comment, nil)
+}
+
+// TreeEntry is an entry of a git tree.
+type TreeEntry struct {
+	Path string `json:"path"`
+	Mode string `json:"mode"`
+	Type string `json:"type"`
+	SHA  string `json:"sha"`
+}
+
+// Tree lists all entries of the tree of ref (a branch, tag or commit)
+// recursively.
+func (c *GitHubClient) Tree(ctx context.Context, repo, ref string) ([]TreeEntry, error) {
+	var tree struct {
+		Tree      []TreeEntry `json:"tree"`
+		Truncated bool        `json:"truncated"`
+	}
+
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/git/trees/%s?recursive=1", repo, ref), nil, &tree)
+	if err != nil {
+		return nil, err
+	}
+
+	if tree.Truncated {
+		return nil, fmt.Errorf("tree of %s at %s is too large for the GitHub API", repo, ref)
+	}
+
+	return tree.Tree, nil
+}
+
+// Blob fetches the content of a blob.
+func (c *GitHubClient) Blob(ctx context.Context, repo, sha string) ([]byte, error) {
+	var blob struct {
+		Content  string `json:"content"`
+		Encoding string `json:"encoding"`
+	}
+
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/git/blobs/%s", repo, sha), nil, &blob)
+	if err != nil {
+		return nil, err
+	}
+
+	if blob.Encoding != "base64" {
+		return []byte(blob.Content), nil
+	}
+
+	content, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(blob.Content, "\n", ""))
+	if err != nil {
+		return nil, fmt.Errorf("can't decode blob %s: %w", sha, err)
+	}
+
+	return content, nil
+}
+
+// UserByEmail searches the account with the given email address and returns
+// its login, or "" if no account or more than one uses it. Only emails that
+// are public or belong to the authenticated user can be found.
+func (c *GitHubClient) UserByEmail(ctx context.Context, email string) (string, error) {
+	var result struct {
+		TotalCount int `json:"total_count"`
+		Items      []struct {
+			Login string `json:"login"`
+		} `json:"items"`
+	}
+
+	query := url.QueryEscape(email + " in:email")
+	err := c.do(ctx, http.MethodGet, "/search/users?q="+query, nil, &result)
+	if err != nil {
+		return "", err
+	}
+
+	if result.TotalCount != 1 || len(result.Items) != 1 {
+		return "", nil
+	}
+
+	return result.Items[0].Login, nil
+}
+
+// UserExists checks whether a GitHub account with the login exists.
+func (c *GitHubClient) UserExists(ctx context.Context, login string) (bool, error) {
+	err := c.do(ctx, http.MethodGet, "/users/"+url.PathEscape(login), nil, nil)
+	var apiErr *GitHubError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// OrgMembers lists the logins of the members of an org.
+func (c *GitHubClient) OrgMembers(ctx context.Context, org string) ([]string, error) {
+	return c.listField(ctx, fmt.Sprintf("/orgs/%s/members", org), "login")
+}
+
+// OrgTeams lists the slugs of the teams of an org visible to the token.
+func (c *GitHubClient) OrgTeams(ctx context.Context, org string) ([]string, error) {
+	return c.listField(ctx, fmt.Sprintf("/orgs/%s/teams", org), "slug")
+}
+
+// listField collects a string field of the objects on all pages of a list
+// endpoint.
+func (c *GitHubClient) listField(ctx context.Context, path, field string) ([]string, error) {
+	var values []string
+	for page := 1; ; page++ {
+		var objects []map[string]interface{}
+		err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s?per_page=%d&page=%d", path, gitHubPageSize, page), nil, &objects)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, object := range objects {
+			if value, ok := object[field].(string); ok {
+				values = append(values, value)
+			}
+		}
+
+		if len(objects) < gitHubPageSize {
+			return values, nil
+		}
+	}
+}
+
+// CodeownersError is a problem GitHub found in the CODEOWNERS file of a repo.
+// Line and Column are 1-based, Source is the affected line.
+type CodeownersError struct {
+	Line       int    `json:"line"`
+	Column     int    `json:"column"`
+	Kind       string `json:"kind"`
+	Source     string `json:"source"`
+	Suggestion string `json:"suggestion"`
+	Path       string `json:"path"`
+}
+
+// CodeownersErrors fetches the problems GitHub found in the CODEOWNERS file
+// of repo at ref, the default branch if ref is empty.
+func (c *GitHubClient) CodeownersErrors(ctx context.Context, repo, ref string) ([]CodeownersError, error) {
+	path := fmt.Sprintf("/repos/%s/codeowners/errors", repo)
+	if ref != "" {
+		path += "?ref=" + url.QueryEscape(ref)
+	}
+
+	var result struct {
+		Errors []CodeownersError `json:"errors"`
+	}
+	err := c.do(ctx, http.MethodGet, path, nil, &result)
+	return result.Errors, err
+}
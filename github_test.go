@@ -0,0 +1,58 @@
+package codeowners
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitHubClient(t *testing.T) {
+	var requested map[string][]string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/pulls/7/files", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		// Serve one full page and one partial page
+		var files []map[string]string
+		count := gitHubPageSize
+		if r.URL.Query().Get("page") == "2" {
+			count = 1
+		}
+		for i := 0; i < count; i++ {
+			files = append(files, map[string]string{"filename": fmt.Sprintf("file%s-%d", r.URL.Query().Get("page"), i)})
+		}
+		_ = json.NewEncoder(w).Encode(files)
+	})
+	mux.HandleFunc("/repos/org/repo/pulls/7/requested_reviewers", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&requested))
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("/repos/org/repo/pulls/8/files", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := &GitHubClient{BaseURL: server.URL, Token: "secret", HTTPClient: server.Client()}
+
+	files, err := client.PullRequestFiles(context.Background(), "org/repo", 7)
+	require.NoError(t, err)
+	require.Len(t, files, gitHubPageSize+1)
+	require.Equal(t, "file2-0", files[gitHubPageSize])
+
+	err = client.RequestReviewers(context.Background(), "org/repo", 7, []string{"user"}, []string{"team"})
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{"reviewers": {"user"}, "team_reviewers": {"team"}}, requested)
+
+	_, err = client.PullRequestFiles(context.Background(), "org/repo", 8)
+	require.EqualError(t, err, "GitHub API GET /repos/org/repo/pulls/8/files?per_page=100&page=1 returned 404: Not Found")
+}
@@ -0,0 +1,48 @@
+package codeowners
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// annotatedSourceRegexp matches the trailing comment of --annotate-source,
+// e.g. "# src/CODEOWNERS:3".
+var annotatedSourceRegexp = regexp.MustCompile(`#\s*(\S*` + codeownersFileName + `):(\d+)\s*$`)
+
+// CodeownersErrorFindings converts the problems GitHub found in the generated
+// file to findings. If the affected line was generated with --annotate-source,
+// the finding points at the rule in its nested CO file, otherwise at the
+// generated file.
+func CodeownersErrorFindings(errs []CodeownersError) []Finding {
+	findings := make([]Finding, 0, len(errs))
+	for _, e := range errs {
+		message := fmt.Sprintf("GitHub reports %s in %s:%d", strings.ToLower(e.Kind), e.Path, e.Line)
+		if source := strings.TrimSpace(e.Source); source != "" {
+			message += fmt.Sprintf(" (%s)", source)
+		}
+		if e.Suggestion != "" {
+			message += ", " + e.Suggestion
+		}
+
+		finding := Finding{
+			Check:    "github-codeowners-error",
+			Severity: SeverityError,
+			Message:  message,
+			File:     e.Path,
+			Line:     e.Line,
+			Column:   e.Column,
+		}
+
+		if match := annotatedSourceRegexp.FindStringSubmatch(e.Source); match != nil {
+			finding.File = match[1]
+			finding.Line, _ = strconv.Atoi(match[2])
+			finding.Column = 0
+		}
+
+		findings = append(findings, finding)
+	}
+
+	return findings
+}
@@ -0,0 +1,47 @@
+package codeowners
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodeownersErrors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/codeowners/errors", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "candidate", r.URL.Query().Get("ref"))
+		_, _ = w.Write([]byte(`{"errors": [
+			{"line": 5, "column": 9, "kind": "Unknown owner", "source": "/src @org/paymnets # src/CODEOWNERS:1\n", "suggestion": "make sure @org/paymnets exists and has write access to the repository", "message": "...", "path": ".github/CODEOWNERS"},
+			{"line": 6, "column": 1, "kind": "Invalid pattern", "source": "/docs/[a-z] @org/docs", "suggestion": null, "path": ".github/CODEOWNERS"}
+		]}`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := &GitHubClient{BaseURL: server.URL, HTTPClient: server.Client()}
+	errs, err := client.CodeownersErrors(context.Background(), "org/repo", "candidate")
+	require.NoError(t, err)
+	require.Len(t, errs, 2)
+
+	require.Equal(t, []Finding{
+		{
+			Check:    "github-codeowners-error",
+			Severity: SeverityError,
+			Message:  "GitHub reports unknown owner in .github/CODEOWNERS:5 (/src @org/paymnets # src/CODEOWNERS:1), make sure @org/paymnets exists and has write access to the repository",
+			File:     "src/CODEOWNERS",
+			Line:     1,
+		},
+		{
+			Check:    "github-codeowners-error",
+			Severity: SeverityError,
+			Message:  "GitHub reports invalid pattern in .github/CODEOWNERS:6 (/docs/[a-z] @org/docs)",
+			File:     ".github/CODEOWNERS",
+			Line:     6,
+			Column:   1,
+		},
+	}, CodeownersErrorFindings(errs))
+}
@@ -0,0 +1,155 @@
+package codeowners
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Section is a GitLab CODEOWNERS section. Sections are evaluated independently
+// by GitLab, a change needs approval from the owners in every section with a
+// matching rule.
+type Section struct {
+	Name string
+
+	// Approvals is the number of required approvals, 0 means GitLab's default
+	// of one.
+	Approvals int
+
+	// Optional sections request reviews without blocking the merge.
+	Optional bool
+}
+
+// String renders the section header, e.g. "[Documentation][2]" or
+// "^[Documentation]" for optional sections.
+func (s Section) String() string {
+	header := fmt.Sprintf("[%s]", s.Name)
+	if s.Approvals > 0 {
+		header = fmt.Sprintf("%s[%d]", header, s.Approvals)
+	}
+	if s.Optional {
+		header = "^" + header
+	}
+
+	return header
+}
+
+// ApplyGitLabSections assigns the sections of the rules for the GitLab target
+// as configured: the rules without section get a section per top-level dir if
+// enabled, then the configured approvals and optional markers override the
+// ones of the pragmas. Sections are shared by the rules of a file, so that
+// changed sections are copied.
+func ApplyGitLabSections(rules []Rule, cfg GitLabConfig) []Rule {
+	if !cfg.SectionPerTopLevelDir && len(cfg.Sections) == 0 {
+		return rules
+	}
+
+	topLevelSections := map[string]*Section{}
+	configured := map[*Section]*Section{}
+	applied := make([]Rule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.Section == nil && cfg.SectionPerTopLevelDir {
+			if dir := topLevelDir(rule.Source); dir != "" {
+				if _, ok := topLevelSections[dir]; !ok {
+					topLevelSections[dir] = &Section{Name: dir}
+				}
+				rule.Section = topLevelSections[dir]
+			}
+		}
+
+		if rule.Section != nil {
+			section, ok := configured[rule.Section]
+			if !ok {
+				section = cfg.configureSection(rule.Section)
+				configured[rule.Section] = section
+			}
+			rule.Section = section
+		}
+
+		applied = append(applied, rule)
+	}
+
+	return applied
+}
+
+// configureSection returns the section with the overrides of its config, or
+// the section itself if there are none.
+func (c GitLabConfig) configureSection(section *Section) *Section {
+	override, ok := c.Sections[section.Name]
+	if !ok {
+		return section
+	}
+
+	// Optional sections can't require approvals, the config wins over the
+	// pragmas
+	configured := *section
+	if override.Approvals != nil {
+		configured.Approvals = *override.Approvals
+		configured.Optional = false
+	}
+	if override.Optional != nil {
+		configured.Optional = *override.Optional
+		if configured.Optional {
+			configured.Approvals = 0
+		}
+	}
+
+	return &configured
+}
+
+// topLevelDir returns the first dir of the path of a CO file like the default
+// section names, e.g. "/src", and "" for files in the root.
+func topLevelDir(source string) string {
+	dir := path.Dir(source)
+	if dir == "." || dir == "" {
+		return ""
+	}
+
+	return "/" + strings.SplitN(dir, "/", 2)[0]
+}
+
+// groupBySection orders the rules for GitLab: Rules without section come
+// first, followed by the rules of every section in the order the sections
+// first appear.
+func groupBySection(rules []Rule) []Rule {
+	var grouped []Rule
+	var sections []*Section
+	bySection := map[*Section][]Rule{}
+
+	for _, rule := range rules {
+		if rule.Section == nil {
+			grouped = append(grouped, rule)
+			continue
+		}
+
+		if _, ok := bySection[rule.Section]; !ok {
+			sections = append(sections, rule.Section)
+		}
+		bySection[rule.Section] = append(bySection[rule.Section], rule)
+	}
+
+	for _, section := range sections {
+		grouped = append(grouped, bySection[section]...)
+	}
+
+	return grouped
+}
+
+// sectionLine returns the line to emit before rule if it starts a new
+// section, rendered for the given target. Other targets than GitLab don't know
+// sections, they are degraded to comments there.
+func sectionLine(prev *Section, rule Rule, target string) (string, bool) {
+	if rule.Section == nil || rule.Section == prev {
+		return "", false
+	}
+
+	if target == TargetGitLab {
+		return rule.Section.String(), true
+	}
+
+	// The optional marker has no meaning outside of GitLab
+	section := *rule.Section
+	section.Optional = false
+
+	return fmt.Sprintf("%s GitLab section %s", codeownersCommentPrefix, section), true
+}
@@ -0,0 +1,142 @@
+package codeowners
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitLabSections(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admins\n")
+	writeFile(t, repoPath, "docs/CODEOWNERS", "# codeowners: section=Documentation; approvals=2\n@org/docs\n*.png @org/design\n")
+	writeFile(t, repoPath, "src/CODEOWNERS", "# codeowners: approvals=1\n@org/dev\n")
+	writeFile(t, repoPath, "src/lib/CODEOWNERS", "@org/lib\n")
+	writeFile(t, repoPath, "web/CODEOWNERS", "# codeowners: optional=true\n*.md @org/docs\n")
+
+	rules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.NoError(t, err)
+
+	expected := `[Documentation][2]
+/docs @org/docs
+/docs/*.png @org/design
+[/src][1]
+/src @org/dev
+^[/web]
+/web/*.md @org/docs
+`
+	// Rules without section come first
+	output := GenerateCodeownersFile(rules, GenerateOptions{NoHeader: true, Target: TargetGitLab})
+	require.Equal(t, "* @org/admins\n/src/lib @org/lib\n"+expected, output)
+
+	expected = `* @org/admins
+# GitLab section [Documentation][2]
+/docs @org/docs
+/docs/*.png @org/design
+# GitLab section [/src][1]
+/src @org/dev
+# GitLab section [/web]
+/web/*.md @org/docs
+/src/lib @org/lib
+`
+	output = GenerateCodeownersFile(rules, GenerateOptions{NoHeader: true})
+	require.Equal(t, expected, output)
+}
+
+func TestApplyGitLabSections(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admins\n")
+	writeFile(t, repoPath, "docs/CODEOWNERS", "# codeowners: section=Documentation\n@org/docs\n")
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/dev\n")
+	writeFile(t, repoPath, "src/lib/CODEOWNERS", "@org/lib\n")
+	writeFile(t, repoPath, "web/CODEOWNERS", "# codeowners: approvals=2\n@org/web\n")
+	writeFile(t, repoPath, ConfigFileName, "gitlab:\n  section-per-top-level-dir: true\n  sections:\n    Documentation:\n      approvals: 2\n    /web:\n      optional: true\n")
+
+	cfg, err := LoadConfig(repoPath, "")
+	require.NoError(t, err)
+
+	rules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.NoError(t, err)
+
+	applied := ApplyGitLabSections(rules, cfg.GitLab)
+	expected := `* @org/admins
+[Documentation][2]
+/docs @org/docs
+[/src]
+/src @org/dev
+/src/lib @org/lib
+^[/web]
+/web @org/web
+`
+	require.Equal(t, expected, GenerateCodeownersFile(applied, GenerateOptions{NoHeader: true, Target: TargetGitLab}))
+
+	// The sections of the rewritten rules are left alone
+	for _, rule := range rules {
+		switch rule.Source {
+		case "src/CODEOWNERS":
+			require.Nil(t, rule.Section)
+		case "web/CODEOWNERS":
+			require.Equal(t, &Section{Name: "/web", Approvals: 2}, rule.Section)
+		}
+	}
+
+	writeFile(t, repoPath, ConfigFileName, "gitlab:\n  sections:\n    Docs:\n      approvals: 2\n      optional: true\n")
+	_, err = LoadConfig(repoPath, "")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "sets approvals for optional section Docs")
+}
+
+func TestParseSection(t *testing.T) {
+	section, _, err := parseFilePragmas([]string{"# Just a comment: really", "@org/team"}, "CODEOWNERS", "/")
+	require.NoError(t, err)
+	require.Nil(t, section)
+
+	section, _, err = parseFilePragmas([]string{"# Section: the docs team owns this", "# Optional: ping #infra on slack"}, "CODEOWNERS", "/dir")
+	require.NoError(t, err)
+	require.Nil(t, section)
+
+	section, _, err = parseFilePragmas([]string{"#codeowners: Approvals=3"}, "CODEOWNERS", "/dir")
+	require.NoError(t, err)
+	require.Equal(t, &Section{Name: "/dir", Approvals: 3}, section)
+
+	_, _, err = parseFilePragmas([]string{"# codeowners: approvals=none"}, "dir/CODEOWNERS", "/dir")
+	require.EqualError(t, err, `dir/CODEOWNERS:1:15: invalid number of approvals "none"`)
+
+	_, _, err = parseFilePragmas([]string{"# codeowners: optional=yes"}, "dir/CODEOWNERS", "/dir")
+	require.EqualError(t, err, `dir/CODEOWNERS:1:15: invalid optional value "yes"`)
+
+	_, _, err = parseFilePragmas([]string{"# codeowners: optional=true", "# codeowners: approvals=2"}, "dir/CODEOWNERS", "/dir")
+	require.EqualError(t, err, "dir/CODEOWNERS:2: optional section /dir can't require approvals")
+}
+
+func TestGitLabLocations(t *testing.T) {
+	name, ok := TargetFileName(TargetGitLab, "")
+	require.True(t, ok)
+	require.Equal(t, ".gitlab/CODEOWNERS", name)
+	name, _ = TargetFileName(TargetGitLab, "docs/CODEOWNERS")
+	require.Equal(t, "docs/CODEOWNERS", name)
+	require.Error(t, ValidGitLabFileName("gitlab/CODEOWNERS"))
+
+	repoPath := t.TempDir()
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/dev\n")
+	writeFile(t, repoPath, ".gitlab/CODEOWNERS", "/old @org/old\n")
+	require.NoError(t, CheckGitLabLocation(repoPath, ".gitlab/CODEOWNERS"))
+
+	// The generated file is never read back as input
+	writeFile(t, repoPath, "docs/CODEOWNERS", "/src @org/generated\n")
+	rules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{Output: "docs/CODEOWNERS"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"/src @org/dev"}, ruleStrings(rules))
+
+	err = CheckGitLabLocation(repoPath, "docs/CODEOWNERS")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "but .gitlab/CODEOWNERS exists besides the generated docs/CODEOWNERS")
+
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admin\n")
+	err = CheckGitLabLocation(repoPath, ".gitlab/CODEOWNERS")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "but CODEOWNERS exists besides the generated .gitlab/CODEOWNERS")
+}
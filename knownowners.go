@@ -0,0 +1,148 @@
+package codeowners
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ExportKnownOwners lists the members (@login) and teams (@org/slug) of a
+// GitHub org, sorted, for a known owners file, see writeKnownOwnersFile.
+func ExportKnownOwners(ctx context.Context, client *GitHubClient, org string) ([]string, error) {
+	members, err := client.OrgMembers(ctx, org)
+	if err != nil {
+		return nil, fmt.Errorf("can't list the members of %s: %w", org, err)
+	}
+
+	teams, err := client.OrgTeams(ctx, org)
+	if err != nil {
+		return nil, fmt.Errorf("can't list the teams of %s: %w", org, err)
+	}
+
+	owners := make([]string, 0, len(members)+len(teams))
+	for _, member := range members {
+		owners = append(owners, "@"+member)
+	}
+	for _, team := range teams {
+		owners = append(owners, "@"+org+"/"+team)
+	}
+	sort.Strings(owners)
+
+	return owners, nil
+}
+
+// FormatKnownOwnersFile renders a known owners file: a comment naming the org
+// and the export time, followed by one owner per line.
+func FormatKnownOwnersFile(org string, owners []string, exportedAt time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Known owners of the GitHub org %s, exported at %s\n", org, exportedAt.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "# Refresh with: codeowners known-owners --org %s --output <this file>\n", org)
+	for _, owner := range owners {
+		b.WriteString(owner + "\n")
+	}
+
+	return b.String()
+}
+
+// readKnownOwnersFile reads the owners of a known owners file, one per line.
+// Empty lines and lines starting with "#" are skipped.
+func readKnownOwnersFile(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't read known owners file: %w", err)
+	}
+
+	var owners []string
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			owners = append(owners, line)
+		}
+	}
+
+	return owners, nil
+}
+
+// CheckGitHubOwners validates the users and teams owning the rules against
+// the GitHub API. Teams must exist in their org and users must be members of
+// one of the orgs, which are the given orgs and the orgs of the teams. Users
+// that aren't members are looked up to tell typos from people who left. Every
+// owner is reported once, at its first rule, emails aren't checked.
+func CheckGitHubOwners(ctx context.Context, client *GitHubClient, rules []Rule, orgs []string) ([]Finding, error) {
+	for _, rule := range rules {
+		for _, owner := range rule.Owners {
+			if strings.Contains(owner, "/") && !ContainsString(orgs, teamOrg(owner)) {
+				orgs = append(orgs, teamOrg(owner))
+			}
+		}
+	}
+
+	var known []string
+	knownLower := map[string]bool{}
+	for _, org := range orgs {
+		owners, err := ExportKnownOwners(ctx, client, org)
+		if err != nil {
+			return nil, err
+		}
+
+		known = append(known, owners...)
+		for _, owner := range owners {
+			knownLower[strings.ToLower(owner)] = true
+		}
+	}
+
+	var findings []Finding
+	checked := map[string]bool{}
+	for _, rule := range rules {
+		for _, owner := range rule.Owners {
+			lower := strings.ToLower(owner)
+			if isEmailOwner(owner) || knownLower[lower] || checked[lower] {
+				continue
+			}
+			checked[lower] = true
+
+			var message string
+			switch {
+			case strings.Contains(owner, "/"):
+				message = fmt.Sprintf("%s isn't a team of %s", owner, teamOrg(owner))
+			default:
+				exists, err := client.UserExists(ctx, strings.TrimPrefix(owner, "@"))
+				if err != nil {
+					return nil, fmt.Errorf("can't look up %s: %w", owner, err)
+				}
+
+				if exists && len(orgs) == 0 {
+					continue
+				}
+
+				message = fmt.Sprintf("%s isn't a GitHub user", owner)
+				if exists {
+					message = fmt.Sprintf("%s isn't a member of %s", owner, strings.Join(orgs, " or "))
+				}
+			}
+
+			if suggestions := suggestOwners(owner, known); len(suggestions) > 0 {
+				message += fmt.Sprintf(", did you mean %s?", strings.Join(suggestions, " or "))
+			}
+
+			findings = append(findings, Finding{
+				Check:    "unknown-owner",
+				Severity: SeverityError,
+				Message:  message,
+				File:     rule.Source,
+				Line:     rule.Line,
+			})
+		}
+	}
+
+	return findings, nil
+}
+
+// teamOrg returns the org of a team "@org/slug".
+func teamOrg(team string) string {
+	org, _, _ := SplitTeam(team)
+	return org
+}
@@ -0,0 +1,82 @@
+package codeowners
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKnownOwners(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/org/members", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]map[string]interface{}{{"login": "bob", "id": 2}, {"login": "alice", "id": 1}})
+	})
+	mux.HandleFunc("/orgs/org/teams", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]map[string]interface{}{{"slug": "payments", "name": "Payments"}})
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := &GitHubClient{BaseURL: server.URL, HTTPClient: server.Client()}
+	owners, err := ExportKnownOwners(context.Background(), client, "org")
+	require.NoError(t, err)
+	require.Equal(t, []string{"@alice", "@bob", "@org/payments"}, owners)
+
+	content := FormatKnownOwnersFile("org", owners, time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC))
+	require.Equal(t, "# Known owners of the GitHub org org, exported at 2024-06-01T12:00:00Z\n# Refresh with: codeowners known-owners --org org --output <this file>\n@alice\n@bob\n@org/payments\n", content)
+
+	// The exported file is read through the config
+	repoPath := t.TempDir()
+	writeFile(t, repoPath, ".github/known-owners.txt", content)
+	writeFile(t, repoPath, ConfigFileName, "lint:\n  known-owners: [\"@carol\"]\n  known-owners-file: .github/known-owners.txt\n")
+
+	cfg, err := LoadConfig(repoPath, "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"@carol", "@alice", "@bob", "@org/payments"}, cfg.Lint.KnownOwners)
+
+	writeFile(t, repoPath, ConfigFileName, "lint:\n  known-owners-file: missing.txt\n")
+	_, err = LoadConfig(repoPath, "")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "can't read known owners file")
+}
+
+func TestCheckGitHubOwners(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/org/members", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]map[string]interface{}{{"login": "alice"}})
+	})
+	mux.HandleFunc("/orgs/org/teams", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]map[string]interface{}{{"slug": "payments"}})
+	})
+	mux.HandleFunc("/users/bob", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"login": "bob"})
+	})
+	mux.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"message": "Not Found"})
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	rules := []Rule{
+		{Pattern: "*", Owners: []string{"@org/payments", "@Alice"}, Source: "CODEOWNERS", Line: 1},
+		{Pattern: "/src", Owners: []string{"@org/paymnts", "@bob", "dev@example.com"}, Source: "src/CODEOWNERS", Line: 1},
+		{Pattern: "/docs", Owners: []string{"@alic", "@bob"}, Source: "docs/CODEOWNERS", Line: 2},
+	}
+
+	client := &GitHubClient{BaseURL: server.URL, HTTPClient: server.Client()}
+	findings, err := CheckGitHubOwners(context.Background(), client, rules, nil)
+	require.NoError(t, err)
+	require.Equal(t, []Finding{
+		{Check: "unknown-owner", Severity: SeverityError, Message: "@org/paymnts isn't a team of org, did you mean @org/payments?", File: "src/CODEOWNERS", Line: 1},
+		{Check: "unknown-owner", Severity: SeverityError, Message: "@bob isn't a member of org", File: "src/CODEOWNERS", Line: 1},
+		{Check: "unknown-owner", Severity: SeverityError, Message: "@alic isn't a GitHub user, did you mean @alice?", File: "docs/CODEOWNERS", Line: 2},
+	}, findings)
+}
@@ -0,0 +1,138 @@
+package codeowners
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Layouts of the rules in the generated file.
+const (
+	// LayoutSource keeps the rules in the BFS order of their nested CO files.
+	LayoutSource = "source"
+
+	// LayoutOwner groups the rules by their owners.
+	LayoutOwner = "owner"
+)
+
+// Layouts are all supported layouts.
+var Layouts = []string{LayoutSource, LayoutOwner}
+
+// ValidLayout checks whether layout is supported, the empty layout is the
+// source layout.
+func ValidLayout(layout string) error {
+	if layout == "" || ContainsString(Layouts, layout) {
+		return nil
+	}
+
+	return fmt.Errorf("unknown layout %s, must be one of %s", layout, strings.Join(Layouts, ", "))
+}
+
+// ownerGroup is a run of rules with the same owners in the owner layout.
+type ownerGroup struct {
+	Owners []string
+	Rules  []Rule
+
+	// Continued is set if an earlier group has the same owners, because the
+	// rules in between take precedence over the rules of the earlier group.
+	Continued bool
+}
+
+// groupByOwner groups the rules by their owners, compared case-insensitively
+// and in order, the groups are ordered by their first rule. Since the last
+// matching rule wins, a rule is never moved before another rule whose pattern
+// might match the same paths. If that would be necessary, the rules of the
+// same owners are split into several groups.
+func groupByOwner(rules []Rule) []ownerGroup {
+	patterns := make([]pattern, len(rules))
+	for i, rule := range rules {
+		patterns[i] = compilePattern(rule.Pattern)
+	}
+
+	remaining := make([]int, len(rules))
+	for i := range remaining {
+		remaining[i] = i
+	}
+
+	var groups []ownerGroup
+	seen := map[string]bool{}
+	for len(remaining) > 0 {
+		// The first remaining rule can always be emitted
+		owners := rules[remaining[0]].Owners
+		key := ownersKey(owners)
+		group := ownerGroup{Owners: owners, Continued: seen[key]}
+		seen[key] = true
+
+		var skipped []int
+		for _, i := range remaining {
+			if ownersKey(rules[i].Owners) == key && !overlapsAny(patterns, skipped, i) {
+				group.Rules = append(group.Rules, rules[i])
+				continue
+			}
+			skipped = append(skipped, i)
+		}
+
+		groups = append(groups, group)
+		remaining = skipped
+	}
+
+	return groups
+}
+
+// ownersKey identifies the owners of a rule in groupByOwner.
+func ownersKey(owners []string) string {
+	return strings.ToLower(strings.Join(owners, " "))
+}
+
+// overlapsAny checks whether the pattern of rule i might match the same paths
+// as the pattern of any of the given rules.
+func overlapsAny(patterns []pattern, rules []int, i int) bool {
+	for _, j := range rules {
+		if patternsOverlap(patterns[i], patterns[j]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// patternsOverlap checks whether two patterns might match the same path. It
+// is conservative, only patterns that provably match disjoint paths, e.g.
+// because of different literal segments, don't overlap.
+func patternsOverlap(a, b pattern) bool {
+	if !a.anchored || !b.anchored {
+		return true
+	}
+
+	for i := 0; i < len(a.segments) && i < len(b.segments); i++ {
+		sa, sb := a.segments[i], b.segments[i]
+		if sa == "**" || sb == "**" {
+			return true
+		}
+
+		wildA, wildB := strings.ContainsAny(sa, `*?\`), strings.ContainsAny(sb, `*?\`)
+		switch {
+		case wildA && wildB:
+			continue
+		case wildA && !matchSegment(sa, sb), wildB && !matchSegment(sb, sa):
+			return false
+		case !wildA && !wildB && sa != sb:
+			return false
+		}
+	}
+
+	// One pattern is a prefix of the other and might match inside its dirs
+	return true
+}
+
+// ownerGroupComment renders the comment that introduces a group of rules.
+func ownerGroupComment(group ownerGroup) string {
+	owners := strings.Join(group.Owners, " ")
+	if owners == "" {
+		owners = "nobody"
+	}
+	if group.Continued {
+		return fmt.Sprintf("%s Owned by %s (continued)", codeownersCommentPrefix, owners)
+	}
+
+	return fmt.Sprintf("%s Owned by %s", codeownersCommentPrefix, owners)
+}
@@ -0,0 +1,66 @@
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupByOwner(t *testing.T) {
+	rules := []Rule{
+		{Pattern: "*", Owners: []string{"@org/admin"}},
+		{Pattern: "/payments", Owners: []string{"@org/payments"}},
+		{Pattern: "/platform", Owners: []string{"@org/platform"}},
+		{Pattern: "/billing", Owners: []string{"@org/Payments"}},
+		{Pattern: "/platform/payments-sdk", Owners: []string{"@org/payments"}},
+		{Pattern: "/docs/*.md", Owners: []string{"@org/admin"}},
+	}
+
+	expected := `# Owned by @org/admin
+* @org/admin
+/docs/*.md @org/admin
+
+# Owned by @org/payments
+/payments @org/payments
+/billing @org/Payments
+
+# Owned by @org/platform
+/platform @org/platform
+
+# Owned by @org/payments (continued)
+/platform/payments-sdk @org/payments
+`
+	require.Equal(t, expected, GenerateCodeownersFile(rules, GenerateOptions{NoHeader: true, Layout: LayoutOwner}))
+
+	// The layout must not change the owners of any path
+	original := NewMatcher(rules)
+	var reordered []Rule
+	for _, group := range groupByOwner(rules) {
+		reordered = append(reordered, group.Rules...)
+	}
+	for _, path := range []string{"README.md", "payments/api.go", "platform/payments-sdk/sdk.go", "platform/ci.yaml", "docs/guide.md", "billing/x"} {
+		expectedRule, _ := original.Match(path)
+		actualRule, _ := NewMatcher(reordered).Match(path)
+		require.Equal(t, expectedRule, actualRule, path)
+	}
+}
+
+func TestPatternsOverlap(t *testing.T) {
+	cases := []struct {
+		a, b    string
+		overlap bool
+	}{
+		{"/src", "/docs", false},
+		{"/src", "/src/main.go", true},
+		{"/src/*.go", "/src/*.md", true},
+		{"/src/*.go", "/src/README.md", false},
+		{"/src/*.go", "/docs/main.go", false},
+		{"/src/**/test", "/src/a/b", true},
+		{"*.md", "/src", true},
+		{"/src/", "/src/a/", true},
+	}
+
+	for _, c := range cases {
+		require.Equal(t, c.overlap, patternsOverlap(compilePattern(c.a), compilePattern(c.b)), "%s %s", c.a, c.b)
+	}
+}
@@ -1,14 +1,20 @@
-package main
+package codeowners
 
 import (
 	"container/list"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/denormal/go-gitignore"
 )
@@ -16,7 +22,7 @@ import (
 const (
 	codeownersFileName      = "CODEOWNERS"
 	codeownersCommentPrefix = "#"
-	generatedFileName       = ".github/CODEOWNERS"
+	GeneratedFileName       = ".github/CODEOWNERS"
 	generatedFileWarning    = "# GENERATED FILE, DO NOT EDIT!\n\n# File generated by https://github.com/gmolau/codeowners"
 )
 
@@ -29,7 +35,7 @@ func validateRoot(path string) (string, error) {
 		return "", fmt.Errorf("error while resolving path %s: %w", path, err)
 	}
 
-	absPathInfo, err := os.Stat(absPath)
+	absPathInfo, err := os.Stat(longPath(absPath))
 	if err != nil {
 		return "", fmt.Errorf("error while reading resolved path %s: %w", absPath, err)
 	}
@@ -41,18 +47,124 @@ func validateRoot(path string) (string, error) {
 	return absPath, nil
 }
 
+// DiscoverRoot finds the root of the git repository enclosing path by walking
+// up the dir tree until a dir containing .git is found (a dir for regular
+// repos, a file for worktrees and submodules). If there is no enclosing
+// repository, the absolute path itself is returned.
+func DiscoverRoot(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("error while resolving path %s: %w", path, err)
+	}
+
+	for dir := absPath; ; dir = filepath.Dir(dir) {
+		_, err := os.Lstat(filepath.Join(dir, ".git"))
+		if err == nil {
+			return dir, nil
+		}
+
+		if filepath.Dir(dir) == dir { // Reached the file system root
+			return absPath, nil
+		}
+	}
+}
+
+// Options configures how the rules of the nested CODEOWNERS files are rewritten.
+type Options struct {
+	// PathPrefix is prepended to every rewritten pattern, e.g. to make the rules
+	// of a subtree valid in the repo it gets merged into.
+	PathPrefix string
+
+	// Unanchored strips the leading "/" from every rewritten pattern so that
+	// GitHub matches them anywhere in the repo instead of relative to its root.
+	Unanchored bool
+
+	// SkipRootCodeowners excludes the CODEOWNERS file in the root dir itself,
+	// e.g. because it is a hand-written legacy file.
+	SkipRootCodeowners bool
+
+	// Files restricts processing to these CODEOWNERS files instead of walking
+	// the whole root. Relative paths are resolved against the root.
+	Files []string
+
+	// Strict selects the strict parser mode, which fails on anything the
+	// lenient default mode skips and reports to Diagnostics: rules that are
+	// dropped, e.g. file rules without owners, invalid owners and unknown
+	// pragmas.
+	Strict bool
+
+	// Diagnostics, if set, receives a warning for every dropped rule.
+	Diagnostics func(Finding)
+
+	// Inputs, if set, receives the path of every processed CO file relative
+	// to the root.
+	Inputs func(source string)
+
+	// TeamsManifest is the path of a teams manifest relative to the root
+	// whose rules are merged with the rules of the nested CO files, see
+	// TeamsManifest. Empty if there is none.
+	TeamsManifest string
+
+	// ReadmeOwners reads the owners declared in the front matter of READMEs
+	// as additional dir rules, which precede the rules of the CO file in the
+	// same dir.
+	ReadmeOwners bool
+
+	// SkipGenerated skips the CO files in subtrees marked linguist-generated
+	// by .gitattributes files, which are usually stray copies in generated
+	// or vendored code.
+	SkipGenerated bool
+
+	// MaxFileSize is the size limit of the CO files in bytes. Larger files
+	// are rejected since they are invariably generated files or binaries with
+	// the wrong name. 0 selects DefaultMaxFileSize, a negative size disables
+	// the limit.
+	MaxFileSize int64
+
+	// Output is the path of the generated file relative to the root, slash
+	// separated, if it is at the location of a nested CO file, e.g. the
+	// GitLab location CODEOWNERS. It is skipped so that the generated file
+	// isn't read back as input.
+	Output string
+}
+
 // RewriteCodeownersRules visits every CODEOWNERS file under path (respecting .gitignore files
-// and rewrites its rules for inclusion in the root CO file.
-func RewriteCodeownersRules(path string) ([]string, error) {
+// and rewrites its rules for inclusion in the root CO file. The walk stops when ctx is done.
+func RewriteCodeownersRules(ctx context.Context, path string, opts Options) ([]Rule, error) {
 	root, err := validateRoot(path)
 	if err != nil {
 		return nil, fmt.Errorf("error while validating path %s: %w", path, err)
 	}
 
-	var rewrittenRules []string
+	var rewrittenRules []Rule
+
+	walk := walkCodeownersFiles
+	if opts.Files != nil {
+		walk = func(ctx context.Context, root string, procFn procFn) error {
+			return visitCodeownersFiles(ctx, root, opts.Files, procFn)
+		}
+	}
+
+	var generated *GeneratedPaths
+	if opts.SkipGenerated {
+		generated = NewGeneratedPaths(root)
+	}
+
+	err = walk(ctx, root, func(coPath string) error {
+		if opts.SkipRootCodeowners && filepath.Dir(coPath) == root {
+			return nil
+		}
+		if generated != nil || opts.Output != "" {
+			source, err := relativeSourcePath(root, coPath)
+			if err != nil {
+				return err
+			}
+			if source == opts.Output || (generated != nil && generated.isGenerated(source)) {
+				return nil
+			}
+		}
 
-	err = walkCodeownersFiles(root, func(coPath string) error {
-		rules, procErr := processCodeownersFile(root, coPath)
+		rules, procErr := processCodeownersFile(root, coPath, opts)
 		if procErr != nil {
 			return procErr
 		}
@@ -65,28 +177,199 @@ func RewriteCodeownersRules(path string) ([]string, error) {
 		return nil, fmt.Errorf("error while processing CODEOWNERS files: %w", err)
 	}
 
+	var dirs []string
+	var dirRules []Rule
+	if opts.TeamsManifest != "" {
+		manifest, err := LoadTeamsManifest(root, opts.TeamsManifest)
+		if err != nil {
+			return nil, err
+		}
+		if opts.Inputs != nil {
+			opts.Inputs(manifest.Source)
+		}
+
+		for _, entry := range manifest.Entries {
+			dirs = append(dirs, entry.Dir)
+		}
+		dirRules = append(dirRules, manifest.Rules(opts)...)
+	}
+
+	if opts.ReadmeOwners {
+		readmeDirs, readmeRules, err := rewriteReadmeRules(ctx, root, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		dirs = append(dirs, readmeDirs...)
+		dirRules = append(dirRules, readmeRules...)
+	}
+
+	if dirRules != nil {
+		sortByDirDepth(dirs, dirRules)
+		rewrittenRules = mergeDirRules(dirs, dirRules, rewrittenRules)
+	}
+
+	sortByPriority(rewrittenRules)
+	return rewrittenRules, nil
+}
+
+// rewriteCodeownersTree is RewriteCodeownersRules for a repo that isn't
+// checked out, e.g. a past commit or a remote repo. files are all files of the
+// repo relative to the root and slash separated, read returns the content of
+// one of them.
+func rewriteCodeownersTree(ctx context.Context, files []string, read func(file string) ([]byte, error), opts Options) ([]Rule, error) {
+	var generated *GeneratedPaths
+	if opts.SkipGenerated {
+		generated = newGeneratedPathsFrom(read)
+	}
+
+	var paths []string
+	for _, file := range files {
+		if !isNestedCodeownersPath(file) {
+			continue
+		}
+		if opts.SkipRootCodeowners && file == codeownersFileName {
+			continue
+		}
+		if file == opts.Output || (generated != nil && generated.isGenerated(file)) {
+			continue
+		}
+
+		paths = append(paths, file)
+	}
+	sortBreadthFirst(paths, "/")
+
+	var rewrittenRules []Rule
+	for i, source := range paths {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("stopped before %s after processing %d CODEOWNERS files: %w", source, i, ctx.Err())
+		}
+
+		content, err := read(source)
+		if err != nil {
+			return nil, fmt.Errorf("error while reading %s: %w", source, err)
+		}
+
+		err = checkFileSize(source, int64(len(content)), opts)
+		if err != nil {
+			return nil, err
+		}
+
+		lines, err := splitCodeownersContent(source, content)
+		if err != nil {
+			return nil, err
+		}
+
+		rewrittenPath := path.Join("/", opts.PathPrefix, path.Dir(source))
+		rules, err := processCodeownersLines(source, rewrittenPath, lines, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error while processing CODEOWNERS files: %w", err)
+		}
+
+		rewrittenRules = append(rewrittenRules, rules...)
+	}
+
+	sortByPriority(rewrittenRules)
 	return rewrittenRules, nil
 }
 
+// symlinkMode is the git file mode of symlinks, whose blobs contain the link
+// target.
+const symlinkMode = "120000"
+
+// followTreeSymlinks wraps the read function of rewriteCodeownersTree to
+// follow the symlinks among the files of a tree read from git or the GitHub
+// API, like resolveCodeownersFile does in a checkout. Links are resolved
+// relative to their dir and must stay inside the repo.
+func followTreeSymlinks(links map[string]bool, read func(file string) ([]byte, error)) func(file string) ([]byte, error) {
+	return func(file string) ([]byte, error) {
+		visited := map[string]bool{}
+		for links[file] {
+			if visited[file] {
+				return nil, fmt.Errorf("symlink cycle at %s", file)
+			}
+			visited[file] = true
+
+			target, err := read(file)
+			if err != nil {
+				return nil, err
+			}
+
+			resolved := path.Join(path.Dir(file), string(target))
+			if path.IsAbs(string(target)) || resolved == ".." || strings.HasPrefix(resolved, "../") {
+				return nil, fmt.Errorf("symlink %s points outside the repo to %s", file, target)
+			}
+			file = resolved
+		}
+
+		return read(file)
+	}
+}
+
 // procFn gets the path to a CODEOWNERS file and processes it.
 type procFn = func(coPath string) error
 
 // walkCodeownersFiles walks visits every CODEOWNERS file under root and calls
-// procFn with the files absolute path as argument.
-func walkCodeownersFiles(root string, procFn procFn) error {
+// procFn with the files absolute path as argument. If ctx is done before the
+// walk is complete, an error wrapping ctx.Err() that reports the progress of
+// the walk is returned.
+func walkCodeownersFiles(ctx context.Context, root string, procFn procFn) error {
+	processedFiles := 0
+
+	err := walkTree(ctx, root, func(path string, dirEntry fs.DirEntry) error {
+		if !isCodeownersFile(dirEntry) {
+			return nil
+		}
+
+		// Skip the target file
+		if isGeneratedFile(path) {
+			return nil
+		}
+
+		err := procFn(path)
+		if err != nil {
+			return err
+		}
+		processedFiles++
+
+		return nil
+	})
+
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("%w (processed %d CODEOWNERS files)", err, processedFiles)
+	}
+
+	return err
+}
+
+// visitFn gets the absolute path of a file found by walkTree and its dir entry.
+type visitFn = func(path string, dirEntry fs.DirEntry) error
+
+// walkTree walks the dirs under root in BFS and lexicographic order, skipping
+// dirs ignored by .gitignore files, and calls visitFn for every non-dir entry.
+// If ctx is done before the walk is complete, an error wrapping ctx.Err() that
+// reports the progress of the walk is returned.
+func walkTree(ctx context.Context, root string, visitFn visitFn) error {
 	ignore := initGitignore(root)
 
 	dirQueue := newStringQueue()
 	dirQueue.Enqueue(root)
 
+	visitedDirs := 0
+
 	for dirQueue.Len() > 0 {
 		currentDir := dirQueue.Dequeue()
 
+		if ctx.Err() != nil {
+			return fmt.Errorf("walk stopped before %s after visiting %d dirs: %w", currentDir, visitedDirs, ctx.Err())
+		}
+		visitedDirs++
+
 		if shouldIgnoreDir(ignore, currentDir) {
 			continue
 		}
 
-		dir, err := os.Open(currentDir)
+		dir, err := os.Open(longPath(currentDir))
 		if err != nil {
 			return fmt.Errorf("error while opening dir %s: %w", currentDir, err)
 		}
@@ -101,28 +384,113 @@ func walkCodeownersFiles(root string, procFn procFn) error {
 		sort.Slice(dirEntries, func(i, j int) bool { return dirEntries[i].Name() < dirEntries[j].Name() })
 
 		for _, dirEntry := range dirEntries {
-			if isCodeownersFile(dirEntry) {
-				path := filepath.Join(currentDir, dirEntry.Name())
+			path := filepath.Join(currentDir, dirEntry.Name())
 
-				// Skip the target file
-				if strings.HasSuffix(path, generatedFileName) {
-					continue
-				}
+			if dirEntry.IsDir() {
+				dirQueue.Enqueue(path)
+				continue
+			}
 
-				err = procFn(path)
-				if err != nil {
-					return err
-				}
-			} else if dirEntry.IsDir() {
-				dirEntryPath := filepath.Join(currentDir, dirEntry.Name())
-				dirQueue.Enqueue(dirEntryPath)
+			err = visitFn(path, dirEntry)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ListFiles returns the paths of all files under root that aren't ignored by
+// .gitignore files, relative to root and with forward slashes.
+func ListFiles(ctx context.Context, path string) ([]string, error) {
+	root, err := validateRoot(path)
+	if err != nil {
+		return nil, fmt.Errorf("error while validating path %s: %w", path, err)
+	}
+
+	ignore := initGitignore(root)
+
+	var files []string
+	err = walkTree(ctx, root, func(path string, dirEntry fs.DirEntry) error {
+		if ignore != nil {
+			if match := ignore.Absolute(path, false); match != nil && match.Ignore() {
+				return nil
 			}
 		}
+
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return fmt.Errorf("can't make path %s relative to %s: %w", path, root, err)
+		}
+
+		files = append(files, filepath.ToSlash(relPath))
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("error while listing files: %w", err)
+	}
+
+	return files, nil
+}
+
+// visitCodeownersFiles calls procFn for each of the given CODEOWNERS files in
+// the same order as walkCodeownersFiles would visit them. The files must be
+// named CODEOWNERS and be located under root, the generated file is skipped.
+func visitCodeownersFiles(ctx context.Context, root string, files []string, procFn procFn) error {
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		path := file
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(root, path)
+		}
+		path = filepath.Clean(path)
+
+		if filepath.Base(path) != codeownersFileName {
+			return fmt.Errorf("%s is not a %s file", file, codeownersFileName)
+		}
+
+		relPath, err := filepath.Rel(root, path)
+		if err != nil || strings.HasPrefix(relPath, "..") {
+			return fmt.Errorf("%s is not located under %s", file, root)
+		}
+
+		if isGeneratedFile(relPath) {
+			continue
+		}
+
+		paths = append(paths, path)
+	}
+
+	sortBreadthFirst(paths, string(filepath.Separator))
+
+	for i, path := range paths {
+		if ctx.Err() != nil {
+			return fmt.Errorf("stopped before %s after processing %d CODEOWNERS files: %w", path, i, ctx.Err())
+		}
+
+		err := procFn(path)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// sortBreadthFirst orders paths like the BFS walk, i.e. shallow paths first,
+// then in lexicographic order.
+func sortBreadthFirst(paths []string, separator string) {
+	sort.SliceStable(paths, func(i, j int) bool {
+		di, dj := strings.Count(paths[i], separator), strings.Count(paths[j], separator)
+		if di != dj {
+			return di < dj
+		}
+		return paths[i] < paths[j]
+	})
+}
+
 // initGitignore parses the .gitignore files under root, including nested ones.
 // If none are found or parsing errors, nil is returned.
 func initGitignore(root string) gitignore.GitIgnore {
@@ -149,56 +517,340 @@ func shouldIgnoreDir(ignore gitignore.GitIgnore, path string) bool {
 	return false
 }
 
-// isCodeownersFile checks whether a direntry is a CODEOWNERS file.
+// listDirs returns the dirs containing the files, which are relative to the
+// root and slash separated, in lexicographic order. The root isn't included.
+func listDirs(files []string) []string {
+	var dirs []string
+	seen := map[string]bool{}
+	for _, file := range files {
+		for dir := path.Dir(file); dir != "." && !seen[dir]; dir = path.Dir(dir) {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+
+	return dirs
+}
+
+// isNestedCodeownersPath checks whether a slash separated path relative to the
+// root is a nested CO file, i.e. a CO file that isn't generated.
+func isNestedCodeownersPath(file string) bool {
+	return path.Base(file) == codeownersFileName && !isGeneratedFile(file)
+}
+
+// isCodeownersFile checks whether a direntry is a CODEOWNERS file. Symlinks
+// are accepted as shared CO files are often symlinked into several dirs, they
+// are resolved when reading the file.
 func isCodeownersFile(d fs.DirEntry) bool {
-	return !d.IsDir() && d.Name() == codeownersFileName
+	isFile := d.Type().IsRegular() || d.Type()&fs.ModeSymlink != 0
+	return isFile && d.Name() == codeownersFileName
+}
+
+// resolveCodeownersFile follows the symlinks of a CO file to the regular file
+// they point to. The rules of a symlinked CO file still apply to the dir
+// containing the link.
+func resolveCodeownersFile(path string) (string, error) {
+	visited := map[string]bool{}
+	for {
+		info, err := os.Lstat(longPath(path))
+		if err != nil {
+			return "", err
+		}
+
+		if info.Mode()&fs.ModeSymlink == 0 {
+			if !info.Mode().IsRegular() {
+				return "", fmt.Errorf("%s is not a regular file", path)
+			}
+			return path, nil
+		}
+
+		if visited[path] {
+			return "", fmt.Errorf("symlink cycle at %s", path)
+		}
+		visited[path] = true
+
+		target, err := os.Readlink(longPath(path))
+		if err != nil {
+			return "", err
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		path = filepath.Clean(target)
+	}
 }
 
 // processCodeownersFile reads and rewrites the codeowner rules.
-func processCodeownersFile(root, path string) ([]string, error) {
+func processCodeownersFile(root, path string, opts Options) ([]Rule, error) {
+	source, err := relativeSourcePath(root, path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Errors are reported by readCodeownersFile
+	if info, err := os.Stat(longPath(path)); err == nil {
+		err = checkFileSize(source, info.Size(), opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	lines, err := readCodeownersFile(path)
 	if err != nil {
 		return nil, err
 	}
 
-	rewrittenPath, err := rewriteCodeownersPath(root, path)
+	rewrittenPath, err := rewriteCodeownersPath(root, path, opts.PathPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return processCodeownersLines(source, rewrittenPath, lines, opts)
+}
+
+// processCodeownersLines rewrites the rules in the lines of the CO file
+// source, which is relative to the root, for the dir rewrittenPath.
+func processCodeownersLines(source, rewrittenPath string, lines []string, opts Options) ([]Rule, error) {
+	if opts.Inputs != nil {
+		opts.Inputs(source)
+	}
+
+	section, attrs, err := parseFilePragmas(lines, source, rewrittenPath)
 	if err != nil {
 		return nil, err
 	}
 
-	var rewrittenRules []string
-	for _, line := range lines {
+	err = checkUnknownPragmas(source, lines, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var rewrittenRules []Rule
+	var comments []string
+	for i, line := range lines {
+		if comment, ok := ordinaryComment(line); ok {
+			comments = append(comments, comment)
+			continue
+		}
+		if isPragmaLine(line) {
+			continue
+		}
+
+		ruleComments := comments
+		comments = nil
+
 		if isCodeownersRule(line) {
-			rewritten, err := rewriteCodeownersRule(rewrittenPath, line)
+			rewritten, ok := rewriteCodeownersRule(rewrittenPath, line)
+			if !ok {
+				err := reportDroppedRule(source, i+1, line, opts)
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			for _, finding := range lintCodeownersRule(source, i+1, line) {
+				switch finding.Check {
+				case "escaping-pattern":
+					// The rewritten pattern would be misleading in any mode
+					return nil, &ParseError{Source: source, Line: finding.Line, Column: finding.Column, Err: errors.New(finding.Message)}
+				case "invalid-owner", "unsupported-pattern":
+					finding.Severity = SeverityWarning
+					err := reportParseProblem(finding, opts)
+					if err != nil {
+						return nil, err
+					}
+				}
+			}
+
+			if opts.Unanchored {
+				rewritten.Pattern = strings.TrimPrefix(rewritten.Pattern, "/")
+			}
+
+			rewritten.Source = source
+			rewritten.Line = i + 1
+			rewritten.Section = section
+			rewritten.Comments = ruleComments
+
+			ruleAttrs, err := parseRulePragmas(source, i+1, line, attrs)
 			if err != nil {
 				return nil, err
 			}
+			ruleAttrs.apply(&rewritten)
 
-			if rewritten != "" {
-				rewrittenRules = append(rewrittenRules, rewritten)
-			}
+			rewrittenRules = append(rewrittenRules, rewritten)
 		}
 	}
 
 	return rewrittenRules, nil
 }
 
+// ordinaryComment returns the comment of a comment line without the leading
+// "#", unless it is a pragma or suppression.
+func ordinaryComment(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, codeownersCommentPrefix) || isPragmaLine(line) {
+		return "", false
+	}
+
+	return strings.TrimSpace(strings.TrimPrefix(trimmed, codeownersCommentPrefix)), true
+}
+
+// isPragmaLine checks whether line is a comment line with pragmas or a
+// suppression, which apply to the whole file and aren't attached to a rule.
+func isPragmaLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, codeownersCommentPrefix) {
+		return false
+	}
+
+	comment := strings.TrimSpace(strings.TrimPrefix(trimmed, codeownersCommentPrefix))
+	return strings.HasPrefix(comment, pragmaPrefix) || strings.HasPrefix(comment, suppressionPrefix)
+}
+
+// ParseError is an error in a CO file. Line and Column are 1-based, Column
+// counts bytes and is 0 if the error concerns the whole line.
+type ParseError struct {
+	Source string
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	if e.Column > 0 {
+		return fmt.Sprintf("%s:%d:%d: %s", e.Source, e.Line, e.Column, e.Err)
+	}
+
+	return fmt.Sprintf("%s:%d: %s", e.Source, e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// reportDroppedRule reports a rule that can't be rewritten, i.e. a file rule
+// without owners, see reportParseProblem.
+func reportDroppedRule(source string, line int, rule string, opts Options) error {
+	tokens, _ := tokenizeCodeownersRule(rule)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	column := tokenColumns(rule)[0]
+	message := fmt.Sprintf("rule for %s has no owners", tokens[0])
+	if opts.Strict {
+		return &ParseError{Source: source, Line: line, Column: column, Err: errors.New(message)}
+	}
+
+	return reportParseProblem(Finding{
+		Check:    "missing-owners",
+		Severity: SeverityWarning,
+		Message:  message + " and is dropped",
+		File:     source,
+		Line:     line,
+		Column:   column,
+	}, opts)
+}
+
+// reportParseProblem reports something the parser doesn't understand and
+// skips, e.g. a dropped rule or an unknown pragma. It is a warning sent to
+// opts.Diagnostics in lenient mode and a ParseError in strict mode.
+func reportParseProblem(finding Finding, opts Options) error {
+	if opts.Strict {
+		return &ParseError{Source: finding.File, Line: finding.Line, Column: finding.Column, Err: errors.New(finding.Message)}
+	}
+
+	if opts.Diagnostics != nil {
+		opts.Diagnostics(finding)
+	}
+
+	return nil
+}
+
+// relativeSourcePath makes the path of a CO file relative to the root for use
+// in Rule.Source.
+func relativeSourcePath(root, path string) (string, error) {
+	relPath, err := filepath.Rel(root, path)
+	if err != nil {
+		return "", fmt.Errorf("can't make CODEOWNERS path %s relative to %s: %w", path, root, err)
+	}
+
+	return filepath.ToSlash(relPath), nil
+}
+
 // readCodeownersFile reads a CO file line-wise into a slice of strings. If an
 // error occurs, the returned error contains the file path and the error.
 func readCodeownersFile(path string) ([]string, error) {
-	file, err := os.Open(path)
+	resolvedPath, err := resolveCodeownersFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't resolve CODEOWNERS file %s: %w", path, err)
+	}
+
+	file, err := os.Open(longPath(resolvedPath))
 	if err != nil {
 		return nil, fmt.Errorf("can't open CODEOWNERS file %s: %w", path, err)
 	}
 	defer file.Close()
 
-	bytes, err := io.ReadAll(file)
+	content, err := io.ReadAll(file)
 	if err != nil {
 		return nil, fmt.Errorf("can't read CODEOWNERS file %s: %w", path, err)
 	}
 
-	content := string(bytes)
-	return strings.Split(content, "\n"), nil
+	return splitCodeownersContent(path, content)
+}
+
+// splitCodeownersContent splits the content of the CO file at path into lines.
+// Content with NUL bytes or invalid UTF-8, usually a binary file with the
+// wrong name, is rejected with the first offending line.
+func splitCodeownersContent(path string, content []byte) ([]string, error) {
+	lines := strings.Split(string(content), "\n")
+	for i, line := range lines {
+		if column := strings.IndexByte(line, 0); column >= 0 {
+			err := errors.New("CODEOWNERS file contains NUL bytes, is it a binary file?")
+			return nil, &ParseError{Source: path, Line: i + 1, Column: column + 1, Err: err}
+		}
+		if column := invalidUTF8Index(line); column >= 0 {
+			err := errors.New("CODEOWNERS file is not valid UTF-8")
+			return nil, &ParseError{Source: path, Line: i + 1, Column: column + 1, Err: err}
+		}
+	}
+
+	return lines, nil
+}
+
+// DefaultMaxFileSize is the default size limit of CO files, see
+// Options.MaxFileSize. Even the CO files of large monorepos stay far below.
+const DefaultMaxFileSize = 1 << 20
+
+// checkFileSize rejects the CO file source of size bytes if it exceeds the
+// size limit of the options.
+func checkFileSize(source string, size int64, opts Options) error {
+	limit := opts.MaxFileSize
+	if limit == 0 {
+		limit = DefaultMaxFileSize
+	}
+	if limit < 0 || size <= limit {
+		return nil
+	}
+
+	return fmt.Errorf("CODEOWNERS file %s has %d bytes, more than the limit of %d bytes, is it a generated or binary file?", source, size, limit)
+}
+
+// invalidUTF8Index returns the byte index of the first invalid UTF-8 sequence
+// in s, or -1 if s is valid.
+func invalidUTF8Index(s string) int {
+	for i, r := range s {
+		if r == utf8.RuneError {
+			if _, size := utf8.DecodeRuneInString(s[i:]); size == 1 {
+				return i
+			}
+		}
+	}
+
+	return -1
 }
 
 // isCodeownersRule decides whether a line from a CO file should be processed.
@@ -211,19 +863,20 @@ func isCodeownersRule(line string) bool {
 
 // rewriteCodeownersPath takes the absolut path of a CO file and rewrites it
 // for usage in the root CO file by taking its parent dir and making it absolute
-// to the root.
-func rewriteCodeownersPath(root, path string) (string, error) {
+// to the root. A non-empty prefix is inserted between the root and the dir.
+func rewriteCodeownersPath(root, file, prefix string) (string, error) {
 	// Get the dir of this CODEOWNERS file
-	dir := filepath.Dir(path)
+	dir := filepath.Dir(file)
 
 	// Make that dir relative to the root
 	relDir, err := filepath.Rel(root, dir)
 	if err != nil {
-		return "", fmt.Errorf("can't rewrite CODEOWNERS path %s: %s", path, err)
+		return "", fmt.Errorf("can't rewrite CODEOWNERS path %s: %s", file, err)
 	}
 
-	// Make that path absolute to the root
-	return fmt.Sprintf("/%s", relDir), nil
+	// Make that path absolute to the root, with slashes on every OS since
+	// it is a pattern
+	return path.Join("/", prefix, filepath.ToSlash(relDir)), nil
 }
 
 // rewriteCodeownersRule rewrites a valid CO rule for inclusion in the root CO file.
@@ -233,49 +886,253 @@ func rewriteCodeownersPath(root, path string) (string, error) {
 //     "/path/to/dir @org/user"
 //   - File and glob ownership rules have the CO file path prepended to the file:
 //     "main.go @org/user" becomes "/path/to/dir/main.go @org/user"
-func rewriteCodeownersRule(rewrittenPath, rule string) (string, error) {
-	if isDirRule(rule) {
-		return rewriteDirRule(rewrittenPath, rule), nil
+//
+// Rules that can't be rewritten, i.e. file rules without owners, are dropped by
+// returning false.
+func rewriteCodeownersRule(rewrittenPath, rule string) (Rule, bool) {
+	tokens, comment := tokenizeCodeownersRule(rule)
+	if len(tokens) == 0 {
+		return Rule{}, false
+	}
+
+	if isDirRule(tokens) {
+		return rewriteDirRule(rewrittenPath, tokens, comment), true
 	} else {
-		return rewriteNonDirRule(rewrittenPath, rule), nil
+		return rewriteNonDirRule(rewrittenPath, tokens, comment)
+	}
+}
+
+// tokenizeCodeownersRule splits a CO rule into its whitespace separated tokens
+// and a trailing comment, which starts with the first token beginning with "#".
+func tokenizeCodeownersRule(rule string) ([]string, string) {
+	tokens := strings.Fields(rule)
+	for i, token := range tokens {
+		if strings.HasPrefix(token, codeownersCommentPrefix) {
+			comment := strings.Join(tokens[i:], " ")
+			comment = strings.TrimSpace(strings.TrimPrefix(comment, codeownersCommentPrefix))
+			return tokens[:i], comment
+		}
+	}
+
+	return tokens, ""
+}
+
+// tokenColumns returns the 1-based byte columns at which the whitespace
+// separated tokens of line start, including the tokens of a trailing comment.
+func tokenColumns(line string) []int {
+	var columns []int
+	inToken := false
+	for i, r := range line {
+		isSpace := unicode.IsSpace(r)
+		if !isSpace && !inToken {
+			columns = append(columns, i+1)
+		}
+		inToken = !isSpace
 	}
+
+	return columns
 }
 
 // isDirRule checks whether a CO rule concerns a directory. This is the
 // standard case, it is assumed when the first token of the rule contains an "@"
 // (as codeowners can only be GitHub groups or users or email addresses).
-func isDirRule(rule string) bool {
-	tokens := strings.SplitN(rule, " ", 2)
+func isDirRule(tokens []string) bool {
 	return len(tokens) >= 1 && strings.Contains(tokens[0], "@")
 }
 
-func rewriteDirRule(path, rule string) string {
-	// Edge case: If the path is "/.", i.e. we are processing a CO file in
+func rewriteDirRule(path string, owners []string, comment string) Rule {
+	// Edge case: If the path is "/", i.e. we are processing a CO file in
 	// root the path should be a glob according to the CODEOWNERS syntax
 	// https://docs.github.com/en/github/creating-cloning-and-archiving-repositories/creating-a-repository-on-github/about-code-owners#codeowners-syntax
-	if path == "/." {
+	if path == "/" {
 		path = "*"
 	}
 
-	return fmt.Sprintf("%s %s", path, rule)
+	return Rule{Pattern: path, Owners: owners, Dir: true, Comment: comment}
 }
 
-func rewriteNonDirRule(path, rule string) string {
-	tokens := strings.SplitN(rule, " ", 2)
+func rewriteNonDirRule(dir string, tokens []string, comment string) (Rule, bool) {
 	if len(tokens) < 2 {
+		return Rule{}, false
+	}
+
+	ruleTarget := tokens[0]
+	pattern := path.Join(dir, ruleTarget)
+
+	return Rule{Pattern: pattern, Owners: tokens[1:], Comment: comment}, true
+}
+
+// GenerateOptions configures how the root CO file is generated.
+type GenerateOptions struct {
+	// Header replaces the default warning header at the top of the file. Lines
+	// that aren't comments yet are turned into comments.
+	Header string
+
+	// NoHeader omits the header entirely, leaving only the rules.
+	NoHeader bool
+
+	// AnnotateSource appends the location of the rule in its nested CO file
+	// as trailing comment to every rule.
+	AnnotateSource bool
+
+	// Metadata is appended to the header if set.
+	Metadata *Metadata
+
+	// Target is the platform the file is generated for, TargetGitHub if empty.
+	// It determines how sections are rendered.
+	Target string
+
+	// Layout determines the order of the rules, LayoutSource if empty. The
+	// owner layout omits section comments.
+	Layout string
+
+	// PreserveComments emits the comment lines above the rules in the nested
+	// CO files above the rewritten rules.
+	PreserveComments bool
+
+	// Provenance emits a "# from src/CODEOWNERS" comment before every block
+	// of consecutive rules from the same nested CO file.
+	Provenance bool
+}
+
+// Metadata describes the generation run of a root CO file, it answers when and
+// from what the file was generated.
+type Metadata struct {
+	GeneratedAt  time.Time
+	ToolVersion  string
+	SourceCommit string
+}
+
+// comment renders the metadata as comment lines, empty fields are omitted.
+func (m Metadata) comment() string {
+	var lines []string
+	if !m.GeneratedAt.IsZero() {
+		lines = append(lines, fmt.Sprintf("# Generated at: %s", m.GeneratedAt.UTC().Format(time.RFC3339)))
+	}
+	if m.ToolVersion != "" {
+		lines = append(lines, fmt.Sprintf("# Tool version: %s", m.ToolVersion))
+	}
+	if m.SourceCommit != "" {
+		lines = append(lines, fmt.Sprintf("# Source commit: %s", m.SourceCommit))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// GenerateCodeownersFile renders the root CO file from the rewritten rules.
+func GenerateCodeownersFile(rules []Rule, opts GenerateOptions) string {
+	rules = TargetRules(rules, opts.Target)
+
+	var lines []string
+	if opts.Layout == LayoutOwner {
+		for i, group := range groupByOwner(rules) {
+			if i > 0 {
+				lines = append(lines, "")
+			}
+			lines = append(lines, ownerGroupComment(group))
+			for j, rule := range group.Rules {
+				if j == 0 || rule.Source != group.Rules[j-1].Source {
+					lines = append(lines, provenanceLines(rule, opts)...)
+				}
+				lines = append(lines, generateRuleComments(rule, opts)...)
+				lines = append(lines, generateRuleLine(rule, opts))
+			}
+		}
+	} else {
+		var section *Section
+		for i, rule := range rules {
+			line, sectionStart := sectionLine(section, rule, opts.Target)
+			if sectionStart {
+				lines = append(lines, line)
+			}
+			section = rule.Section
+
+			if i == 0 || sectionStart || rule.Source != rules[i-1].Source {
+				lines = append(lines, provenanceLines(rule, opts)...)
+			}
+
+			lines = append(lines, generateRuleComments(rule, opts)...)
+			lines = append(lines, generateRuleLine(rule, opts))
+		}
+	}
+
+	body := strings.Join(lines, "\n")
+
+	header := generateHeader(opts)
+	if header == "" {
+		return fmt.Sprintf("%s\n", body)
+	}
+
+	return fmt.Sprintf("%s\n\n%s\n", header, body)
+}
+
+// generateRuleLine renders a rule as line of the root CO file.
+func generateRuleLine(rule Rule, opts GenerateOptions) string {
+	line := rule.String()
+	if opts.AnnotateSource {
+		line = fmt.Sprintf("%s %s %s", line, codeownersCommentPrefix, rule.Location())
+	}
+
+	return line
+}
+
+// provenanceLines renders the comment that starts a block of rules from the
+// nested CO file of rule if the provenance is emitted. Rules that don't come
+// from a file, e.g. for never-owned paths, have no provenance.
+func provenanceLines(rule Rule, opts GenerateOptions) []string {
+	if !opts.Provenance || rule.Source == "" {
+		return nil
+	}
+
+	return []string{fmt.Sprintf("%s from %s", codeownersCommentPrefix, rule.Source)}
+}
+
+// generateRuleComments renders the comments above a rule as comment lines of
+// the root CO file if they are preserved.
+func generateRuleComments(rule Rule, opts GenerateOptions) []string {
+	if !opts.PreserveComments {
+		return nil
+	}
+
+	lines := make([]string, 0, len(rule.Comments))
+	for _, comment := range rule.Comments {
+		lines = append(lines, strings.TrimSpace(fmt.Sprintf("%s %s", codeownersCommentPrefix, comment)))
+	}
+
+	return lines
+}
+
+// generateHeader returns the header comment block of the root CO file.
+func generateHeader(opts GenerateOptions) string {
+	if opts.NoHeader {
 		return ""
 	}
 
-	ruleTarget := strings.TrimSpace(tokens[0])
-	rule = strings.TrimSpace(tokens[1])
-	path = filepath.Join(path, ruleTarget)
+	header := generatedFileWarning
+	if opts.Header != "" {
+		header = commentLines(opts.Header)
+	}
 
-	return fmt.Sprintf("%s %s", path, rule)
+	if opts.Metadata != nil {
+		if metadata := opts.Metadata.comment(); metadata != "" {
+			header = fmt.Sprintf("%s\n\n%s", header, metadata)
+		}
+	}
+
+	return header
 }
 
-func GenerateCodeownersFile(rules []string) string {
-	body := strings.Join(rules, "\n")
-	return fmt.Sprintf("%s\n\n%s\n", generatedFileWarning, body)
+// commentLines turns every non-empty line of text into a comment unless it
+// already is one.
+func commentLines(text string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range lines {
+		if line != "" && !strings.HasPrefix(line, codeownersCommentPrefix) {
+			lines[i] = fmt.Sprintf("%s %s", codeownersCommentPrefix, line)
+		}
+	}
+
+	return strings.Join(lines, "\n")
 }
 
 // stringQueue is the queue for BFS traversal
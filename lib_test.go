@@ -1,9 +1,13 @@
-package main
+package codeowners
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -23,7 +27,7 @@ func TestE2E(t *testing.T) {
 	existingCOFile := generatedFileWarning + `
 /src/foobar @org/previousUser
 `
-	writeFile(t, repoPath, generatedFileName, existingCOFile)
+	writeFile(t, repoPath, GeneratedFileName, existingCOFile)
 
 	// Create a simple CODEOWNERS file for the happy path
 	simpleCOFile := `
@@ -90,9 +94,9 @@ ci.yaml @org/ci-admin
 		"/src/dir2/*.js @org/frontend @fullstackUser",
 	}
 
-	rewrittenRules, err := RewriteCodeownersRules(repoPath)
+	rewrittenRules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{})
 	require.NoError(t, err)
-	require.Equal(t, expectedRules, rewrittenRules)
+	require.Equal(t, expectedRules, ruleStrings(rewrittenRules))
 
 	// Test file generation
 	expectedFile := generatedFileWarning + `
@@ -107,10 +111,403 @@ ci.yaml @org/ci-admin
 /src/dir2/*.js @org/frontend @fullstackUser
 `
 
-	generatedFile := GenerateCodeownersFile(rewrittenRules)
+	generatedFile := GenerateCodeownersFile(rewrittenRules, GenerateOptions{})
 	require.Equal(t, expectedFile, generatedFile)
 }
 
+func TestGenerateHeader(t *testing.T) {
+	rules := []Rule{{Pattern: "*", Owners: []string{"@org/admin"}}}
+
+	generatedFile := GenerateCodeownersFile(rules, GenerateOptions{NoHeader: true})
+	require.Equal(t, "* @org/admin\n", generatedFile)
+
+	generatedFile = GenerateCodeownersFile(rules, GenerateOptions{Header: "Managed by platform\n\n# See docs/ownership.md"})
+	require.Equal(t, "# Managed by platform\n\n# See docs/ownership.md\n\n* @org/admin\n", generatedFile)
+
+	metadata := &Metadata{
+		GeneratedAt:  time.Date(2021, 8, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*60*60)),
+		ToolVersion:  "v0.1.5",
+		SourceCommit: "0123abc",
+	}
+	generatedFile = GenerateCodeownersFile(rules, GenerateOptions{Header: "Managed by platform", Metadata: metadata})
+	require.Equal(t, `# Managed by platform
+
+# Generated at: 2021-08-01T10:00:00Z
+# Tool version: v0.1.5
+# Source commit: 0123abc
+
+* @org/admin
+`, generatedFile)
+}
+
+func TestPathPrefix(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admin\n*.md @org/docs\n")
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/user\nmain.go @org/gopher\n")
+
+	expectedRules := []string{
+		"/services/checkout @org/admin",
+		"/services/checkout/*.md @org/docs",
+		"/services/checkout/src @org/user",
+		"/services/checkout/src/main.go @org/gopher",
+	}
+
+	rewrittenRules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{PathPrefix: "services/checkout/"})
+	require.NoError(t, err)
+	require.Equal(t, expectedRules, ruleStrings(rewrittenRules))
+}
+
+func TestUnanchored(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admin\ngo.mod @org/gopher\n")
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/user\n")
+
+	expectedRules := []string{
+		"* @org/admin",
+		"go.mod @org/gopher",
+		"src @org/user",
+	}
+
+	rewrittenRules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{Unanchored: true})
+	require.NoError(t, err)
+	require.Equal(t, expectedRules, ruleStrings(rewrittenRules))
+}
+
+func TestSkipRootCodeowners(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admin\n")
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/user\n")
+
+	rewrittenRules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{SkipRootCodeowners: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{"/src @org/user"}, ruleStrings(rewrittenRules))
+}
+
+func TestRewriteCodeownersPath(t *testing.T) {
+	root := filepath.Join("repo")
+	for file, expected := range map[string]string{
+		filepath.Join(root, "CODEOWNERS"):                    "/",
+		filepath.Join(root, "src", "api", "CODEOWNERS"):      "/src/api",
+		filepath.Join(root, "docs", ".github", "CODEOWNERS"): "/docs/.github",
+	} {
+		// Patterns use slashes on every OS
+		rewritten, err := rewriteCodeownersPath(root, file, "")
+		require.NoError(t, err)
+		require.Equal(t, expected, rewritten)
+	}
+
+	rewritten, err := rewriteCodeownersPath(root, filepath.Join(root, "src", "CODEOWNERS"), "sub")
+	require.NoError(t, err)
+	require.Equal(t, "/sub/src", rewritten)
+}
+
+func TestFilterRuleKind(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admin\n*.md @org/docs\n")
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/user\nmain.go @org/gopher\n")
+
+	rewrittenRules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"* @org/admin", "/src @org/user"}, ruleStrings(FilterRuleKind(rewrittenRules, true)))
+	require.Equal(t, []string{"/*.md @org/docs", "/src/main.go @org/gopher"}, ruleStrings(FilterRuleKind(rewrittenRules, false)))
+}
+
+func TestPriority(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admin\n/security/** @org/security # codeowners: priority=10\n")
+	writeFile(t, repoPath, "security/CODEOWNERS", "@org/user\n")
+	writeFile(t, repoPath, "src/CODEOWNERS", "# codeowners: priority=-1\n@org/fallback\n")
+
+	rewrittenRules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"/src @org/fallback", "* @org/admin", "/security @org/user", "/security/** @org/security # codeowners: priority=10"}, ruleStrings(rewrittenRules))
+
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/user # codeowners: priority=high\n")
+	_, err = RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `invalid priority "high"`)
+}
+
+func TestAnnotateSource(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admin\n")
+	writeFile(t, repoPath, "src/payments/CODEOWNERS", "# Payments\n\n@org/payments # Team payments\n")
+
+	rewrittenRules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.NoError(t, err)
+
+	expectedFile := `* @org/admin # CODEOWNERS:1
+/src/payments @org/payments # Team payments # src/payments/CODEOWNERS:3
+`
+	generatedFile := GenerateCodeownersFile(rewrittenRules, GenerateOptions{NoHeader: true, AnnotateSource: true})
+	require.Equal(t, expectedFile, generatedFile)
+}
+
+func TestPreserveComments(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "CODEOWNERS", "# Default owners\n@org/admin\n")
+	writeFile(t, repoPath, "src/CODEOWNERS", "# File header\n\n# codeowners: label=src\n# Owned by the Go team,\n# ask in #go\n# codeowners-lint: disable=CO015\n*.go @org/go\n#\nmain.go @org/lead # Trailing\nREADME.md @org/docs\n")
+
+	rewrittenRules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"Owned by the Go team,", "ask in #go"}, rewrittenRules[1].Comments)
+
+	expectedFile := `# Default owners
+* @org/admin
+# Owned by the Go team,
+# ask in #go
+/src/*.go @org/go
+#
+/src/main.go @org/lead # Trailing
+/src/README.md @org/docs
+`
+	generatedFile := GenerateCodeownersFile(rewrittenRules, GenerateOptions{NoHeader: true, PreserveComments: true})
+	require.Equal(t, expectedFile, generatedFile)
+
+	generatedFile = GenerateCodeownersFile(rewrittenRules, GenerateOptions{NoHeader: true})
+	require.Equal(t, "* @org/admin\n/src/*.go @org/go\n/src/main.go @org/lead # Trailing\n/src/README.md @org/docs\n", generatedFile)
+}
+
+func TestProvenance(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admin\n")
+	writeFile(t, repoPath, "src/dir1/CODEOWNERS", "@org/dev\n")
+	writeFile(t, repoPath, "src/dir2/CODEOWNERS", "@org/dev\n# Generated code\n*.pb.go @org/api\n")
+
+	rewrittenRules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.NoError(t, err)
+	rewrittenRules = append(rewrittenRules, NeverOwnedRules([]string{"/vendor"}, Options{})...)
+
+	expectedFile := `# from CODEOWNERS
+* @org/admin
+# from src/dir1/CODEOWNERS
+/src/dir1 @org/dev
+# from src/dir2/CODEOWNERS
+/src/dir2 @org/dev
+# Generated code
+/src/dir2/*.pb.go @org/api
+/vendor # never owned, see .codeowners.yaml
+`
+	generatedFile := GenerateCodeownersFile(rewrittenRules, GenerateOptions{NoHeader: true, Provenance: true, PreserveComments: true})
+	require.Equal(t, expectedFile, generatedFile)
+
+	expectedFile = `# Owned by @org/admin
+# from CODEOWNERS
+* @org/admin
+
+# Owned by @org/dev
+# from src/dir1/CODEOWNERS
+/src/dir1 @org/dev
+# from src/dir2/CODEOWNERS
+/src/dir2 @org/dev
+`
+	generatedFile = GenerateCodeownersFile(rewrittenRules[:3], GenerateOptions{NoHeader: true, Provenance: true, Layout: LayoutOwner})
+	require.Equal(t, expectedFile, generatedFile)
+}
+
+func TestFiles(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admin\n")
+	writeFile(t, repoPath, "src/a/CODEOWNERS", "@org/a\n")
+	writeFile(t, repoPath, "src/b/CODEOWNERS", "@org/b\n")
+	writeFile(t, repoPath, "src/c/CODEOWNERS", "@org/c\n")
+
+	files := []string{"src/c/CODEOWNERS", filepath.Join(repoPath, "src/a/CODEOWNERS"), "CODEOWNERS", ".github/CODEOWNERS"}
+	rewrittenRules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{Files: files})
+	require.NoError(t, err)
+	require.Equal(t, []string{"* @org/admin", "/src/a @org/a", "/src/c @org/c"}, ruleStrings(rewrittenRules))
+
+	_, err = RewriteCodeownersRules(context.Background(), repoPath, Options{Files: []string{"src/a/README.md"}})
+	require.Error(t, err)
+
+	_, err = RewriteCodeownersRules(context.Background(), repoPath, Options{Files: []string{"../CODEOWNERS"}})
+	require.Error(t, err)
+}
+
+func TestSymlinkedCodeowners(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "shared/OWNERS", "*.go @org/go\n")
+	writeFile(t, repoPath, "services/a/main.go", "")
+	writeFile(t, repoPath, "services/b/main.go", "")
+	require.NoError(t, os.Symlink("../../shared/OWNERS", filepath.Join(repoPath, "services/a/CODEOWNERS")))
+	require.NoError(t, os.Symlink("../a/CODEOWNERS", filepath.Join(repoPath, "services/b/CODEOWNERS")))
+
+	rewrittenRules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"/services/a/*.go @org/go", "/services/b/*.go @org/go"}, ruleStrings(rewrittenRules))
+
+	// Cycles are reported instead of followed
+	require.NoError(t, os.Mkdir(filepath.Join(repoPath, "loop"), 0700))
+	require.NoError(t, os.Symlink("CODEOWNERS", filepath.Join(repoPath, "loop/CODEOWNERS")))
+	_, err = RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "symlink cycle")
+}
+
+func TestDroppedRules(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/user\nmain.go\n")
+
+	var diagnostics []string
+	opts := Options{Diagnostics: func(finding Finding) { diagnostics = append(diagnostics, finding.String()) }}
+	rewrittenRules, err := RewriteCodeownersRules(context.Background(), repoPath, opts)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/src @org/user"}, ruleStrings(rewrittenRules))
+	require.Equal(t, []string{"src/CODEOWNERS:2:1: warning: rule for main.go has no owners and is dropped [CO002 missing-owners]"}, diagnostics)
+
+	_, err = RewriteCodeownersRules(context.Background(), repoPath, Options{Strict: true})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "src/CODEOWNERS:2:1: rule for main.go has no owners")
+}
+
+func TestEscapingPatterns(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/user\n\nlib/../main.go @org/go\n../other-dir/thing @org/other\n")
+	_, err := RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "src/CODEOWNERS:4:1: pattern ../other-dir/thing points outside the dir of its CODEOWNERS file")
+
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/user\n/a/../../thing @org/other\n")
+	_, err = RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "src/CODEOWNERS:2:1: pattern /a/../../thing points outside")
+
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/user\nlib/../main.go @org/go\n")
+	rules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"/src @org/user", "/src/main.go @org/go"}, ruleStrings(rules))
+}
+
+func TestParserModes(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "src/CODEOWNERS", "# Note: not a pragma\n@org/user\nmain.go org/go # codeowners: label=go; lable=main\n")
+
+	var diagnostics []string
+	opts := Options{Diagnostics: func(finding Finding) { diagnostics = append(diagnostics, finding.String()) }}
+	rewrittenRules, err := RewriteCodeownersRules(context.Background(), repoPath, opts)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/src @org/user", "/src/main.go org/go # codeowners: label=go; lable=main"}, ruleStrings(rewrittenRules))
+	require.Equal(t, []string{
+		"src/CODEOWNERS:3:40: warning: unknown pragma lable [CO013 unknown-pragma]",
+		"src/CODEOWNERS:3:9: warning: org/go is not a valid user, team or email address [CO003 invalid-owner]",
+	}, diagnostics)
+
+	_, err = RewriteCodeownersRules(context.Background(), repoPath, Options{Strict: true})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "src/CODEOWNERS:3:40: unknown pragma lable")
+
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/user\nmain.go org/go\n")
+	_, err = RewriteCodeownersRules(context.Background(), repoPath, Options{Strict: true})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "src/CODEOWNERS:2:9: org/go is not a valid user, team or email address")
+}
+
+func TestProseComments(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "docs/CODEOWNERS", "# Section: the docs team owns this\n# Optional: ping #infra on slack\n@org/docs # Label: see wiki\n")
+
+	var diagnostics []string
+	opts := Options{Strict: true, Diagnostics: func(finding Finding) { diagnostics = append(diagnostics, finding.String()) }}
+	rewrittenRules, err := RewriteCodeownersRules(context.Background(), repoPath, opts)
+	require.NoError(t, err)
+	require.Empty(t, diagnostics)
+	require.Len(t, rewrittenRules, 1)
+	require.Nil(t, rewrittenRules[0].Section)
+	require.Nil(t, rewrittenRules[0].Labels)
+	require.Equal(t, []string{"Section: the docs team owns this", "Optional: ping #infra on slack"}, rewrittenRules[0].Comments)
+}
+
+func TestBinaryCodeowners(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/user\n\x7fELF\x02\x01\x00\n")
+	_, err := RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), filepath.Join("src", "CODEOWNERS")+":2:7: CODEOWNERS file contains NUL bytes")
+
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/user\n\n*.go @org/\xff\n")
+	_, err = RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), filepath.Join("src", "CODEOWNERS")+":3:11: CODEOWNERS file is not valid UTF-8")
+}
+
+func TestLargeCodeowners(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/user\n# "+strings.Repeat("x", DefaultMaxFileSize)+"\n")
+	_, err := RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "CODEOWNERS file src/CODEOWNERS has 1048589 bytes, more than the limit of 1048576 bytes")
+
+	rules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{MaxFileSize: -1})
+	require.NoError(t, err)
+	require.Equal(t, []string{"/src @org/user"}, ruleStrings(rules))
+
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/user\n")
+	_, err = RewriteCodeownersRules(context.Background(), repoPath, Options{MaxFileSize: 5})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "has 10 bytes, more than the limit of 5 bytes")
+}
+
+func TestParseErrorLocation(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/user\n*.go   @org/go  #  codeowners: label=go; section=Go\n")
+
+	_, err := RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.Error(t, err)
+
+	var parseErr *ParseError
+	require.True(t, errors.As(err, &parseErr))
+	require.Equal(t, "src/CODEOWNERS", parseErr.Source)
+	require.Equal(t, 2, parseErr.Line)
+	require.Equal(t, 42, parseErr.Column)
+	require.Equal(t, "src/CODEOWNERS:2:42: the section pragma can only be set for the whole file", parseErr.Error())
+}
+
+func TestCancelledWalk(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/user\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := RewriteCodeownersRules(ctx, repoPath, Options{})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestDiscoverRoot(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, ".git/HEAD", "ref: refs/heads/main\n")
+	writeFile(t, repoPath, "src/dir/CODEOWNERS", "@org/user\n")
+
+	root, err := DiscoverRoot(filepath.Join(repoPath, "src", "dir"))
+	require.NoError(t, err)
+	require.Equal(t, repoPath, root)
+
+	// Without enclosing repository the path itself is the root
+	noRepoPath := t.TempDir()
+	root, err = DiscoverRoot(noRepoPath)
+	require.NoError(t, err)
+	require.Equal(t, noRepoPath, root)
+}
+
 func writeFile(t *testing.T, root, path, content string) {
 	// Construct the abspath to the file's dir first so that we can
 	// create the parent dirs
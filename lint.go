@@ -0,0 +1,515 @@
+package codeowners
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/denormal/go-gitignore"
+)
+
+// Severity is the severity of a finding.
+type Severity string
+
+const (
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// Finding is a problem found in the nested CO files or the rules generated
+// from them.
+type Finding struct {
+	// Check is the name of the check that produced the finding.
+	Check string `json:"check"`
+
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+
+	// File is the path of the affected file relative to the root, Line its
+	// 1-based line number. Both are empty for findings about the whole repo.
+	// Column is the 1-based byte column in the line, if known.
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+
+	// Fixable is set for mechanical findings that lint --fix can fix.
+	Fixable bool `json:"fixable,omitempty"`
+
+	// Fix is the suggested edit of findings with a mechanical remediation,
+	// e.g. for editor integrations and bots.
+	Fix *Fix `json:"fix,omitempty"`
+}
+
+// Fix is a suggested edit that resolves a finding: the lines StartLine to
+// EndLine of File, both 1-based and inclusive, are replaced by Replacement,
+// which has no trailing newline. An empty Replacement deletes the lines.
+type Fix struct {
+	File        string `json:"file"`
+	StartLine   int    `json:"startLine"`
+	EndLine     int    `json:"endLine"`
+	Replacement string `json:"replacement"`
+}
+
+// ID returns the stable ID of the check of the finding, see checkIDs.
+func (f Finding) ID() string {
+	return checkID(f.Check)
+}
+
+// MarshalJSON adds the ID to the fields of the finding.
+func (f Finding) MarshalJSON() ([]byte, error) {
+	type finding Finding // Without the MarshalJSON method
+	return json.Marshal(struct {
+		ID string `json:"id,omitempty"`
+		finding
+	}{f.ID(), finding(f)})
+}
+
+func (f Finding) String() string {
+	location := ""
+	if f.File != "" {
+		location = f.File + ": "
+		if f.Line > 0 {
+			location = fmt.Sprintf("%s:%d: ", f.File, f.Line)
+		}
+		if f.Line > 0 && f.Column > 0 {
+			location = fmt.Sprintf("%s:%d:%d: ", f.File, f.Line, f.Column)
+		}
+	}
+
+	check := f.Check
+	if id := f.ID(); id != "" {
+		check = id + " " + check
+	}
+
+	return fmt.Sprintf("%s%s: %s [%s]", location, f.Severity, f.Message, check)
+}
+
+// HasErrors checks whether any of the findings is an error.
+func HasErrors(findings []Finding) bool {
+	for _, finding := range findings {
+		if finding.Severity == SeverityError {
+			return true
+		}
+	}
+
+	return false
+}
+
+var (
+	userOwnerRegexp = regexp.MustCompile(`^@[A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?$`)
+	teamOwnerRegexp = regexp.MustCompile(`^@[A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?/[A-Za-z0-9._-]+$`)
+)
+
+// isValidOwner checks whether owner is a syntactically valid GitHub user
+// (@user), team (@org/team) or email address.
+func isValidOwner(owner string) bool {
+	return userOwnerRegexp.MatchString(owner) || teamOwnerRegexp.MatchString(owner) || isEmailOwner(owner)
+}
+
+// isTeamOwner checks whether owner is a GitHub team (@org/team).
+func isTeamOwner(owner string) bool {
+	return teamOwnerRegexp.MatchString(owner)
+}
+
+// LintCodeownersFiles checks the syntax and formatting of every nested CO file
+// under root. Fixable findings can be fixed with FixCodeownersFiles.
+func LintCodeownersFiles(ctx context.Context, root string, cfg LintConfig) ([]Finding, error) {
+	files, err := lintCodeownersFiles(ctx, root, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	for _, file := range files {
+		findings = append(findings, file.findings...)
+	}
+
+	return findings, nil
+}
+
+// lintCodeownersRule checks the syntax of a single rule of a nested CO file.
+func lintCodeownersRule(source string, line int, rule string) []Finding {
+	tokens, _ := tokenizeCodeownersRule(rule)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	columns := tokenColumns(rule)
+	first := 0
+	if !isDirRule(tokens) {
+		first = 1
+		if len(tokens) == 1 {
+			return []Finding{{
+				Check:    "missing-owners",
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("rule for %s has no owners and is dropped", tokens[0]),
+				File:     source,
+				Line:     line,
+				Column:   columns[0],
+			}}
+		}
+	}
+
+	var findings []Finding
+	if first == 1 && escapesDir(tokens[0]) {
+		findings = append(findings, Finding{
+			Check:    "escaping-pattern",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("pattern %s points outside the dir of its CODEOWNERS file, which may only declare ownership within its subtree", tokens[0]),
+			File:     source,
+			Line:     line,
+			Column:   columns[0],
+		})
+	}
+
+	if first == 1 {
+		if problem := unsupportedPatternSyntax(tokens[0]); problem != "" {
+			findings = append(findings, Finding{
+				Check:    "unsupported-pattern",
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("pattern %s uses %s, which GitHub doesn't support", tokens[0], problem),
+				File:     source,
+				Line:     line,
+				Column:   columns[0],
+			})
+		}
+	}
+
+	for i := first; i < len(tokens); i++ {
+		if !isValidOwner(tokens[i]) {
+			findings = append(findings, Finding{
+				Check:    "invalid-owner",
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("%s is not a valid user, team or email address", tokens[i]),
+				File:     source,
+				Line:     line,
+				Column:   columns[i],
+			})
+		}
+	}
+
+	return findings
+}
+
+// unsupportedPatternSyntax returns the .gitignore syntax of a pattern that
+// GitHub doesn't support, "!" negation or "[ ]" character ranges, and "" if
+// there is none. Escaped chars are literals.
+func unsupportedPatternSyntax(pattern string) string {
+	if strings.HasPrefix(pattern, "!") {
+		return "! negation"
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '[':
+			if strings.Contains(pattern[i+1:], "]") {
+				return "a [ ] character range"
+			}
+		}
+	}
+
+	return ""
+}
+
+// escapesDir checks whether a pattern of a CO file points outside of its dir,
+// e.g. "../other/thing" or "/a/../../thing".
+func escapesDir(pattern string) bool {
+	cleaned := path.Clean(strings.TrimPrefix(pattern, "/"))
+	return cleaned == ".." || strings.HasPrefix(cleaned, "../")
+}
+
+// FindStaleRules reports rules whose patterns don't match any of the files.
+// The findings of rules of nested CO files suggest deleting the rule.
+func FindStaleRules(rules []Rule, files []string) []Finding {
+	fileSegments := make([][]string, len(files))
+	for i, file := range files {
+		fileSegments[i] = pathSegments(file)
+	}
+
+	var findings []Finding
+	for _, rule := range rules {
+		pattern := compilePattern(rule.Pattern)
+
+		matched := false
+		for _, segments := range fileSegments {
+			if pattern.match(segments) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			finding := Finding{
+				Check:    "stale-pattern",
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("pattern %s doesn't match any file", rule.Pattern),
+				File:     rule.Source,
+				Line:     rule.Line,
+			}
+
+			// Rules of the teams manifest can't be removed by deleting a line
+			if path.Base(rule.Source) == codeownersFileName && rule.Line > 0 {
+				finding.Fix = &Fix{File: rule.Source, StartLine: rule.Line, EndLine: rule.Line}
+			}
+
+			findings = append(findings, finding)
+		}
+	}
+
+	return findings
+}
+
+// CheckPolicies checks the rules against the configured policies, taking the
+// overrides for their labels into account.
+func CheckPolicies(rules []Rule, config PolicyConfig) []Finding {
+	var findings []Finding
+	for _, rule := range rules {
+		policy := config.forRule(rule)
+		if len(rule.Owners) < policy.MinOwners {
+			findings = append(findings, Finding{
+				Check:    "min-owners",
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("rule for %s has %s, at least %d are required", rule.Pattern, Pluralize(len(rule.Owners), "owner"), policy.MinOwners),
+				File:     rule.Source,
+				Line:     rule.Line,
+			})
+		}
+
+		if policy.RequireTeams {
+			for _, owner := range rule.Owners {
+				if !isTeamOwner(owner) {
+					findings = append(findings, Finding{
+						Check:    "require-teams",
+						Severity: SeverityError,
+						Message:  fmt.Sprintf("owner %s of %s is not a team", owner, rule.Pattern),
+						File:     rule.Source,
+						Line:     rule.Line,
+					})
+				}
+			}
+		}
+	}
+
+	return findings
+}
+
+// CheckRequiredCodeowners reports the dirs matching the configured patterns
+// that contain no CO file, neither directly nor in a subdir. The patterns are
+// matched against whole dir paths relative to the root, e.g. "services/*".
+// Wildcards don't match hidden dirs. The files are the files of the repo, dirs
+// without files are ignored.
+func CheckRequiredCodeowners(files []string, policy PolicyConfig) []Finding {
+	patterns := policy.requiredCodeownersDirs()
+	if patterns == nil {
+		return nil
+	}
+
+	owned := map[string]bool{}
+	for _, file := range files {
+		if !isNestedCodeownersPath(file) {
+			continue
+		}
+
+		for dir := path.Dir(file); dir != "."; dir = path.Dir(dir) {
+			owned[dir] = true
+		}
+	}
+
+	var findings []Finding
+	for _, dir := range listDirs(files) {
+		if owned[dir] || !matchesAnyDirPattern(dir, patterns) {
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Check:    "require-codeowners",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("dir %s contains no %s file", dir, codeownersFileName),
+			File:     dir,
+		})
+	}
+
+	return findings
+}
+
+// matchesAnyDirPattern checks whether dir matches one of the patterns segment
+// by segment.
+func matchesAnyDirPattern(dir string, patterns []string) bool {
+	dirSegments := strings.Split(dir, "/")
+
+	for _, pattern := range patterns {
+		patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+		if len(patternSegments) != len(dirSegments) {
+			continue
+		}
+
+		matched := true
+		for i, segment := range patternSegments {
+			hidden := strings.HasPrefix(dirSegments[i], ".") && !strings.HasPrefix(segment, ".")
+			if hidden || !matchSegment(segment, dirSegments[i]) {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckExpiry reports rules whose expiry date has passed as errors and rules
+// that expire within the next warnDays days as warnings. A rule is valid until
+// the end of its expiry date (UTC).
+func CheckExpiry(rules []Rule, now time.Time, warnDays int) []Finding {
+	today := now.UTC().Truncate(24 * time.Hour)
+
+	var findings []Finding
+	for _, rule := range rules {
+		if rule.Expires.IsZero() {
+			continue
+		}
+
+		date := rule.Expires.Format(expiryDateLayout)
+		daysLeft := int(rule.Expires.Sub(today).Hours() / 24)
+		switch {
+		case daysLeft < 0:
+			findings = append(findings, Finding{
+				Check:    "expired-rule",
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("ownership of %s expired on %s", rule.Pattern, date),
+				File:     rule.Source,
+				Line:     rule.Line,
+			})
+		case daysLeft <= warnDays:
+			findings = append(findings, Finding{
+				Check:    "expiring-rule",
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("ownership of %s expires on %s (in %s)", rule.Pattern, date, Pluralize(daysLeft, "day")),
+				File:     rule.Source,
+				Line:     rule.Line,
+			})
+		}
+	}
+
+	return findings
+}
+
+// FindIgnoredCodeownersFiles reports CO files in dirs ignored by .gitignore
+// files, which are skipped when generating the rules, together with the
+// ignore rule responsible.
+func FindIgnoredCodeownersFiles(ctx context.Context, root string) ([]Finding, error) {
+	ignore := initGitignore(root)
+	if ignore == nil {
+		return nil, nil
+	}
+
+	var findings []Finding
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if !d.IsDir() || path == root {
+			return nil
+		}
+		if d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		match := ignore.Match(path)
+		if match == nil || !match.Ignore() {
+			return nil
+		}
+
+		dir, err := relativeSourcePath(root, path)
+		if err != nil {
+			return err
+		}
+
+		// The whole dir is skipped, so report every CO file below it
+		err = filepath.WalkDir(path, func(coPath string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !isCodeownersFile(d) {
+				return nil
+			}
+
+			source, err := relativeSourcePath(root, coPath)
+			if err != nil {
+				return err
+			}
+
+			findings = append(findings, Finding{
+				Check:    "ignored-codeowners",
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("CODEOWNERS file is skipped because %s is ignored by %q in %s", dir, match.String(), ignoreRuleLocation(root, path, match)),
+				File:     source,
+			})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return filepath.SkipDir
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("error while searching ignored dirs for CODEOWNERS files: %w", err)
+	}
+
+	return findings, nil
+}
+
+// ignoreRuleLocation returns the location (file:line) of the ignore rule that
+// ignores the dir path. The .gitignore files are searched from the dir of path
+// up to the root, where the first one with a matching rule wins like in git,
+// followed by .git/info/exclude.
+func ignoreRuleLocation(root, path string, match gitignore.Match) string {
+	file := ""
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		candidate := filepath.Join(dir, gitignore.File)
+		if ignore, err := gitignore.NewFromFile(candidate); err == nil && ignore.Absolute(path, true) != nil {
+			file = candidate
+			break
+		}
+
+		if dir == root || dir == filepath.Dir(dir) {
+			file = filepath.Join(root, ".git", "info", "exclude")
+			break
+		}
+	}
+
+	if rel, err := filepath.Rel(root, file); err == nil {
+		file = filepath.ToSlash(rel)
+	}
+
+	return fmt.Sprintf("%s:%d", file, match.Position().Line)
+}
+
+// SortFindings orders findings by file and line, findings about the whole
+// repo come first.
+func SortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+}
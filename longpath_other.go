@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package codeowners
+
+// longPath returns path as is, only Windows limits the length of paths.
+func longPath(path string) string {
+	return path
+}
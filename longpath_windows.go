@@ -0,0 +1,30 @@
+package codeowners
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// longPathPrefix makes the Windows file APIs accept paths longer than
+// MAX_PATH, which deep monorepos easily exceed. Paths with the prefix aren't
+// normalized by Windows, so they have to be clean and absolute.
+const longPathPrefix = `\\?\`
+
+// maxShortPathLength is the longest dir path the Windows APIs accept without
+// prefix, MAX_PATH minus room for a 8.3 file name.
+const maxShortPathLength = 248
+
+// longPath prefixes long absolute paths with longPathPrefix, other paths are
+// returned as is.
+func longPath(path string) string {
+	if len(path) < maxShortPathLength || strings.HasPrefix(path, longPathPrefix) || !filepath.IsAbs(path) {
+		return path
+	}
+
+	path = filepath.Clean(path)
+	if strings.HasPrefix(path, `\\`) { // UNC path, \\server\share\...
+		return longPathPrefix + `UNC\` + path[2:]
+	}
+
+	return longPathPrefix + path
+}
@@ -0,0 +1,30 @@
+package codeowners
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLongPath(t *testing.T) {
+	require.Equal(t, `C:\repo\src`, longPath(`C:\repo\src`))
+
+	long := `C:\repo\` + strings.Repeat(`very-long-dir-name\`, 20) + "CODEOWNERS"
+	require.Equal(t, `\\?\`+long, longPath(long))
+	require.Equal(t, `\\?\`+long, longPath(`\\?\`+long))
+	require.Equal(t, `\\?\UNC\server\share\`+long[3:], longPath(`\\server\share\`+long[3:]))
+
+	// The walk reaches CODEOWNERS files beyond MAX_PATH
+	repoPath := t.TempDir()
+	dir := filepath.Join(repoPath, strings.Repeat("very-long-dir-name", 4), strings.Repeat("another-long-dir-name", 4), strings.Repeat("yet-another-long-dir", 4))
+	require.NoError(t, os.MkdirAll(longPath(dir), 0755))
+	require.NoError(t, os.WriteFile(longPath(filepath.Join(dir, "CODEOWNERS")), []byte("@org/deep\n"), 0644))
+
+	rules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+}
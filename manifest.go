@@ -0,0 +1,240 @@
+package codeowners
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TeamsManifestFileName is the conventional name of the teams manifest in the
+// repo root.
+const TeamsManifestFileName = "teams.yaml"
+
+// TeamsManifest declares ownership centrally by mapping teams to the dirs
+// they own, e.g.
+//
+//	"@org/payments":
+//	  - services/payments
+//	  - libs/billing
+//	"@org/platform":
+//	  - infra/*
+//
+// Dirs are relative to the root and may contain wildcards. The rules of the
+// manifest take effect as if they were declared in a CO file in their dir
+// that precedes the nested CO file of the dir, so nested CO files override
+// the manifest in their dir and below while the manifest overrides the CO
+// files of the parent dirs.
+type TeamsManifest struct {
+	// Source is the path of the manifest relative to the root.
+	Source string
+
+	// Entries are the owned dirs in BFS order, a dir listed for several
+	// teams has one entry with all of them.
+	Entries []ManifestEntry
+}
+
+// ManifestEntry is a dir of the manifest together with its owners.
+type ManifestEntry struct {
+	Dir    string
+	Owners []string
+
+	// Line is the 1-based line of the first mention of the dir.
+	Line int
+}
+
+// LoadTeamsManifest reads the manifest at source, which is relative to root.
+func LoadTeamsManifest(root, source string) (TeamsManifest, error) {
+	manifest := TeamsManifest{Source: filepath.ToSlash(source)}
+
+	content, err := os.ReadFile(longPath(filepath.Join(root, source)))
+	if err != nil {
+		return manifest, fmt.Errorf("can't read teams manifest %s: %w", source, err)
+	}
+
+	var doc yaml.Node
+	err = yaml.Unmarshal(content, &doc)
+	if err != nil {
+		return manifest, fmt.Errorf("can't parse teams manifest %s: %w", source, err)
+	}
+	if len(doc.Content) == 0 {
+		return manifest, nil
+	}
+
+	teams := doc.Content[0]
+	if teams.Kind != yaml.MappingNode {
+		return manifest, fmt.Errorf("%s:%d: teams manifest must map teams to lists of dirs", manifest.Source, teams.Line)
+	}
+
+	entries := map[string]*ManifestEntry{}
+	var dirs []string
+	for i := 0; i+1 < len(teams.Content); i += 2 {
+		team, teamDirs := teams.Content[i], teams.Content[i+1]
+		if !isValidOwner(team.Value) {
+			return manifest, fmt.Errorf("%s:%d: %s is not a valid user, team or email address", manifest.Source, team.Line, team.Value)
+		}
+		if teamDirs.Kind != yaml.SequenceNode {
+			return manifest, fmt.Errorf("%s:%d: dirs of %s must be a list", manifest.Source, teamDirs.Line, team.Value)
+		}
+
+		for _, node := range teamDirs.Content {
+			dir, err := cleanManifestDir(node.Value)
+			if err != nil {
+				return manifest, fmt.Errorf("%s:%d: %w", manifest.Source, node.Line, err)
+			}
+
+			entry, ok := entries[dir]
+			if !ok {
+				entry = &ManifestEntry{Dir: dir, Line: node.Line}
+				entries[dir] = entry
+				dirs = append(dirs, dir)
+			}
+			if !ContainsString(entry.Owners, team.Value) {
+				entry.Owners = append(entry.Owners, team.Value)
+			}
+		}
+	}
+
+	sort.SliceStable(dirs, func(i, j int) bool {
+		di, dj := dirDepth(dirs[i]), dirDepth(dirs[j])
+		if di != dj {
+			return di < dj
+		}
+		return dirs[i] < dirs[j]
+	})
+	for _, dir := range dirs {
+		manifest.Entries = append(manifest.Entries, *entries[dir])
+	}
+
+	return manifest, nil
+}
+
+// cleanManifestDir validates and normalizes a dir of the manifest, the root
+// is ".".
+func cleanManifestDir(dir string) (string, error) {
+	cleaned := path.Clean("/" + strings.TrimSpace(dir))
+	if strings.TrimSpace(dir) == "" || strings.HasPrefix(path.Clean(dir), "..") {
+		return "", fmt.Errorf("invalid dir %q", dir)
+	}
+	if cleaned == "/" {
+		return ".", nil
+	}
+
+	return strings.TrimPrefix(cleaned, "/"), nil
+}
+
+// Rules converts the manifest to rules, rewritten like the rules of nested CO
+// files. Dirs with wildcards become dir-only patterns so that they don't match
+// files next to the owned dirs.
+func (m TeamsManifest) Rules(opts Options) []Rule {
+	rules := make([]Rule, 0, len(m.Entries))
+	for _, entry := range m.Entries {
+		pattern := path.Join("/", opts.PathPrefix, entry.Dir)
+		if pattern == "/" {
+			pattern = "*"
+		} else if strings.ContainsAny(entry.Dir, "*?[") {
+			pattern += "/"
+		}
+		if opts.Unanchored {
+			pattern = strings.TrimPrefix(pattern, "/")
+		}
+
+		rules = append(rules, Rule{Pattern: pattern, Owners: entry.Owners, Dir: true, Source: m.Source, Line: entry.Line})
+	}
+
+	return rules
+}
+
+// mergeDirRules inserts rules of other ownership sources than the nested CO
+// files, e.g. the teams manifest, among the rules of the nested CO files,
+// which are in BFS order, so that every inserted rule precedes the rules of
+// the nested CO files at the same or a deeper level. dirs are the slash
+// separated dirs of the inserted rules relative to the root, both are sorted
+// by depth.
+func mergeDirRules(dirs []string, rules, nested []Rule) []Rule {
+	merged := make([]Rule, 0, len(rules)+len(nested))
+
+	next := 0
+	for _, rule := range nested {
+		depth := dirDepth(path.Dir(rule.Source))
+		for next < len(rules) && dirDepth(dirs[next]) <= depth {
+			merged = append(merged, rules[next])
+			next++
+		}
+
+		merged = append(merged, rule)
+	}
+
+	return append(merged, rules[next:]...)
+}
+
+// dirDepth returns the number of segments of a slash separated dir relative
+// to the root, which is "." and has depth 0.
+func dirDepth(dir string) int {
+	if dir == "." || dir == "" {
+		return 0
+	}
+
+	return strings.Count(dir, "/") + 1
+}
+
+// ScaffoldCodeownersFiles creates a nested CO file with the owners of the
+// manifest for every dir without wildcards that has no CO file yet. It
+// returns the paths of the created files relative to the root. With dryRun
+// the files are only reported.
+func ScaffoldCodeownersFiles(root string, manifest TeamsManifest, dryRun bool) ([]string, error) {
+	var created []string
+	for _, entry := range manifest.Entries {
+		if strings.ContainsAny(entry.Dir, "*?[") {
+			continue
+		}
+
+		file := path.Join(entry.Dir, codeownersFileName)
+		if isGeneratedFile(file) {
+			continue
+		}
+
+		absPath := filepath.Join(root, filepath.FromSlash(file))
+		if _, err := os.Lstat(absPath); err == nil {
+			continue
+		}
+
+		created = append(created, file)
+		if dryRun {
+			continue
+		}
+
+		content := fmt.Sprintf("# Scaffolded from %s\n%s\n", manifest.Source, strings.Join(entry.Owners, " "))
+		err := WriteFileAtomic(absPath, content)
+		if err != nil {
+			return created, fmt.Errorf("can't scaffold %s: %w", file, err)
+		}
+	}
+
+	return created, nil
+}
+
+// sortByDirDepth stably sorts the rules by the depth of their dirs, dirs[i]
+// is the slash separated dir of rules[i] and is sorted along.
+func sortByDirDepth(dirs []string, rules []Rule) {
+	indexes := make([]int, len(rules))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return dirDepth(dirs[indexes[i]]) < dirDepth(dirs[indexes[j]])
+	})
+
+	sortedDirs := make([]string, len(dirs))
+	sortedRules := make([]Rule, len(rules))
+	for i, index := range indexes {
+		sortedDirs[i], sortedRules[i] = dirs[index], rules[index]
+	}
+
+	copy(dirs, sortedDirs)
+	copy(rules, sortedRules)
+}
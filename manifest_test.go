@@ -0,0 +1,69 @@
+package codeowners
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamsManifest(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "teams.yaml", `"@org/payments":
+  - services/payments
+  - libs/billing/
+"@org/platform":
+  - infra/*
+  - libs/billing
+"@org/admin":
+  - /
+`)
+	writeFile(t, repoPath, "CODEOWNERS", "@org/legacy\n")
+	writeFile(t, repoPath, "services/CODEOWNERS", "@org/services\n")
+	writeFile(t, repoPath, "services/payments/CODEOWNERS", "*.sql @org/dba\n")
+
+	rules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{TeamsManifest: "teams.yaml"})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"* @org/admin",
+		"* @org/legacy",
+		"/services @org/services",
+		"/infra/*/ @org/platform",
+		"/libs/billing @org/payments @org/platform",
+		"/services/payments @org/payments",
+		"/services/payments/*.sql @org/dba",
+	}, ruleStrings(rules))
+	require.Equal(t, "teams.yaml:3", rules[4].Location())
+
+	writeFile(t, repoPath, "teams.yaml", "\"@org/payments\":\n  - ../outside\n")
+	_, err = RewriteCodeownersRules(context.Background(), repoPath, Options{TeamsManifest: "teams.yaml"})
+	require.EqualError(t, err, `teams.yaml:2: invalid dir "../outside"`)
+
+	writeFile(t, repoPath, "teams.yaml", "not_an_owner:\n  - docs\n")
+	_, err = RewriteCodeownersRules(context.Background(), repoPath, Options{TeamsManifest: "teams.yaml"})
+	require.EqualError(t, err, "teams.yaml:1: not_an_owner is not a valid user, team or email address")
+}
+
+func TestScaffoldCodeownersFiles(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "teams.yaml", "\"@org/payments\":\n  - services/payments\n  - libs/billing\n\"@org/platform\":\n  - infra/*\n")
+	writeFile(t, repoPath, "libs/billing/CODEOWNERS", "@org/billing\n")
+
+	manifest, err := LoadTeamsManifest(repoPath, "teams.yaml")
+	require.NoError(t, err)
+
+	created, err := ScaffoldCodeownersFiles(repoPath, manifest, true)
+	require.NoError(t, err)
+	require.Equal(t, []string{"services/payments/CODEOWNERS"}, created)
+	require.NoFileExists(t, filepath.Join(repoPath, "services/payments/CODEOWNERS"))
+
+	_, err = ScaffoldCodeownersFiles(repoPath, manifest, false)
+	require.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(repoPath, "services/payments/CODEOWNERS"))
+	require.NoError(t, err)
+	require.Equal(t, "# Scaffolded from teams.yaml\n@org/payments\n", string(content))
+}
@@ -0,0 +1,304 @@
+package codeowners
+
+import (
+	"sort"
+	"strings"
+)
+
+// Matcher resolves the owners of paths using GitHub's CODEOWNERS semantics:
+// the last rule whose pattern matches a path wins.
+type Matcher struct {
+	rules    []Rule
+	patterns []pattern
+
+	// index holds the rules by the literal leading segments of their
+	// patterns, so that only the rules sharing a prefix with a path are
+	// evaluated.
+	index *prefixNode
+}
+
+// NewMatcher compiles the patterns of the rewritten rules for matching.
+func NewMatcher(rules []Rule) *Matcher {
+	patterns := make([]pattern, len(rules))
+	index := &prefixNode{}
+	for i, rule := range rules {
+		patterns[i] = compilePattern(rule.Pattern)
+		index.insert(patterns[i].literalPrefix(), i)
+	}
+
+	return &Matcher{rules: rules, patterns: patterns, index: index}
+}
+
+// prefixNode is a node of a tree of path segments. Its rules are the indexes
+// of the rules whose literal prefix ends at the node, in ascending order.
+type prefixNode struct {
+	children map[string]*prefixNode
+	rules    []int
+}
+
+// insert adds rule i with the given literal prefix.
+func (n *prefixNode) insert(prefix []string, i int) {
+	for _, segment := range prefix {
+		child, ok := n.children[segment]
+		if !ok {
+			if n.children == nil {
+				n.children = map[string]*prefixNode{}
+			}
+			child = &prefixNode{}
+			n.children[segment] = child
+		}
+		n = child
+	}
+
+	n.rules = append(n.rules, i)
+}
+
+// candidates returns the indexes of the rules whose literal prefix is a
+// prefix of the path segments, in descending order.
+func (n *prefixNode) candidates(segments []string) []int {
+	candidates := append([]int{}, n.rules...)
+	for _, segment := range segments {
+		n = n.children[segment]
+		if n == nil {
+			break
+		}
+		candidates = append(candidates, n.rules...)
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(candidates)))
+	return candidates
+}
+
+// Match returns the rule that determines the owners of path, which is
+// relative to the root (a leading "/" or "./" is ignored). If no rule matches,
+// false is returned.
+func (m *Matcher) Match(path string) (Rule, bool) {
+	i := m.matchIndex(path)
+	if i < 0 {
+		return Rule{}, false
+	}
+
+	return m.rules[i], true
+}
+
+// MatchDir is Match for a dir. Patterns ending with "/" match the dir itself,
+// while an anchored pattern ending with "*", e.g. "/docs/*", only matches the
+// files of a dir, not its subdirs.
+func (m *Matcher) MatchDir(path string) (Rule, bool) {
+	i := m.matchDirIndex(path, true)
+	if i < 0 {
+		return Rule{}, false
+	}
+
+	return m.rules[i], true
+}
+
+// matchIndex returns the index of the rule that determines the owners of
+// path, -1 if no rule matches.
+func (m *Matcher) matchIndex(path string) int {
+	return m.matchDirIndex(path, false)
+}
+
+// matchDirIndex is matchIndex for a path that is a dir if dir is set.
+func (m *Matcher) matchDirIndex(path string, dir bool) int {
+	segments := pathSegments(path)
+
+	for _, i := range m.index.candidates(segments) {
+		if m.patterns[i].matchPath(segments, dir) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// pattern is a compiled CODEOWNERS pattern.
+type pattern struct {
+	segments []string
+
+	// anchored patterns only match relative to the root, i.e. they start
+	// with "/" or contain a "/" before their last char.
+	anchored bool
+
+	// dirOnly patterns end with "/" and only match contents of dirs.
+	dirOnly bool
+}
+
+// compilePattern parses a CODEOWNERS pattern. The syntax follows .gitignore
+// files, without negation and character ranges which GitHub doesn't support.
+func compilePattern(p string) pattern {
+	dirOnly := strings.HasSuffix(p, "/")
+	trimmed := strings.TrimSuffix(p, "/")
+	anchored := strings.Contains(trimmed, "/")
+	trimmed = strings.TrimPrefix(trimmed, "/")
+
+	var segments []string
+	if trimmed != "" {
+		segments = strings.Split(trimmed, "/")
+	}
+
+	return pattern{segments: segments, anchored: anchored, dirOnly: dirOnly}
+}
+
+// literalPrefix returns the leading segments of an anchored pattern without
+// wildcards, which every matching path starts with. Unanchored patterns have
+// no literal prefix.
+func (p pattern) literalPrefix() []string {
+	if !p.anchored {
+		return nil
+	}
+
+	for i, segment := range p.segments {
+		if strings.ContainsAny(segment, `*?\`) {
+			return p.segments[:i]
+		}
+	}
+
+	return p.segments
+}
+
+// match checks whether the pattern matches a path, given as its segments. A
+// pattern matches a path if it matches the path itself or one of its parent
+// dirs, except that a trailing "*" only matches direct children of a dir, as
+// in "/docs/*".
+func (p pattern) match(path []string) bool {
+	return p.matchPath(path, false)
+}
+
+// matchPath is match for a path that is a dir if dir is set: dir-only
+// patterns match the dir itself, anchored patterns with a trailing "*" don't
+// since they only own the files of a dir.
+func (p pattern) matchPath(path []string, dir bool) bool {
+	if len(p.segments) == 0 {
+		return false
+	}
+
+	starts := 1
+	if !p.anchored {
+		starts = len(path)
+	}
+
+	matchesNested := p.segments[len(p.segments)-1] != "*"
+
+	for start := 0; start < starts; start++ {
+		matched := false
+		matchSegments(p.segments, path, start, func(end int) bool {
+			switch {
+			case end == len(path) && dir:
+				matched = p.dirOnly || matchesNested || !p.anchored
+			case end == len(path):
+				matched = !p.dirOnly
+			case end < len(path):
+				matched = matchesNested
+			}
+			return matched
+		})
+
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchSegments calls found with every end index such that the pattern
+// segments match path[start:end]. It stops as soon as found returns true and
+// reports whether that happened.
+func matchSegments(segments, path []string, start int, found func(end int) bool) bool {
+	if len(segments) == 0 {
+		return found(start)
+	}
+
+	segment := segments[0]
+
+	if segment == "**" {
+		// A trailing "**" matches everything inside a dir, but not the dir itself
+		minLen := 0
+		if len(segments) == 1 {
+			minLen = 1
+		}
+
+		for end := start + minLen; end <= len(path); end++ {
+			if matchSegments(segments[1:], path, end, found) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if start < len(path) && matchSegment(segment, path[start]) {
+		return matchSegments(segments[1:], path, start+1, found)
+	}
+
+	return false
+}
+
+// matchSegment matches a single path segment against a pattern segment with
+// the wildcards "*" (any sequence of chars) and "?" (a single char). A
+// backslash escapes the next char.
+func matchSegment(segment, name string) bool {
+	if segment == "" {
+		return name == ""
+	}
+
+	switch segment[0] {
+	case '*':
+		for i := 0; i <= len(name); i++ {
+			if matchSegment(segment[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	case '?':
+		return name != "" && matchSegment(segment[1:], name[1:])
+	case '\\':
+		if len(segment) > 1 {
+			segment = segment[1:]
+		}
+	}
+
+	return name != "" && name[0] == segment[0] && matchSegment(segment[1:], name[1:])
+}
+
+// pathSegments splits a path relative to the root into its segments.
+func pathSegments(path string) []string {
+	path = strings.TrimPrefix(path, "./")
+	path = strings.Trim(path, "/")
+	if path == "" || path == "." {
+		return nil
+	}
+
+	return strings.Split(path, "/")
+}
+
+// QueryResult is the JSON representation of the owners of a path.
+type QueryResult struct {
+	Path   string   `json:"path"`
+	Dir    bool     `json:"dir,omitempty"`
+	Owners []string `json:"owners"`
+	Rule   string   `json:"rule,omitempty"`
+	Source string   `json:"source,omitempty"`
+	Labels []string `json:"labels,omitempty"`
+}
+
+// QueryOwners resolves the owners of path, unowned paths have no owners. A
+// path ending with "/" is resolved as dir, see MatchDir.
+func QueryOwners(matcher *Matcher, path string) QueryResult {
+	result := QueryResult{Path: path, Dir: strings.HasSuffix(path, "/"), Owners: []string{}}
+
+	match := matcher.Match
+	if result.Dir {
+		match = matcher.MatchDir
+	}
+
+	if rule, ok := match(path); ok {
+		result.Owners = append(result.Owners, rule.Owners...) // Not null for rules without owners
+		result.Rule = rule.String()
+		result.Source = rule.Location()
+		result.Labels = rule.Labels
+	}
+
+	return result
+}
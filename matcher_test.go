@@ -0,0 +1,130 @@
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatternMatch(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"*", "main.go", true},
+		{"*", "src/dir/main.go", true},
+		{"*.js", "app.js", true},
+		{"*.js", "src/web/app.js", true},
+		{"*.js", "src/web/app.ts", false},
+		{"/src/dir1", "src/dir1/main.go", true},
+		{"/src/dir1", "src/dir1/nested/main.go", true},
+		{"/src/dir1", "src/dir10/main.go", false},
+		{"/src/dir1", "other/src/dir1/main.go", false},
+		{"/go.mod", "go.mod", true},
+		{"/go.mod", "src/go.mod", false},
+		{"go.mod", "src/go.mod", true},
+		{"/src/dir2/*.js", "src/dir2/app.js", true},
+		{"/src/dir2/*.js", "src/dir2/nested/app.js", false},
+		{"/docs/*", "docs/getting-started.md", true},
+		{"/docs/*", "docs/build-app/troubleshooting.md", false},
+		{"apps/", "apps/main.go", true},
+		{"apps/", "src/apps/main.go", true},
+		{"apps/", "apps", false},
+		{"**/logs", "logs/a.log", true},
+		{"**/logs", "build/logs/a.log", true},
+		{"/build/logs/", "build/logs/a.log", true},
+		{"/build/logs/", "src/build/logs/a.log", false},
+		{"/src/**/test", "src/test/a.go", true},
+		{"/src/**/test", "src/a/b/test/a.go", true},
+		{"/src/**", "src/a/b.go", true},
+		{"/src/**", "src", false},
+		{"/src/ma?n.go", "src/main.go", true},
+		{"/src/ma?n.go", "src/maiin.go", false},
+	}
+
+	for _, c := range cases {
+		matched := compilePattern(c.pattern).match(pathSegments(c.path))
+		require.Equal(t, c.match, matched, "pattern %s, path %s", c.pattern, c.path)
+	}
+}
+
+func TestMatcherLastMatchWins(t *testing.T) {
+	rules := []Rule{
+		{Pattern: "*", Owners: []string{"@org/admin"}},
+		{Pattern: "/src", Owners: []string{"@org/user"}},
+		{Pattern: "/src/main.go", Owners: []string{"@org/gopher"}},
+	}
+	matcher := NewMatcher(rules)
+
+	rule, ok := matcher.Match("README.md")
+	require.True(t, ok)
+	require.Equal(t, []string{"@org/admin"}, rule.Owners)
+
+	rule, ok = matcher.Match("/src/lib.go")
+	require.True(t, ok)
+	require.Equal(t, []string{"@org/user"}, rule.Owners)
+
+	rule, ok = matcher.Match("./src/main.go")
+	require.True(t, ok)
+	require.Equal(t, []string{"@org/gopher"}, rule.Owners)
+
+	_, ok = NewMatcher(rules[1:]).Match("README.md")
+	require.False(t, ok)
+}
+
+func TestMatchDir(t *testing.T) {
+	rules := []Rule{
+		{Pattern: "*", Owners: []string{"@org/admin"}},
+		{Pattern: "/src", Owners: []string{"@org/src"}},
+		{Pattern: "/src/*", Owners: []string{"@org/files"}},
+		{Pattern: "build/", Owners: []string{"@org/build"}},
+		{Pattern: "/src/**/*.go", Owners: []string{"@org/go"}},
+	}
+	matcher := NewMatcher(rules)
+
+	for path, owner := range map[string]string{
+		"docs":        "@org/admin",
+		"src":         "@org/src",
+		"src/lib":     "@org/src", // "/src/*" only owns the files of src
+		"src/build":   "@org/build",
+		"src/x/y.txt": "@org/src",
+	} {
+		rule, ok := matcher.MatchDir(path)
+		require.True(t, ok, path)
+		require.Equal(t, []string{owner}, rule.Owners, path)
+	}
+
+	rule, _ := matcher.Match("src/build")
+	require.Equal(t, []string{"@org/files"}, rule.Owners)
+
+	require.Equal(t, QueryResult{Path: "src/lib/", Dir: true, Owners: []string{"@org/src"}, Rule: "/src @org/src", Source: ":0"}, QueryOwners(matcher, "src/lib/"))
+	require.Equal(t, []string{"@org/files"}, QueryOwners(matcher, "src/lib").Owners)
+}
+
+func TestMatcherIndex(t *testing.T) {
+	rules := []Rule{
+		{Pattern: "*", Owners: []string{"@org/admin"}},
+		{Pattern: "/src/**/*.go", Owners: []string{"@org/go"}},
+		{Pattern: "/src/api", Owners: []string{"@org/api"}},
+		{Pattern: "*.md", Owners: []string{"@org/docs"}},
+		{Pattern: "/src/api/v?/", Owners: []string{"@org/v1"}},
+		{Pattern: "/src/api/gen.go", Owners: []string{"@org/gen"}},
+		{Pattern: "/docs/\\*", Owners: []string{"@org/star"}},
+		{Pattern: "/**/testdata", Owners: []string{"@org/test"}},
+	}
+	matcher := NewMatcher(rules)
+
+	paths := []string{"README.md", "src/main.go", "src/api/api.go", "src/api/README.md", "src/api/v1/api.go", "src/api/gen.go", "docs/*", "docs/x", "src/api/testdata/a.go", "other/x"}
+	for _, path := range paths {
+		// The index must give the same result as evaluating all rules
+		expected := -1
+		for i := len(rules) - 1; i >= 0; i-- {
+			if compilePattern(rules[i].Pattern).match(pathSegments(path)) {
+				expected = i
+				break
+			}
+		}
+		require.Equal(t, expected, matcher.matchIndex(path), path)
+	}
+}
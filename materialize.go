@@ -0,0 +1,55 @@
+package codeowners
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// MaterializeInheritedRules adds an explicit rule for every dir without nested
+// CO file that has an owner, assigning it the owners it inherits from the rule
+// of its nearest owned ancestor. The files are the files of the repo relative
+// to the root, opts must be the options the rules were rewritten with.
+//
+// The inherited rules are inserted directly after the rule they inherit from,
+// so that later, more specific rules still take precedence. They are marked
+// with an "inherited from" comment and keep the provenance of that rule.
+func MaterializeInheritedRules(rules []Rule, files []string, opts Options) []Rule {
+	hasCodeowners := map[string]bool{}
+	for _, file := range files {
+		if isNestedCodeownersPath(file) {
+			hasCodeowners[path.Dir(file)] = true
+		}
+	}
+
+	matcher := NewMatcher(rules)
+	inherited := map[int][]Rule{}
+	for _, dir := range listDirs(files) {
+		if hasCodeowners[dir] {
+			continue
+		}
+
+		dirPath := path.Join(strings.Trim(opts.PathPrefix, "/"), dir)
+		i := matcher.matchIndex(dirPath)
+		if i < 0 {
+			continue
+		}
+
+		rule := rules[i]
+		rule.Pattern = "/" + dirPath + "/"
+		if opts.Unanchored {
+			rule.Pattern = dirPath + "/"
+		}
+		rule.Dir = true
+		rule.Comment = fmt.Sprintf("inherited from %s", rules[i].Pattern)
+		inherited[i] = append(inherited[i], rule)
+	}
+
+	materialized := make([]Rule, 0, len(rules))
+	for i, rule := range rules {
+		materialized = append(materialized, rule)
+		materialized = append(materialized, inherited[i]...)
+	}
+
+	return materialized
+}
@@ -0,0 +1,41 @@
+package codeowners
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaterializeInheritedRules(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/dev\n*.md @org/docs\n")
+	writeFile(t, repoPath, "src/a/main.go", "")
+	writeFile(t, repoPath, "src/a/b/lib.go", "")
+	writeFile(t, repoPath, "src/c/CODEOWNERS", "@org/c\n")
+	writeFile(t, repoPath, "tools/run.sh", "")
+
+	rules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.NoError(t, err)
+
+	files, err := ListFiles(context.Background(), repoPath)
+	require.NoError(t, err)
+
+	expected := []string{
+		"/src @org/dev",
+		"/src/a/ @org/dev # inherited from /src",
+		"/src/a/b/ @org/dev # inherited from /src",
+		"/src/*.md @org/docs",
+		"/src/c @org/c",
+	}
+	materialized := MaterializeInheritedRules(rules, files, Options{})
+	require.Equal(t, expected, ruleStrings(materialized))
+	require.Equal(t, "src/CODEOWNERS:1", materialized[1].Location())
+
+	// Patterns of the inherited rules follow the options
+	rules, err = RewriteCodeownersRules(context.Background(), repoPath, Options{PathPrefix: "/mono", Unanchored: true})
+	require.NoError(t, err)
+	materialized = MaterializeInheritedRules(rules, files, Options{PathPrefix: "/mono", Unanchored: true})
+	require.Equal(t, "mono/src/a/ @org/dev # inherited from mono/src", materialized[1].String())
+}
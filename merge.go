@@ -0,0 +1,155 @@
+package codeowners
+
+import (
+	"fmt"
+	"strings"
+)
+
+// mergeEntry is a rule of a CO file as seen by MergeCodeowners.
+type mergeEntry struct {
+	// key identifies the rule across versions of the file: its pattern (empty
+	// for dir rules in nested files) and the occurrence of that pattern.
+	key string
+
+	// value is the normalized rule, comments and whitespace are ignored.
+	value string
+
+	// line is the rule as written, index its index among the lines of the file.
+	line  string
+	index int
+}
+
+// MergeCodeowners merges two versions of a CO file that were both derived from
+// base. In contrast to a textual merge, rules are identified by their pattern,
+// so that changes to different rules never conflict, no matter how close they
+// are. If both versions change the same rule differently, the result contains
+// conflict markers for it and true is returned.
+func MergeCodeowners(base, ours, theirs string) (string, bool) {
+	baseEntries := indexMergeEntries(parseMergeEntries(strings.Split(base, "\n")))
+	theirEntries := parseMergeEntries(strings.Split(theirs, "\n"))
+	theirIndex := indexMergeEntries(theirEntries)
+
+	ourLines := strings.Split(ours, "\n")
+	ourEntries := map[int]mergeEntry{}
+	ourIndex := map[string]bool{}
+	for _, entry := range parseMergeEntries(ourLines) {
+		ourEntries[entry.index] = entry
+		ourIndex[entry.key] = true
+	}
+
+	// Rules only added by them are inserted after the rule preceding them
+	// in their version, or appended if that doesn't exist in ours
+	insertAfter := map[string][]mergeEntry{}
+	var appended []mergeEntry
+	previous := ""
+	for _, entry := range theirEntries {
+		_, inBase := baseEntries[entry.key]
+		if !ourIndex[entry.key] && !inBase {
+			if previous != "" && ourIndex[previous] {
+				insertAfter[previous] = append(insertAfter[previous], entry)
+			} else {
+				appended = append(appended, entry)
+			}
+		} else {
+			previous = entry.key
+		}
+	}
+
+	conflict := false
+	var merged []string
+	for i, line := range ourLines {
+		entry, isRule := ourEntries[i]
+		if !isRule {
+			merged = append(merged, line)
+			continue
+		}
+
+		baseEntry, inBase := baseEntries[entry.key]
+		theirEntry, inTheirs := theirIndex[entry.key]
+
+		switch {
+		case inTheirs && theirEntry.value == entry.value:
+			merged = append(merged, line) // Same change on both sides
+		case !inBase && !inTheirs:
+			merged = append(merged, line) // Only added by us
+		case inBase && !inTheirs && baseEntry.value == entry.value:
+			// Deleted by them
+		case inBase && inTheirs && baseEntry.value == entry.value:
+			merged = append(merged, theirEntry.line) // Only changed by them
+		case inBase && inTheirs && baseEntry.value == theirEntry.value:
+			merged = append(merged, line) // Only changed by us
+		default:
+			conflict = true
+			theirLine := ""
+			if inTheirs {
+				theirLine = theirEntry.line + "\n"
+			}
+			merged = append(merged, fmt.Sprintf("<<<<<<< ours\n%s\n=======\n%s>>>>>>> theirs", line, theirLine))
+		}
+
+		for _, inserted := range insertAfter[entry.key] {
+			merged = append(merged, inserted.line)
+		}
+	}
+
+	// Rules deleted by us but changed by them conflict as well
+	for _, theirEntry := range theirEntries {
+		baseEntry, inBase := baseEntries[theirEntry.key]
+		if inBase && !ourIndex[theirEntry.key] && baseEntry.value != theirEntry.value {
+			conflict = true
+			appended = append(appended, mergeEntry{line: fmt.Sprintf("<<<<<<< ours\n=======\n%s\n>>>>>>> theirs", theirEntry.line)})
+		}
+	}
+
+	if len(appended) > 0 {
+		merged = trimTrailingEmptyLines(merged)
+		for _, entry := range appended {
+			merged = append(merged, entry.line)
+		}
+		merged = append(merged, "")
+	}
+
+	return strings.Join(merged, "\n"), conflict
+}
+
+// parseMergeEntries parses the rules among the lines of a CO file.
+func parseMergeEntries(lines []string) []mergeEntry {
+	var entries []mergeEntry
+	occurrences := map[string]int{}
+
+	for i, line := range lines {
+		if !isCodeownersRule(line) {
+			continue
+		}
+
+		tokens, _ := tokenizeCodeownersRule(line)
+		if len(tokens) == 0 {
+			continue
+		}
+
+		pattern := ""
+		if !isDirRule(tokens) {
+			pattern = tokens[0]
+		}
+		occurrences[pattern]++
+
+		entries = append(entries, mergeEntry{
+			key:   fmt.Sprintf("%s#%d", pattern, occurrences[pattern]),
+			value: strings.ToLower(strings.Join(tokens, " ")),
+			line:  line,
+			index: i,
+		})
+	}
+
+	return entries
+}
+
+// indexMergeEntries maps the entries by their key.
+func indexMergeEntries(entries []mergeEntry) map[string]mergeEntry {
+	index := map[string]mergeEntry{}
+	for _, entry := range entries {
+		index[entry.key] = entry
+	}
+
+	return index
+}
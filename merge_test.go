@@ -0,0 +1,61 @@
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeCodeowners(t *testing.T) {
+	base := `# Payments
+@org/payments
+api.go @org/api
+db.go @org/db
+`
+	// Adjacent changes that conflict textually but not semantically
+	ours := `# Payments
+@org/payments
+api.go @org/api-v2
+db.go @org/db
+`
+	theirs := `# Payments
+@org/payments
+api.go @org/api
+db.go @org/storage
+cache.go @org/cache
+`
+	merged, conflict := MergeCodeowners(base, ours, theirs)
+	require.False(t, conflict)
+	require.Equal(t, `# Payments
+@org/payments
+api.go @org/api-v2
+db.go @org/storage
+cache.go @org/cache
+`, merged)
+
+	// Deletions and additions
+	ours = `# Payments
+@org/payments
+api.go @org/api
+`
+	theirs = `# Payments
+@org/payments
+web.go @org/web
+api.go @org/api
+db.go @org/db
+`
+	merged, conflict = MergeCodeowners(base, ours, theirs)
+	require.False(t, conflict)
+	require.Equal(t, `# Payments
+@org/payments
+web.go @org/web
+api.go @org/api
+`, merged)
+
+	// Both sides change the same rule differently
+	ours = "@org/payments-a\n"
+	theirs = "@org/payments-b\n"
+	merged, conflict = MergeCodeowners("@org/payments\n", ours, theirs)
+	require.True(t, conflict)
+	require.Equal(t, "<<<<<<< ours\n@org/payments-a\n=======\n@org/payments-b\n>>>>>>> theirs\n", merged)
+}
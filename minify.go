@@ -0,0 +1,85 @@
+package codeowners
+
+// MinifyRules removes the rules whose owners are already what an enclosing
+// earlier rule assigns, e.g. "/src/foo @org/platform" after
+// "/src @org/platform", unless a rule in between with other owners overlaps
+// them. The owners of every path stay the same. Expiring rules aren't
+// considered enclosing since the removed rules would expire with them.
+func MinifyRules(rules []Rule) []Rule {
+	patterns := make([]pattern, len(rules))
+	for i, rule := range rules {
+		patterns[i] = compilePattern(rule.Pattern)
+	}
+
+	var kept []int
+	for i := range rules {
+		if !redundantRule(rules, patterns, kept, i) {
+			kept = append(kept, i)
+		}
+	}
+
+	minified := make([]Rule, len(kept))
+	for i, j := range kept {
+		minified[i] = rules[j]
+	}
+
+	return minified
+}
+
+// redundantRule checks whether rule i doesn't change any ownership after the
+// kept rules, i.e. whether the last of them overlapping it with other owners
+// comes before a rule with the same owners that covers it.
+func redundantRule(rules []Rule, patterns []pattern, kept []int, i int) bool {
+	for k := len(kept) - 1; k >= 0; k-- {
+		j := kept[k]
+		if ownersKey(rules[j].Owners) != ownersKey(rules[i].Owners) || sectionName(rules[j]) != sectionName(rules[i]) {
+			if patternsOverlap(patterns[j], patterns[i]) {
+				return false
+			}
+			continue
+		}
+
+		if rules[j].Expires.IsZero() && patternCovers(patterns[j], patterns[i]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// patternCovers checks whether pattern a matches every path pattern b matches.
+// It only recognizes "*" and literal dirs enclosing b, e.g. "/src" covers
+// "/src/api" and "/src/*.go", which are the cases of nested CO files.
+func patternCovers(a, b pattern) bool {
+	if !a.anchored && !a.dirOnly && len(a.segments) == 1 && a.segments[0] == "*" {
+		return true
+	}
+
+	if !a.anchored || !b.anchored || len(a.segments) == 0 || len(a.literalPrefix()) != len(a.segments) {
+		return false
+	}
+
+	// A dir-only pattern doesn't match a file of the same name
+	prefix := b.literalPrefix()
+	if len(prefix) < len(a.segments) || (len(prefix) == len(a.segments) && a.dirOnly && !b.dirOnly) {
+		return false
+	}
+
+	for i, segment := range a.segments {
+		if prefix[i] != segment {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sectionName returns the name of the GitLab section of a rule, empty if it
+// has none.
+func sectionName(rule Rule) string {
+	if rule.Section == nil {
+		return ""
+	}
+
+	return rule.Section.Name
+}
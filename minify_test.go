@@ -0,0 +1,48 @@
+package codeowners
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMinifyRules(t *testing.T) {
+	rules := []Rule{
+		{Pattern: "*", Owners: []string{"@org/admin"}, Dir: true},
+		{Pattern: "/src", Owners: []string{"@org/platform"}, Dir: true},
+		{Pattern: "/src/foo", Owners: []string{"@org/platform"}, Dir: true},
+		{Pattern: "/src/foo/*.md", Owners: []string{"@org/docs"}},
+		{Pattern: "/src/foo/docs", Owners: []string{"@org/platform"}, Dir: true},
+		{Pattern: "/src/foo/README.md", Owners: []string{"@org/platform"}},
+		{Pattern: "/docs", Owners: []string{"@org/admin"}, Dir: true},
+		{Pattern: "/src/bar", Owners: []string{"@org/Platform"}, Dir: true},
+		{Pattern: "/lib/", Owners: []string{"@org/platform"}},
+		{Pattern: "/lib", Owners: []string{"@org/platform"}},
+		{Pattern: "/lib/x", Owners: []string{"@org/platform"}, Dir: true},
+		{Pattern: "/tmp", Owners: []string{"@org/tmp"}, Dir: true, Expires: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Pattern: "/tmp/keep", Owners: []string{"@org/tmp"}, Dir: true},
+		{Pattern: "*.go", Owners: []string{"@org/admin"}},
+	}
+
+	minified := MinifyRules(rules)
+	require.Equal(t, []Rule{
+		{Pattern: "*", Owners: []string{"@org/admin"}, Dir: true},
+		{Pattern: "/src", Owners: []string{"@org/platform"}, Dir: true},
+		{Pattern: "/src/foo/*.md", Owners: []string{"@org/docs"}},
+		{Pattern: "/src/foo/README.md", Owners: []string{"@org/platform"}},
+		{Pattern: "/lib/", Owners: []string{"@org/platform"}},
+		{Pattern: "/lib", Owners: []string{"@org/platform"}},
+		{Pattern: "/tmp", Owners: []string{"@org/tmp"}, Dir: true, Expires: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Pattern: "/tmp/keep", Owners: []string{"@org/tmp"}, Dir: true},
+		{Pattern: "*.go", Owners: []string{"@org/admin"}},
+	}, minified)
+
+	// The minification must not change the owners of any path
+	original, reduced := NewMatcher(rules), NewMatcher(minified)
+	for _, path := range []string{"README.md", "src/main.go", "src/foo/a.txt", "src/foo/docs/x.md", "src/foo/README.md", "src/foo/CHANGES.md", "src/bar/b.txt", "docs/index.md", "lib", "lib/x/y.txt", "tmp/keep/z.txt"} {
+		want, _ := original.Match(path)
+		got, _ := reduced.Match(path)
+		require.Equal(t, ownersKey(want.Owners), ownersKey(got.Owners), path)
+	}
+}
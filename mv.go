@@ -0,0 +1,225 @@
+package codeowners
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// MoveResult describes how MoveOwnership carried the ownership of a moved path
+// over to its new location.
+type MoveResult struct {
+	// Moved is set if the path was moved by MoveOwnership, it's unset if the
+	// path had already been moved before.
+	Moved bool
+
+	// Relocated are the nested CO files that moved along with the path, at
+	// their new location relative to the root.
+	Relocated []string
+
+	// Updates are the rules whose patterns were updated to the new location.
+	Updates []PatternUpdate
+
+	// Findings are the rules that point into the moved path but can't be
+	// updated in place.
+	Findings []Finding
+}
+
+// PatternUpdate is a rule of a nested CO file whose pattern was replaced.
+type PatternUpdate struct {
+	Source     string
+	Line       int
+	OldPattern string
+	NewPattern string
+}
+
+// MoveOwnership moves the path from to the path to, both relative to root, and
+// updates the patterns of the rules in the CO files of the parent dirs that
+// point into the moved path. If from is already gone and to exists, the move
+// is assumed to have happened before and only the rules are updated. The CO
+// files inside the moved path need no update since their rules are relative
+// to their dir.
+func MoveOwnership(ctx context.Context, root, from, to string) (MoveResult, error) {
+	var result MoveResult
+
+	from, err := cleanMovePath(from)
+	if err != nil {
+		return result, err
+	}
+	to, err = cleanMovePath(to)
+	if err != nil {
+		return result, err
+	}
+	if from == to || strings.HasPrefix(to, from+"/") {
+		return result, fmt.Errorf("can't move %s to %s", from, to)
+	}
+
+	fromPath := filepath.Join(root, filepath.FromSlash(from))
+	toPath := filepath.Join(root, filepath.FromSlash(to))
+
+	fromExists, err := pathExists(fromPath)
+	if err != nil {
+		return result, err
+	}
+	toExists, err := pathExists(toPath)
+	if err != nil {
+		return result, err
+	}
+
+	switch {
+	case fromExists && !toExists:
+		err = os.MkdirAll(filepath.Dir(toPath), 0o755)
+		if err == nil {
+			err = os.Rename(fromPath, toPath)
+		}
+		if err != nil {
+			return result, fmt.Errorf("can't move %s to %s: %w", from, to, err)
+		}
+		result.Moved = true
+	case fromExists && toExists:
+		return result, fmt.Errorf("can't move %s to %s: %s already exists", from, to, to)
+	case !toExists:
+		return result, fmt.Errorf("can't move %s to %s: neither exists", from, to)
+	}
+
+	err = walkCodeownersFiles(ctx, root, func(coPath string) error {
+		source, err := relativeSourcePath(root, coPath)
+		if err != nil {
+			return err
+		}
+
+		dir := path.Dir(source)
+		if isPathPrefix(to, dir) {
+			result.Relocated = append(result.Relocated, source)
+			return nil
+		}
+		if !isPathPrefix(dir, from) {
+			return nil
+		}
+
+		return updateMovedPatterns(coPath, source, from, to, &result)
+	})
+	if err != nil {
+		return result, fmt.Errorf("error while updating CODEOWNERS files: %w", err)
+	}
+
+	return result, nil
+}
+
+// updateMovedPatterns replaces the patterns of the rules in the CO file at
+// coPath that point into from, which is below the dir of the file, with the
+// corresponding patterns below to.
+func updateMovedPatterns(coPath, source, from, to string, result *MoveResult) error {
+	lines, err := readCodeownersFile(coPath)
+	if err != nil {
+		return err
+	}
+
+	dir := path.Dir(source)
+	changed := false
+	for i, line := range lines {
+		pattern, owners, comment := splitCodeownersRule(line)
+
+		newPattern, ok := movedPattern(dir, pattern, from, to)
+		if !ok {
+			continue
+		}
+		if newPattern == "" {
+			result.Findings = append(result.Findings, Finding{
+				Check:    "moved-pattern",
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("pattern %s points into %s which moved to %s outside of %s, the rule must be moved to a CODEOWNERS file above %s", pattern, from, to, dir, to),
+				File:     source,
+				Line:     i + 1,
+			})
+			continue
+		}
+
+		lines[i] = joinCodeownersRule(newPattern, owners, comment)
+		result.Updates = append(result.Updates, PatternUpdate{Source: source, Line: i + 1, OldPattern: pattern, NewPattern: newPattern})
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+
+	resolvedPath, err := resolveCodeownersFile(coPath)
+	if err != nil {
+		return fmt.Errorf("can't update %s: %w", source, err)
+	}
+
+	err = WriteFileAtomic(resolvedPath, strings.Join(lines, "\n"))
+	if err != nil {
+		return fmt.Errorf("can't update %s: %w", source, err)
+	}
+
+	return nil
+}
+
+// movedPattern updates the pattern of a rule in a CO file in dir, which is a
+// parent dir of from, to point into to instead of from. It returns false if
+// the pattern doesn't point into from, and an empty pattern if to is outside
+// of dir so that the pattern can't be updated in place.
+func movedPattern(dir, pattern, from, to string) (string, bool) {
+	anchor := ""
+	if strings.HasPrefix(pattern, "/") {
+		anchor = "/"
+	}
+
+	oldPrefix := relativeToDir(dir, from)
+	rest := strings.TrimPrefix(pattern, anchor)
+	if pattern == "" || (rest != oldPrefix && !strings.HasPrefix(rest, oldPrefix+"/")) {
+		return "", false
+	}
+
+	if !isPathPrefix(dir, to) {
+		return "", true
+	}
+
+	return anchor + relativeToDir(dir, to) + strings.TrimPrefix(rest, oldPrefix), true
+}
+
+// cleanMovePath validates and normalizes a path relative to the root given to
+// MoveOwnership.
+func cleanMovePath(p string) (string, error) {
+	cleaned := path.Clean(filepath.ToSlash(p))
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") || path.IsAbs(cleaned) {
+		return "", fmt.Errorf("invalid path %q, it must be inside the root", p)
+	}
+
+	return cleaned, nil
+}
+
+// isPathPrefix checks whether the slash separated path p is dir or below dir,
+// every path is below the root ".".
+func isPathPrefix(dir, p string) bool {
+	return dir == "." || p == dir || strings.HasPrefix(p, dir+"/")
+}
+
+// relativeToDir makes p, which is below dir, relative to dir.
+func relativeToDir(dir, p string) string {
+	if dir == "." {
+		return p
+	}
+
+	return strings.TrimPrefix(p, dir+"/")
+}
+
+// pathExists checks whether path exists without following symlinks.
+func pathExists(path string) (bool, error) {
+	_, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,80 @@
+package codeowners
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveOwnership(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admin\nsrc/legacy/api/ @org/api\n")
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/dev\nlegacy/main.go @org/core # Entry point\n/legacy/** @org/legacy\nlegacyx.go @org/other\n*.go @org/go\n")
+	writeFile(t, repoPath, "src/legacy/CODEOWNERS", "@org/legacy\n")
+	writeFile(t, repoPath, "src/legacy/main.go", "")
+
+	result, err := MoveOwnership(context.Background(), repoPath, "src/legacy", "src/core")
+	require.NoError(t, err)
+	require.True(t, result.Moved)
+	require.Equal(t, []string{"src/core/CODEOWNERS"}, result.Relocated)
+	require.Equal(t, []PatternUpdate{
+		{Source: "CODEOWNERS", Line: 2, OldPattern: "src/legacy/api/", NewPattern: "src/core/api/"},
+		{Source: "src/CODEOWNERS", Line: 2, OldPattern: "legacy/main.go", NewPattern: "core/main.go"},
+		{Source: "src/CODEOWNERS", Line: 3, OldPattern: "/legacy/**", NewPattern: "/core/**"},
+	}, result.Updates)
+	require.Empty(t, result.Findings)
+
+	require.NoFileExists(t, filepath.Join(repoPath, "src/legacy/main.go"))
+	require.FileExists(t, filepath.Join(repoPath, "src/core/main.go"))
+
+	content, err := os.ReadFile(filepath.Join(repoPath, "src/CODEOWNERS"))
+	require.NoError(t, err)
+	require.Equal(t, "@org/dev\ncore/main.go @org/core # Entry point\n/core/** @org/legacy\nlegacyx.go @org/other\n*.go @org/go\n", string(content))
+
+	rules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"* @org/admin",
+		"/src/core/api @org/api",
+		"/src @org/dev",
+		"/src/core/main.go @org/core # Entry point",
+		"/src/core/** @org/legacy",
+		"/src/legacyx.go @org/other",
+		"/src/*.go @org/go",
+		"/src/core @org/legacy",
+	}, ruleStrings(rules))
+}
+
+func TestMoveOwnershipAlreadyMoved(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/dev\nlegacy/main.go @org/core\n")
+	writeFile(t, repoPath, "lib/legacy/main.go", "")
+
+	result, err := MoveOwnership(context.Background(), repoPath, "src/legacy", "lib/legacy")
+	require.NoError(t, err)
+	require.False(t, result.Moved)
+	require.Empty(t, result.Updates)
+	require.Equal(t, []Finding{{
+		Check:    "moved-pattern",
+		Severity: SeverityWarning,
+		Message:  "pattern legacy/main.go points into src/legacy which moved to lib/legacy outside of src, the rule must be moved to a CODEOWNERS file above lib/legacy",
+		File:     "src/CODEOWNERS",
+		Line:     2,
+	}}, result.Findings)
+
+	_, err = MoveOwnership(context.Background(), repoPath, "src/gone", "lib/gone")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "neither exists")
+
+	_, err = MoveOwnership(context.Background(), repoPath, "lib", "lib/nested")
+	require.Error(t, err)
+
+	_, err = MoveOwnership(context.Background(), repoPath, "../outside", "lib/outside")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "must be inside the root")
+}
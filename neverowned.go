@@ -0,0 +1,88 @@
+package codeowners
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// neverOwnedComment marks the rules generated for never-owned paths.
+const neverOwnedComment = "never owned, see " + ConfigFileName
+
+// NeverOwnedRules returns a rule without owners for every never-owned
+// pattern, see PolicyConfig.NeverOwned. Appended to the generated rules they
+// remove the ownership of these paths, since the last matching rule wins.
+func NeverOwnedRules(patterns []string, opts Options) []Rule {
+	rules := make([]Rule, 0, len(patterns))
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, "/") {
+			rewritten := path.Join("/", opts.PathPrefix, pattern)
+			if strings.HasSuffix(pattern, "/") {
+				rewritten += "/"
+			}
+			pattern = rewritten
+			if opts.Unanchored {
+				pattern = strings.TrimPrefix(pattern, "/")
+			}
+		}
+
+		rules = append(rules, Rule{Pattern: pattern, Comment: neverOwnedComment})
+	}
+
+	return rules
+}
+
+// CheckNeverOwned reports the rules whose patterns point into a never-owned
+// path, e.g. "/gen/api" for the never-owned "/gen/**". Broader rules that also
+// cover other paths, like "*", are fine since NeverOwnedRules overrides them.
+func CheckNeverOwned(rules []Rule, patterns []string) []Finding {
+	compiled := make([]pattern, len(patterns))
+	for i, p := range patterns {
+		compiled[i] = compilePattern(p)
+	}
+
+	var findings []Finding
+	for _, rule := range rules {
+		if !strings.HasPrefix(rule.Pattern, "/") {
+			continue
+		}
+
+		// The pattern itself or anything inside of it
+		segments := pathSegments(rule.Pattern)
+		inside := append(append([]string{}, segments...), "x")
+
+		for i, never := range compiled {
+			if never.match(segments) || never.match(inside) {
+				findings = append(findings, Finding{
+					Check:    "never-owned",
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("rule for %s assigns owners to paths that must never be owned (%s)", rule.Pattern, patterns[i]),
+					File:     rule.Source,
+					Line:     rule.Line,
+				})
+				break
+			}
+		}
+	}
+
+	return findings
+}
+
+// ExcludeNeverOwned removes the never-owned files, e.g. before computing the
+// coverage.
+func ExcludeNeverOwned(files, patterns []string) []string {
+	if len(patterns) == 0 {
+		return files
+	}
+
+	neverOwned := NewMatcher(NeverOwnedRules(patterns, Options{}))
+
+	kept := make([]string, 0, len(files))
+	for _, file := range files {
+		if _, ok := neverOwned.Match(file); !ok {
+			kept = append(kept, file)
+		}
+	}
+
+	return kept
+}
@@ -0,0 +1,45 @@
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNeverOwned(t *testing.T) {
+	never := []string{"/gen/**", "/third_party/"}
+	rules := []Rule{
+		{Pattern: "*", Owners: []string{"@org/admin"}, Source: "CODEOWNERS", Line: 1},
+		{Pattern: "/gen", Owners: []string{"@org/gen"}, Source: "gen/CODEOWNERS", Line: 1},
+		{Pattern: "/gen/*.pb.go", Owners: []string{"@org/proto"}, Source: "CODEOWNERS", Line: 2},
+		{Pattern: "/generated", Owners: []string{"@org/dev"}, Source: "generated/CODEOWNERS", Line: 1},
+		{Pattern: "/third_party/lib", Owners: []string{"@org/dev"}, Source: "third_party/lib/CODEOWNERS", Line: 1},
+	}
+
+	findings := CheckNeverOwned(rules, never)
+	require.Len(t, findings, 3)
+	require.Equal(t, Finding{
+		Check:    "never-owned",
+		Severity: SeverityError,
+		Message:  "rule for /gen assigns owners to paths that must never be owned (/gen/**)",
+		File:     "gen/CODEOWNERS",
+		Line:     1,
+	}, findings[0])
+	require.Equal(t, 2, findings[1].Line)
+	require.Equal(t, "third_party/lib/CODEOWNERS", findings[2].File)
+
+	generated := append(rules[:1:1], NeverOwnedRules(never, Options{PathPrefix: "sub"})...)
+	require.Equal(t, []string{
+		"* @org/admin",
+		"/sub/gen/** # never owned, see .codeowners.yaml",
+		"/sub/third_party/ # never owned, see .codeowners.yaml",
+	}, ruleStrings(generated))
+
+	matcher := NewMatcher(generated)
+	rule, _ := matcher.Match("sub/gen/api/api.pb.go")
+	require.Empty(t, rule.Owners)
+
+	files := []string{"README.md", "gen/api.go", "third_party/lib/lib.go", "generated/x.go"}
+	require.Equal(t, []string{"README.md", "generated/x.go"}, ExcludeNeverOwned(files, never))
+	require.Equal(t, files, ExcludeNeverOwned(files, nil))
+}
@@ -0,0 +1,76 @@
+package codeowners
+
+import "strings"
+
+// DedupeRules removes the owners listed more than once in a rule, compared
+// case-insensitively like GitHub does, and the rules repeated by a later rule
+// with the same pattern and owners in the same section. The earlier one of
+// such rules never takes effect, so the last one is kept.
+func DedupeRules(rules []Rule) []Rule {
+	deduped := make([]Rule, len(rules))
+	last := map[string]int{}
+	for i, rule := range rules {
+		seen := map[string]bool{}
+		owners := make([]string, 0, len(rule.Owners))
+		for _, owner := range rule.Owners {
+			key := strings.ToLower(owner)
+			if seen[key] {
+				continue
+			}
+
+			seen[key] = true
+			owners = append(owners, owner)
+		}
+
+		rule.Owners = owners
+		deduped[i] = rule
+		last[dedupeKey(rule)] = i
+	}
+
+	result := make([]Rule, 0, len(deduped))
+	for i, rule := range deduped {
+		if last[dedupeKey(rule)] == i {
+			result = append(result, rule)
+		}
+	}
+
+	return result
+}
+
+// dedupeKey identifies the rules that DedupeRules collapses.
+func dedupeKey(rule Rule) string {
+	return sectionName(rule) + "\x00" + rule.Pattern + "\x00" + ownersKey(rule.Owners)
+}
+
+// NormalizeOwners spells every owner of the rules the way it is spelled most
+// often, ties broken by the first occurrence, e.g. @Org/Team becomes
+// @org/team if most rules use the latter. GitHub compares owners
+// case-insensitively, so only the spelling changes.
+func NormalizeOwners(rules []Rule) []Rule {
+	counts := map[string]int{}
+	spellings := map[string]string{}
+	for _, rule := range rules {
+		for _, owner := range rule.Owners {
+			counts[owner]++
+
+			key := strings.ToLower(owner)
+			best, ok := spellings[key]
+			if !ok || counts[owner] > counts[best] {
+				spellings[key] = owner
+			}
+		}
+	}
+
+	normalized := make([]Rule, len(rules))
+	for i, rule := range rules {
+		owners := make([]string, len(rule.Owners))
+		for j, owner := range rule.Owners {
+			owners[j] = spellings[strings.ToLower(owner)]
+		}
+
+		rule.Owners = owners
+		normalized[i] = rule
+	}
+
+	return normalized
+}
@@ -0,0 +1,46 @@
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedupeRules(t *testing.T) {
+	docs := &Section{Name: "Docs"}
+	rules := []Rule{
+		{Pattern: "*", Owners: []string{"@org/admin"}, Source: "CODEOWNERS", Line: 1},
+		{Pattern: "/src", Owners: []string{"@org/dev", "@org/Dev", "@alice"}, Source: "src/CODEOWNERS", Line: 1},
+		{Pattern: "/src/*.md", Owners: []string{"@org/docs"}, Source: "src/CODEOWNERS", Line: 2},
+		{Pattern: "/src/*.md", Owners: []string{"@org/docs"}, Source: "src/CODEOWNERS", Line: 3, Section: docs},
+		{Pattern: "/src/api", Owners: []string{"@org/api"}, Source: "src/api/CODEOWNERS", Line: 1},
+		{Pattern: "/src/*.md", Owners: []string{"@org/Docs"}, Source: "docs/CODEOWNERS", Line: 1},
+	}
+
+	require.Equal(t, []Rule{
+		{Pattern: "*", Owners: []string{"@org/admin"}, Source: "CODEOWNERS", Line: 1},
+		{Pattern: "/src", Owners: []string{"@org/dev", "@alice"}, Source: "src/CODEOWNERS", Line: 1},
+		{Pattern: "/src/*.md", Owners: []string{"@org/docs"}, Source: "src/CODEOWNERS", Line: 3, Section: docs},
+		{Pattern: "/src/api", Owners: []string{"@org/api"}, Source: "src/api/CODEOWNERS", Line: 1},
+		{Pattern: "/src/*.md", Owners: []string{"@org/Docs"}, Source: "docs/CODEOWNERS", Line: 1},
+	}, DedupeRules(rules))
+
+	// The input isn't modified
+	require.Equal(t, []string{"@org/dev", "@org/Dev", "@alice"}, rules[1].Owners)
+}
+
+func TestNormalizeOwners(t *testing.T) {
+	rules := []Rule{
+		{Pattern: "*", Owners: []string{"@Org/Admin"}},
+		{Pattern: "/src", Owners: []string{"@org/dev", "@Alice"}},
+		{Pattern: "/docs", Owners: []string{"@org/admin", "@alice"}},
+		{Pattern: "/api", Owners: []string{"@org/admin", "@org/Dev"}},
+	}
+
+	require.Equal(t, []Rule{
+		{Pattern: "*", Owners: []string{"@org/admin"}},
+		{Pattern: "/src", Owners: []string{"@org/dev", "@Alice"}},
+		{Pattern: "/docs", Owners: []string{"@org/admin", "@Alice"}},
+		{Pattern: "/api", Owners: []string{"@org/admin", "@org/dev"}},
+	}, NormalizeOwners(rules))
+}
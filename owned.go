@@ -0,0 +1,43 @@
+package codeowners
+
+import (
+	"context"
+)
+
+// OwnedPatterns returns the rules that assign owner, except for rules whose
+// pattern is redefined by a later rule and thus never takes effect.
+func OwnedPatterns(rules []Rule, owner string) []Rule {
+	lastIndex := map[string]int{}
+	for i, rule := range rules {
+		lastIndex[rule.Pattern] = i
+	}
+
+	var owned []Rule
+	for i, rule := range rules {
+		if lastIndex[rule.Pattern] == i && rule.HasOwner(owner) {
+			owned = append(owned, rule)
+		}
+	}
+
+	return owned
+}
+
+// OwnedFiles returns the files under root whose effective owners, as resolved
+// by the rules, include owner. Paths are relative to root.
+func OwnedFiles(ctx context.Context, root string, rules []Rule, owner string) ([]string, error) {
+	files, err := ListFiles(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+
+	matcher := NewMatcher(rules)
+
+	var owned []string
+	for _, file := range files {
+		if rule, ok := matcher.Match(file); ok && rule.HasOwner(owner) {
+			owned = append(owned, file)
+		}
+	}
+
+	return owned, nil
+}
@@ -0,0 +1,32 @@
+package codeowners
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOwned(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, ".gitignore", "*.log\n")
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admin\n")
+	writeFile(t, repoPath, "README.md", "")
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/Payments\nmain.go @org/gopher\n")
+	writeFile(t, repoPath, "src/lib.go", "")
+	writeFile(t, repoPath, "src/main.go", "")
+	writeFile(t, repoPath, "src/debug.log", "")
+	writeFile(t, repoPath, "src/nested/nested.go", "")
+	writeFile(t, repoPath, "src/nested/CODEOWNERS", "*.md @org/docs\n")
+
+	rules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.NoError(t, err)
+
+	patterns := OwnedPatterns(rules, "@org/payments")
+	require.Equal(t, []string{"/src @org/Payments"}, ruleStrings(patterns))
+
+	files, err := OwnedFiles(context.Background(), repoPath, rules, "@org/payments")
+	require.NoError(t, err)
+	require.Equal(t, []string{"src/CODEOWNERS", "src/lib.go", "src/nested/CODEOWNERS", "src/nested/nested.go"}, files)
+}
@@ -0,0 +1,134 @@
+package codeowners
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// OwnerSummary describes how an owner is referenced by the rules.
+type OwnerSummary struct {
+	// Owner is the owner as first spelled in the rules.
+	Owner string `json:"owner"`
+
+	// Rules is the number of rules referencing the owner.
+	Rules int `json:"rules"`
+
+	// Sources are the nested CO files referencing the owner.
+	Sources []string `json:"sources"`
+}
+
+// ListOwners returns the distinct owners referenced by the rules, sorted by
+// name. Owners are compared case-insensitively.
+func ListOwners(rules []Rule) []OwnerSummary {
+	summaries := map[string]*OwnerSummary{}
+	seenSources := map[string]bool{}
+
+	for _, rule := range rules {
+		for _, owner := range rule.Owners {
+			key := strings.ToLower(owner)
+
+			summary, ok := summaries[key]
+			if !ok {
+				summary = &OwnerSummary{Owner: owner}
+				summaries[key] = summary
+			}
+			summary.Rules++
+
+			sourceKey := key + "\x00" + rule.Source
+			if !seenSources[sourceKey] {
+				seenSources[sourceKey] = true
+				summary.Sources = append(summary.Sources, rule.Source)
+			}
+		}
+	}
+
+	owners := make([]OwnerSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		owners = append(owners, *summary)
+	}
+
+	sort.Slice(owners, func(i, j int) bool {
+		return strings.ToLower(owners[i].Owner) < strings.ToLower(owners[j].Owner)
+	})
+
+	return owners
+}
+
+// OwnerFilter restricts rules to the ones involving certain owners. Its
+// patterns are owners or globs like "@org/*", matched case-insensitively.
+type OwnerFilter []string
+
+// ParseOwnerFilter parses a comma separated list of owner patterns.
+func ParseOwnerFilter(s string) (OwnerFilter, error) {
+	var filter OwnerFilter
+	for _, pattern := range strings.Split(s, ",") {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+
+		_, err := path.Match(pattern, "")
+		if err != nil {
+			return nil, fmt.Errorf("invalid owner pattern %q: %w", pattern, err)
+		}
+		filter = append(filter, pattern)
+	}
+
+	return filter, nil
+}
+
+// MatchOwner checks whether owner matches one of the patterns.
+func (f OwnerFilter) MatchOwner(owner string) bool {
+	owner = strings.ToLower(owner)
+	for _, pattern := range f {
+		if matched, _ := path.Match(pattern, owner); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Rules returns the rules with at least one matching owner, all rules if the
+// filter is empty.
+func (f OwnerFilter) Rules(rules []Rule) []Rule {
+	if len(f) == 0 {
+		return rules
+	}
+
+	var filtered []Rule
+	for _, rule := range rules {
+		for _, owner := range rule.Owners {
+			if f.MatchOwner(owner) {
+				filtered = append(filtered, rule)
+				break
+			}
+		}
+	}
+
+	return filtered
+}
+
+// Findings returns the findings located at one of the rules, i.e. findings
+// about the rules. Findings without such a location are dropped.
+func (f OwnerFilter) Findings(findings []Finding, rules []Rule) []Finding {
+	if len(f) == 0 {
+		return findings
+	}
+
+	locations := map[string]bool{}
+	for _, rule := range f.Rules(rules) {
+		locations[fmt.Sprintf("%s:%d", rule.Source, rule.Line)] = true
+	}
+
+	filtered := []Finding{}
+	for _, finding := range findings {
+		if finding.Line > 0 && locations[fmt.Sprintf("%s:%d", finding.File, finding.Line)] {
+			filtered = append(filtered, finding)
+		}
+	}
+
+	return filtered
+}
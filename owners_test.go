@@ -0,0 +1,73 @@
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListOwners(t *testing.T) {
+	rules := []Rule{
+		{Pattern: "*", Owners: []string{"@org/admin"}, Source: "CODEOWNERS"},
+		{Pattern: "/src", Owners: []string{"@org/user", "@org/Admin"}, Source: "src/CODEOWNERS"},
+		{Pattern: "/src/main.go", Owners: []string{"@org/user"}, Source: "src/CODEOWNERS"},
+	}
+
+	expected := []OwnerSummary{
+		{Owner: "@org/admin", Rules: 2, Sources: []string{"CODEOWNERS", "src/CODEOWNERS"}},
+		{Owner: "@org/user", Rules: 2, Sources: []string{"src/CODEOWNERS"}},
+	}
+	require.Equal(t, expected, ListOwners(rules))
+}
+
+func TestOwnerFilter(t *testing.T) {
+	rules := []Rule{
+		{Pattern: "*", Owners: []string{"@org/admin"}, Source: "CODEOWNERS", Line: 1},
+		{Pattern: "/payments", Owners: []string{"@org/Payments", "@jane"}, Source: "payments/CODEOWNERS", Line: 1},
+		{Pattern: "/payments/api", Owners: []string{"@org/payments-api"}, Source: "payments/CODEOWNERS", Line: 2},
+		{Pattern: "/docs", Owners: []string{"docs@example.com"}, Source: "docs/CODEOWNERS", Line: 1},
+	}
+
+	filter, err := ParseOwnerFilter("@org/payments")
+	require.NoError(t, err)
+	require.Equal(t, []string{"/payments @org/Payments @jane"}, ruleStrings(filter.Rules(rules)))
+
+	filter, err = ParseOwnerFilter("@org/payments*, *@example.com")
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"/payments @org/Payments @jane",
+		"/payments/api @org/payments-api",
+		"/docs docs@example.com",
+	}, ruleStrings(filter.Rules(rules)))
+
+	findings := []Finding{
+		{Check: "min-owners", File: "payments/CODEOWNERS", Line: 2},
+		{Check: "min-owners", File: "CODEOWNERS", Line: 1},
+		{Check: "min-coverage"},
+	}
+	require.Equal(t, findings[:1], filter.Findings(findings, rules))
+
+	filter, err = ParseOwnerFilter("")
+	require.NoError(t, err)
+	require.Equal(t, rules, filter.Rules(rules))
+	require.Equal(t, findings, filter.Findings(findings, rules))
+
+	_, err = ParseOwnerFilter("@org/[")
+	require.Error(t, err)
+}
+
+func TestSplitTeam(t *testing.T) {
+	org, slug, ok := SplitTeam("@org/payments")
+	require.True(t, ok)
+	require.Equal(t, "org", org)
+	require.Equal(t, "payments", slug)
+
+	org, slug, ok = SplitTeam("org/payments")
+	require.True(t, ok)
+	require.Equal(t, []string{"org", "payments"}, []string{org, slug})
+
+	for _, owner := range []string{"@alice", "dev@example.com", ""} {
+		_, _, ok = SplitTeam(owner)
+		require.False(t, ok, owner)
+	}
+}
@@ -0,0 +1,184 @@
+package codeowners
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// OwnershipDocFileName is the name of the generated ownership summaries.
+const OwnershipDocFileName = "OWNERSHIP.md"
+
+// ownershipDocMarker starts every generated ownership summary.
+const ownershipDocMarker = "<!-- Generated by codeowners from the CODEOWNERS files, don't edit. -->"
+
+// OwnershipDoc summarizes the ownership of the top dir of a team, i.e. a dir
+// assigned to the team by a dir rule that isn't inside another dir assigned
+// to the team.
+type OwnershipDoc struct {
+	// Dir is the top dir relative to the root.
+	Dir string
+
+	// Owners are the owners of the dir rule of Dir.
+	Owners []string
+
+	// Rules are the rules inside Dir involving any of the Owners.
+	Rules []Rule
+
+	// Files is the number of files inside Dir, of which Owned are owned by
+	// any of the Owners and Others by other owners.
+	Files  int
+	Owned  int
+	Others int
+}
+
+// BuildOwnershipDocs computes the ownership summaries of the top dirs of all
+// teams. The rules must be anchored and without path prefix, the files are
+// the files of the repo relative to the root. The root itself is skipped.
+func BuildOwnershipDocs(rules []Rule, files []string) []OwnershipDoc {
+	dirOwners := map[string][]string{}
+	teamDirs := map[string][]string{}
+	for _, rule := range rules {
+		if !rule.Dir || rule.Pattern == "*" || strings.ContainsAny(rule.Pattern, "*?[") {
+			continue
+		}
+
+		dir := strings.Trim(rule.Pattern, "/")
+		dirOwners[dir] = rule.Owners
+		for _, owner := range rule.Owners {
+			if isTeamOwner(owner) {
+				key := strings.ToLower(owner)
+				teamDirs[key] = append(teamDirs[key], dir)
+			}
+		}
+	}
+
+	topDirs := map[string]bool{}
+	for _, dirs := range teamDirs {
+		for _, dir := range dirs {
+			top := true
+			for _, other := range dirs {
+				if other != dir && strings.HasPrefix(dir, other+"/") {
+					top = false
+					break
+				}
+			}
+			if top {
+				topDirs[dir] = true
+			}
+		}
+	}
+
+	matcher := NewMatcher(rules)
+	docs := make([]OwnershipDoc, 0, len(topDirs))
+	for dir := range topDirs {
+		doc := OwnershipDoc{Dir: dir, Owners: dirOwners[dir]}
+		for _, rule := range rules {
+			if hasAnyOwner(rule, doc.Owners) && isPathPrefix(dir, strings.Trim(rule.Pattern, "/")) {
+				doc.Rules = append(doc.Rules, rule)
+			}
+		}
+
+		for _, file := range files {
+			// The summaries themselves are skipped to keep them stable
+			if !strings.HasPrefix(file, dir+"/") || path.Base(file) == OwnershipDocFileName {
+				continue
+			}
+
+			doc.Files++
+			rule, ok := matcher.Match(file)
+			switch {
+			case ok && hasAnyOwner(rule, doc.Owners):
+				doc.Owned++
+			case ok:
+				doc.Others++
+			}
+		}
+
+		// Dirs of the teams manifest might not exist
+		if doc.Files > 0 {
+			docs = append(docs, doc)
+		}
+	}
+
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].Dir < docs[j].Dir
+	})
+
+	return docs
+}
+
+// hasAnyOwner checks whether any of the owners is an owner of the rule.
+func hasAnyOwner(rule Rule, owners []string) bool {
+	for _, owner := range owners {
+		if rule.HasOwner(owner) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Render renders the summary as Markdown.
+func (d OwnershipDoc) Render() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s\n# Ownership of %s\n\n## Contacts\n\n", ownershipDocMarker, d.Dir)
+	for _, owner := range d.Owners {
+		fmt.Fprintf(&b, "- %s\n", ownerLink(owner))
+	}
+
+	fmt.Fprintf(&b, "\n## Patterns\n\n| Pattern | Owners | Declared in |\n| --- | --- | --- |\n")
+	for _, rule := range d.Rules {
+		fmt.Fprintf(&b, "| `%s` | %s | %s |\n", rule.Pattern, strings.Join(rule.Owners, " "), rule.Location())
+	}
+
+	coverage := Coverage{Files: d.Files, Owned: d.Owned}
+	fmt.Fprintf(&b, "\n## Coverage\n\n%d of %s (%.1f%%) are owned by %s, %d by other owners and %d by nobody.\n",
+		d.Owned, Pluralize(d.Files, "file"), coverage.Percent(), strings.Join(d.Owners, " "), d.Others, d.Files-d.Owned-d.Others)
+
+	return b.String()
+}
+
+// ownerLink renders an owner as Markdown link to its GitHub profile, team
+// page or email address.
+func ownerLink(owner string) string {
+	name := strings.TrimPrefix(owner, "@")
+	switch {
+	case !strings.HasPrefix(owner, "@"):
+		return fmt.Sprintf("[%s](mailto:%s)", owner, owner)
+	case isTeamOwner(owner):
+		org, slug, _ := SplitTeam(owner)
+		return fmt.Sprintf("[%s](https://github.com/orgs/%s/teams/%s)", owner, org, slug)
+	default:
+		return fmt.Sprintf("[%s](https://github.com/%s)", owner, name)
+	}
+}
+
+// WriteOwnershipDocs writes the summaries to their dirs under root, unchanged
+// files aren't touched. Existing files that weren't generated are never
+// overwritten. It returns the paths of the written files relative to the
+// root.
+func WriteOwnershipDocs(root string, docs []OwnershipDoc) ([]string, error) {
+	var written []string
+	for _, doc := range docs {
+		file := path.Join(doc.Dir, OwnershipDocFileName)
+		absPath := filepath.Join(root, filepath.FromSlash(file))
+
+		existing, err := os.ReadFile(absPath)
+		if err == nil && !strings.HasPrefix(string(existing), ownershipDocMarker) {
+			return written, fmt.Errorf("can't write %s: the file exists and wasn't generated", file)
+		}
+
+		err = WriteIfChanged(absPath, doc.Render())
+		if err != nil {
+			return written, fmt.Errorf("can't write %s: %w", file, err)
+		}
+		written = append(written, file)
+	}
+
+	return written, nil
+}
@@ -0,0 +1,67 @@
+package codeowners
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOwnershipDocs(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admin\n")
+	writeFile(t, repoPath, "payments/CODEOWNERS", "@org/payments\n*.sql @org/dba\n")
+	writeFile(t, repoPath, "payments/api/CODEOWNERS", "@org/payments @jane\n")
+	writeFile(t, repoPath, "payments/api/api.go", "")
+	writeFile(t, repoPath, "payments/schema.sql", "")
+	writeFile(t, repoPath, "payments/legacy/CODEOWNERS", "@org/legacy\n")
+	writeFile(t, repoPath, "payments/legacy/old.go", "")
+
+	rules, err := RewriteCodeownersRules(context.Background(), repoPath, Options{})
+	require.NoError(t, err)
+	files, err := ListFiles(context.Background(), repoPath)
+	require.NoError(t, err)
+
+	docs := BuildOwnershipDocs(rules, files)
+	require.Len(t, docs, 2)
+	require.Equal(t, "payments", docs[0].Dir)
+	require.Equal(t, "payments/legacy", docs[1].Dir)
+
+	require.Equal(t, `<!-- Generated by codeowners from the CODEOWNERS files, don't edit. -->
+# Ownership of payments
+
+## Contacts
+
+- [@org/payments](https://github.com/orgs/org/teams/payments)
+
+## Patterns
+
+| Pattern | Owners | Declared in |
+| --- | --- | --- |
+| `+"`/payments`"+` | @org/payments | payments/CODEOWNERS:1 |
+| `+"`/payments/api`"+` | @org/payments @jane | payments/api/CODEOWNERS:1 |
+
+## Coverage
+
+3 of 6 files (50.0%) are owned by @org/payments, 3 by other owners and 0 by nobody.
+`, docs[0].Render())
+
+	written, err := WriteOwnershipDocs(repoPath, docs)
+	require.NoError(t, err)
+	require.Equal(t, []string{"payments/OWNERSHIP.md", "payments/legacy/OWNERSHIP.md"}, written)
+
+	// Generating again yields the same summaries
+	files, err = ListFiles(context.Background(), repoPath)
+	require.NoError(t, err)
+	require.Equal(t, docs, BuildOwnershipDocs(rules, files))
+
+	writeFile(t, repoPath, "payments/legacy/OWNERSHIP.md", "# Hand-written\n")
+	_, err = WriteOwnershipDocs(repoPath, docs)
+	require.Error(t, err)
+	content, err := os.ReadFile(filepath.Join(repoPath, "payments/legacy/OWNERSHIP.md"))
+	require.NoError(t, err)
+	require.Equal(t, "# Hand-written\n", string(content))
+}
@@ -0,0 +1,424 @@
+package codeowners
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kinds of packages found by FindPackages.
+const (
+	PackageKindGo   = "go"
+	PackageKindJS   = "js"
+	PackageKindRust = "rust"
+)
+
+// PackageKinds are all supported package kinds.
+var PackageKinds = []string{PackageKindGo, PackageKindJS, PackageKindRust}
+
+// Package is a module or package of a monorepo. The structure encoded by the
+// build tooling is almost always the team structure, so packages are natural
+// ownership boundaries.
+type Package struct {
+	// Dir is the dir of the package relative to the root, slash separated,
+	// "." for the root.
+	Dir  string `json:"dir"`
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+// Label describes the package in messages, by its dir if it has no name.
+func (p Package) Label() string {
+	name := p.Name
+	if name == "" {
+		name = p.Dir
+	}
+
+	return p.Kind + " package " + name
+}
+
+// ParsePackageKinds parses a comma separated list of package kinds.
+func ParsePackageKinds(s string) ([]string, error) {
+	var kinds []string
+	for _, kind := range strings.Split(s, ",") {
+		kind = strings.TrimSpace(kind)
+		if !ContainsString(PackageKinds, kind) {
+			return nil, fmt.Errorf("unknown package kind %q, expected one of %s", kind, strings.Join(PackageKinds, ", "))
+		}
+		kinds = append(kinds, kind)
+	}
+
+	return kinds, nil
+}
+
+// FindPackages finds the packages of the given kinds in the repo in root,
+// files are its files as returned by ListFiles. The packages are sorted by
+// dir.
+func FindPackages(root string, files []string, kinds []string) ([]Package, error) {
+	var packages []Package
+	for _, kind := range kinds {
+		var found []Package
+		var err error
+		switch kind {
+		case PackageKindGo:
+			found, err = findGoModules(root, files)
+		case PackageKindJS:
+			found, err = findJSWorkspacePackages(root, files)
+		case PackageKindRust:
+			found, err = findCargoWorkspaceMembers(root, files)
+		default:
+			err = fmt.Errorf("unknown package kind %q", kind)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		packages = append(packages, found...)
+	}
+
+	sort.SliceStable(packages, func(i, j int) bool { return packages[i].Dir < packages[j].Dir })
+	return packages, nil
+}
+
+// findGoModules finds the Go modules of the repo: the modules used by the
+// go.work file in the root and the dirs of all nested go.mod files.
+func findGoModules(root string, files []string) ([]Package, error) {
+	dirs := map[string]bool{}
+	for _, file := range files {
+		if path.Base(file) == "go.mod" {
+			dirs[path.Dir(file)] = true
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(root, "go.work"))
+	if err == nil {
+		for _, dir := range parseGoWorkUses(string(content)) {
+			dirs[dir] = true
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("can't read go.work: %w", err)
+	}
+
+	var modules []Package
+	for dir := range dirs {
+		content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(dir), "go.mod"))
+		if err != nil {
+			return nil, fmt.Errorf("can't read the go.mod of %s: %w", dir, err)
+		}
+
+		modules = append(modules, Package{Dir: dir, Name: parseGoModulePath(string(content)), Kind: PackageKindGo})
+	}
+
+	return modules, nil
+}
+
+// parseGoWorkUses returns the dirs of the use directives of a go.work file,
+// relative to its dir and slash separated. Dirs outside of it are skipped.
+func parseGoWorkUses(content string) []string {
+	var dirs []string
+	inBlock := false
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+
+		switch {
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case inBlock:
+		case line == "use (":
+			inBlock = true
+			continue
+		case strings.HasPrefix(line, "use "):
+			line = strings.TrimSpace(strings.TrimPrefix(line, "use "))
+		default:
+			continue
+		}
+
+		dir := path.Clean(strings.Trim(line, "\"`"))
+		if line == "" || dir == ".." || strings.HasPrefix(dir, "../") || path.IsAbs(dir) {
+			continue
+		}
+		dirs = append(dirs, dir)
+	}
+
+	return dirs
+}
+
+// parseGoModulePath returns the module path declared in a go.mod file, empty
+// if there is none.
+func parseGoModulePath(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], "\"`")
+		}
+	}
+
+	return ""
+}
+
+// findJSWorkspacePackages finds the packages of the pnpm workspace in
+// pnpm-workspace.yaml or the yarn/npm workspace in the workspaces field of the
+// package.json in the root: the dirs with a package.json matching the
+// workspace globs.
+func findJSWorkspacePackages(root string, files []string) ([]Package, error) {
+	var globs []string
+
+	content, err := os.ReadFile(filepath.Join(root, "pnpm-workspace.yaml"))
+	if err == nil {
+		var workspace struct {
+			Packages []string `yaml:"packages"`
+		}
+		err = yaml.Unmarshal(content, &workspace)
+		if err != nil {
+			return nil, fmt.Errorf("can't parse pnpm-workspace.yaml: %w", err)
+		}
+		globs = append(globs, workspace.Packages...)
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("can't read pnpm-workspace.yaml: %w", err)
+	}
+
+	content, err = os.ReadFile(filepath.Join(root, "package.json"))
+	if err == nil {
+		workspaces, err := parsePackageJSONWorkspaces(content)
+		if err != nil {
+			return nil, err
+		}
+		globs = append(globs, workspaces...)
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("can't read package.json: %w", err)
+	}
+
+	var packages []Package
+	for _, dir := range workspaceMembers(files, "package.json", globs, nil) {
+		content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(dir), "package.json"))
+		if err != nil {
+			return nil, fmt.Errorf("can't read the package.json of %s: %w", dir, err)
+		}
+
+		var manifest struct {
+			Name string `json:"name"`
+		}
+		err = json.Unmarshal(content, &manifest)
+		if err != nil {
+			return nil, fmt.Errorf("can't parse the package.json of %s: %w", dir, err)
+		}
+
+		packages = append(packages, Package{Dir: dir, Name: manifest.Name, Kind: PackageKindJS})
+	}
+
+	return packages, nil
+}
+
+// parsePackageJSONWorkspaces returns the workspace globs of a package.json,
+// either a list or an object with a packages list as used by yarn 1.
+func parsePackageJSONWorkspaces(content []byte) ([]string, error) {
+	var manifest struct {
+		Workspaces json.RawMessage `json:"workspaces"`
+	}
+	err := json.Unmarshal(content, &manifest)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse package.json: %w", err)
+	}
+	if len(manifest.Workspaces) == 0 {
+		return nil, nil
+	}
+
+	var globs []string
+	if json.Unmarshal(manifest.Workspaces, &globs) == nil {
+		return globs, nil
+	}
+
+	var workspaces struct {
+		Packages []string `json:"packages"`
+	}
+	err = json.Unmarshal(manifest.Workspaces, &workspaces)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse the workspaces of package.json: %w", err)
+	}
+
+	return workspaces.Packages, nil
+}
+
+// findCargoWorkspaceMembers finds the members of the Cargo workspace in the
+// Cargo.toml in the root: the dirs with a Cargo.toml matching the members
+// globs but not the exclude globs.
+func findCargoWorkspaceMembers(root string, files []string) ([]Package, error) {
+	content, err := os.ReadFile(filepath.Join(root, "Cargo.toml"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("can't read Cargo.toml: %w", err)
+	}
+
+	members := parseTOMLStringArray(string(content), "workspace", "members")
+	exclude := parseTOMLStringArray(string(content), "workspace", "exclude")
+
+	var packages []Package
+	for _, dir := range workspaceMembers(files, "Cargo.toml", members, exclude) {
+		content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(dir), "Cargo.toml"))
+		if err != nil {
+			return nil, fmt.Errorf("can't read the Cargo.toml of %s: %w", dir, err)
+		}
+
+		var name string
+		if names := parseTOMLStringArray(string(content), "package", "name"); len(names) > 0 {
+			name = names[0]
+		}
+
+		packages = append(packages, Package{Dir: dir, Name: name, Kind: PackageKindRust})
+	}
+
+	return packages, nil
+}
+
+// workspaceMembers returns the dirs below the root with a manifest file that
+// match one of the globs and none of the exclude globs. Globs starting with
+// "!" exclude dirs too, as in pnpm. Globs match whole dirs with "*" and "?"
+// inside a segment and "**" for any number of segments.
+func workspaceMembers(files []string, manifest string, globs, exclude []string) []string {
+	var include []string
+	for _, glob := range globs {
+		if strings.HasPrefix(glob, "!") {
+			exclude = append(exclude, strings.TrimPrefix(glob, "!"))
+		} else {
+			include = append(include, glob)
+		}
+	}
+
+	matchesAny := func(globs []string, dir string) bool {
+		segments := pathSegments(dir)
+		for _, glob := range globs {
+			if matchSegments(pathSegments(glob), segments, 0, func(end int) bool { return end == len(segments) }) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var dirs []string
+	for _, file := range files {
+		dir := path.Dir(file)
+		if path.Base(file) != manifest || dir == "." {
+			continue
+		}
+
+		if matchesAny(include, dir) && !matchesAny(exclude, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	return dirs
+}
+
+// parseTOMLStringArray returns the value of key in the table section of a
+// TOML file, which is a string or an array of strings that may span several
+// lines. This covers the manifests of Cargo without a TOML parser.
+func parseTOMLStringArray(content, section, key string) []string {
+	var values []string
+	inSection, inArray := false, false
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if !inArray && strings.HasPrefix(line, "[") {
+			inSection = line == "["+section+"]"
+			continue
+		}
+		if !inSection {
+			continue
+		}
+
+		if !inArray {
+			i := strings.Index(line, "=")
+			if i < 0 || strings.TrimSpace(line[:i]) != key {
+				continue
+			}
+			line = strings.TrimSpace(line[i+1:])
+			if !strings.HasPrefix(line, "[") {
+				return tomlStrings(line)
+			}
+			line, inArray = line[1:], true
+		}
+
+		if i := strings.Index(line, "]"); i >= 0 {
+			return append(values, tomlStrings(line[:i])...)
+		}
+		values = append(values, tomlStrings(line)...)
+	}
+
+	return values
+}
+
+// tomlStrings returns the quoted strings of a line, skipping a comment.
+func tomlStrings(line string) []string {
+	var values []string
+	for {
+		start := strings.IndexAny(line, "\"'#")
+		if start < 0 || line[start] == '#' {
+			return values
+		}
+
+		end := strings.IndexByte(line[start+1:], line[start])
+		if end < 0 {
+			return values
+		}
+		values = append(values, line[start+1:start+1+end])
+		line = line[start+end+2:]
+	}
+}
+
+// PackageScaffold is a nested CO file proposed for a package.
+type PackageScaffold struct {
+	File    string
+	Package Package
+	Owners  []string
+}
+
+// ScaffoldPackages proposes a nested CO file for every package below the root
+// without one, with the owners inferred from the git history since the given
+// date, see gitHistoryOwners. Packages without history in that time are
+// skipped. Unless dryRun is set, the files are created.
+func ScaffoldPackages(ctx context.Context, root string, packages []Package, since string, maxOwners int, dryRun bool) ([]PackageScaffold, error) {
+	var scaffolds []PackageScaffold
+	for _, pkg := range packages {
+		file := path.Join(pkg.Dir, codeownersFileName)
+		if pkg.Dir == "." || isGeneratedFile(file) {
+			continue
+		}
+
+		absPath := filepath.Join(root, filepath.FromSlash(file))
+		if _, err := os.Lstat(absPath); err == nil {
+			continue
+		}
+
+		owners, err := gitHistoryOwners(ctx, root, pkg.Dir, since, maxOwners)
+		if err != nil {
+			return scaffolds, fmt.Errorf("can't infer the owners of %s: %w", pkg.Dir, err)
+		}
+		if len(owners) == 0 {
+			continue
+		}
+
+		scaffolds = append(scaffolds, PackageScaffold{File: file, Package: pkg, Owners: owners})
+		if dryRun {
+			continue
+		}
+
+		content := fmt.Sprintf("# Scaffolded for the %s from the git history, review the owners\n%s\n", pkg.Label(), strings.Join(owners, " "))
+		err = WriteFileAtomic(absPath, content)
+		if err != nil {
+			return scaffolds, fmt.Errorf("can't scaffold %s: %w", file, err)
+		}
+	}
+
+	return scaffolds, nil
+}
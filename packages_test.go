@@ -0,0 +1,116 @@
+package codeowners
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGoWorkUses(t *testing.T) {
+	content := "go 1.18\n\nuse ./tools // Linters\n\nuse (\n\t.\n\t./services/api\n\t\"./services/web\"\n\t../outside\n)\n"
+	require.Equal(t, []string{"tools", ".", "services/api", "services/web"}, parseGoWorkUses(content))
+}
+
+func TestFindPackages(t *testing.T) {
+	repoPath := t.TempDir()
+	writeFile(t, repoPath, "go.work", "go 1.18\n\nuse (\n\t./services/api\n\t./services/web\n)\n")
+	writeFile(t, repoPath, "services/api/go.mod", "module example.com/api\n\ngo 1.18\n")
+	writeFile(t, repoPath, "services/web/go.mod", "module \"example.com/web\"\n")
+	writeFile(t, repoPath, "tools/go.mod", "module example.com/tools\n")
+
+	files := []string{"go.work", "services/api/go.mod", "services/web/go.mod", "tools/go.mod"}
+	packages, err := FindPackages(repoPath, files, []string{PackageKindGo})
+	require.NoError(t, err)
+	require.Equal(t, []Package{
+		{Dir: "services/api", Name: "example.com/api", Kind: PackageKindGo},
+		{Dir: "services/web", Name: "example.com/web", Kind: PackageKindGo},
+		{Dir: "tools", Name: "example.com/tools", Kind: PackageKindGo},
+	}, packages)
+
+	_, err = ParsePackageKinds("go,maven")
+	require.Error(t, err)
+}
+
+func TestFindWorkspacePackages(t *testing.T) {
+	repoPath := t.TempDir()
+	writeFile(t, repoPath, "pnpm-workspace.yaml", "packages:\n  - 'apps/*'\n  - 'packages/**'\n  - '!**/fixtures/**'\n")
+	writeFile(t, repoPath, "package.json", `{"private": true, "workspaces": {"packages": ["tools/*"]}}`)
+	writeFile(t, repoPath, "apps/web/package.json", `{"name": "@org/web"}`)
+	writeFile(t, repoPath, "packages/ui/button/package.json", `{"name": "@org/button"}`)
+	writeFile(t, repoPath, "packages/ui/fixtures/broken/package.json", `{"name": "fixture"}`)
+	writeFile(t, repoPath, "tools/lint/package.json", `{"name": "lint"}`)
+	writeFile(t, repoPath, "Cargo.toml", "[workspace]\nmembers = [\n  \"crates/*\", # Libraries\n  'cli',\n]\nexclude = [\"crates/legacy\"]\n\n[workspace.package]\nedition = \"2021\"\n")
+	writeFile(t, repoPath, "crates/core/Cargo.toml", "[package]\nname = \"org-core\" # The core\nversion = \"0.1.0\"\n")
+	writeFile(t, repoPath, "crates/legacy/Cargo.toml", "[package]\nname = \"org-legacy\"\n")
+	writeFile(t, repoPath, "cli/Cargo.toml", "[package]\nname = 'org-cli'\n")
+
+	files := []string{
+		"package.json", "pnpm-workspace.yaml", "apps/web/package.json", "packages/ui/button/package.json",
+		"packages/ui/fixtures/broken/package.json", "tools/lint/package.json",
+		"Cargo.toml", "crates/core/Cargo.toml", "crates/legacy/Cargo.toml", "cli/Cargo.toml",
+	}
+	packages, err := FindPackages(repoPath, files, []string{PackageKindJS, PackageKindRust})
+	require.NoError(t, err)
+	require.Equal(t, []Package{
+		{Dir: "apps/web", Name: "@org/web", Kind: PackageKindJS},
+		{Dir: "cli", Name: "org-cli", Kind: PackageKindRust},
+		{Dir: "crates/core", Name: "org-core", Kind: PackageKindRust},
+		{Dir: "packages/ui/button", Name: "@org/button", Kind: PackageKindJS},
+		{Dir: "tools/lint", Name: "lint", Kind: PackageKindJS},
+	}, packages)
+
+	workspaces, err := parsePackageJSONWorkspaces([]byte(`{"workspaces": ["packages/*"]}`))
+	require.NoError(t, err)
+	require.Equal(t, []string{"packages/*"}, workspaces)
+}
+
+func TestScaffoldPackages(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repoPath := t.TempDir()
+	git := func(email string, args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoPath
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL="+email,
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL="+email)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	commit := func(email, file string) {
+		writeFile(t, repoPath, file, email)
+		git(email, "add", "-A")
+		git(email, "commit", "-q", "-m", "Change "+file)
+	}
+
+	git("", "init", "-q")
+	commit("Alice@Example.com", "api/go.mod")
+	commit("123+bob@users.noreply.github.com", "api/main.go")
+	commit("123+bob@users.noreply.github.com", "api/server.go")
+	commit("dependabot[bot]@users.noreply.github.com", "api/deps.go")
+	commit("carol@example.com", "api/util.go")
+	commit("carol@example.com", "web/go.mod")
+	writeFile(t, repoPath, "web/CODEOWNERS", "@org/web\n")
+
+	packages := []Package{
+		{Dir: ".", Name: "example.com/root", Kind: PackageKindGo},
+		{Dir: "api", Name: "example.com/api", Kind: PackageKindGo},
+		{Dir: "web", Name: "example.com/web", Kind: PackageKindGo},
+	}
+	scaffolds, err := ScaffoldPackages(context.Background(), repoPath, packages, "1 year ago", 2, true)
+	require.NoError(t, err)
+	require.Equal(t, []PackageScaffold{{File: "api/CODEOWNERS", Package: packages[1], Owners: []string{"@bob", "carol@example.com"}}}, scaffolds)
+	require.NoFileExists(t, filepath.Join(repoPath, "api", "CODEOWNERS"))
+
+	_, err = ScaffoldPackages(context.Background(), repoPath, packages, "1 year ago", 3, false)
+	require.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(repoPath, "api", "CODEOWNERS"))
+	require.NoError(t, err)
+	require.Equal(t, "# Scaffolded for the go package example.com/api from the git history, review the owners\n@bob carol@example.com alice@example.com\n", string(content))
+}
@@ -0,0 +1,300 @@
+package codeowners
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pragmaPrefix starts the comments with pragmas, e.g.
+// "# codeowners: section=Docs; approvals=2".
+const pragmaPrefix = "codeowners:"
+
+// Pragmas are "key=value" parts of comments starting with pragmaPrefix in
+// nested CO files that attach metadata to rules. On a comment line they apply
+// to all rules of the file, in the trailing comment of a rule only to that
+// rule. Several pragmas are separated by ";". Other comments are ordinary
+// comments, even if they look like "# Section: ...".
+const (
+	// pragmaSection names the GitLab section of the rules of the file.
+	pragmaSection = "section"
+
+	// pragmaApprovals sets the number of approvals the GitLab section of the
+	// file requires.
+	pragmaApprovals = "approvals"
+
+	// pragmaOptional makes the GitLab section of the file optional, its owners
+	// are requested for review but their approval isn't required.
+	pragmaOptional = "optional"
+
+	// pragmaExpires sets the date (YYYY-MM-DD) after which the ownership
+	// expires, e.g. for temporary ownership during team transitions.
+	pragmaExpires = "expires"
+
+	// pragmaLabel tags rules with comma separated labels, e.g. provisional.
+	pragmaLabel = "label"
+
+	// pragmaPriority moves rules out of the BFS order of the generated file,
+	// see sortByPriority.
+	pragmaPriority = "priority"
+)
+
+// filePragmas can only be set for the whole file.
+var filePragmas = map[string]bool{
+	pragmaSection:   true,
+	pragmaApprovals: true,
+	pragmaOptional:  true,
+}
+
+// rulePragmas can be set for single rules or the whole file.
+var rulePragmas = map[string]bool{
+	pragmaExpires:  true,
+	pragmaLabel:    true,
+	pragmaPriority: true,
+}
+
+// expiryDateLayout is the format of the expires pragma.
+const expiryDateLayout = "2006-01-02"
+
+// maxPriority bounds the absolute value of the priority pragma.
+const maxPriority = 100
+
+// pragma is a single parsed pragma.
+type pragma struct {
+	key   string
+	value string
+
+	// offset is the byte offset of the key in the comment.
+	offset int
+}
+
+// parsePragmas parses the pragmas of a comment, given without the leading "#".
+func parsePragmas(comment string) []pragma {
+	pragmas, _ := splitPragmas(comment)
+	return pragmas
+}
+
+// splitPragmas parses the "key=value" parts of a pragma comment, given without
+// the leading "#", into the known pragmas and the parts with unknown keys, e.g.
+// "reason" in "# codeowners: expires=2025-12-31; reason=migration". Comments
+// that don't start with pragmaPrefix have no pragmas.
+func splitPragmas(comment string) ([]pragma, []pragma) {
+	trimmed := strings.TrimLeft(comment, " \t")
+	if !strings.HasPrefix(trimmed, pragmaPrefix) {
+		return nil, nil
+	}
+
+	var known, unknown []pragma
+	offset := len(comment) - len(trimmed) + len(pragmaPrefix)
+	for _, part := range strings.Split(comment[offset:], ";") {
+		partOffset := offset
+		offset += len(part) + 1
+
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+
+		key, value := part, ""
+		if i := strings.Index(part, "="); i >= 0 {
+			key, value = part[:i], part[i+1:]
+		}
+
+		keyOffset := partOffset + len(part) - len(strings.TrimLeft(part, " \t"))
+		p := pragma{key: strings.ToLower(strings.TrimSpace(key)), value: strings.TrimSpace(value), offset: keyOffset}
+		if filePragmas[p.key] || rulePragmas[p.key] {
+			known = append(known, p)
+		} else {
+			unknown = append(unknown, p)
+		}
+	}
+
+	return known, unknown
+}
+
+// ruleAttributes are the attributes of a rule that are set by pragmas.
+type ruleAttributes struct {
+	expires  time.Time
+	labels   []string
+	priority int
+}
+
+// set applies a rule pragma. Labels are added to the labels set so far.
+func (a *ruleAttributes) set(p pragma) error {
+	switch p.key {
+	case pragmaExpires:
+		expires, err := time.Parse(expiryDateLayout, p.value)
+		if err != nil {
+			return fmt.Errorf("invalid expiry date %q, expected YYYY-MM-DD", p.value)
+		}
+		a.expires = expires
+	case pragmaLabel:
+		labels := a.labels[:len(a.labels):len(a.labels)] // Don't modify the file labels
+		for _, label := range strings.Split(p.value, ",") {
+			label = strings.TrimSpace(label)
+			if label == "" {
+				return fmt.Errorf("invalid label list %q", p.value)
+			}
+			if !ContainsString(labels, label) {
+				labels = append(labels, label)
+			}
+		}
+		a.labels = labels
+	case pragmaPriority:
+		priority, err := strconv.Atoi(p.value)
+		if err != nil || priority < -maxPriority || priority > maxPriority {
+			return fmt.Errorf("invalid priority %q, expected an integer from %d to %d", p.value, -maxPriority, maxPriority)
+		}
+		a.priority = priority
+	}
+
+	return nil
+}
+
+// apply copies the attributes to the rule.
+func (a ruleAttributes) apply(rule *Rule) {
+	rule.Expires = a.expires
+	rule.Labels = a.labels
+	rule.Priority = a.priority
+}
+
+// sortByPriority moves the rules with a priority pragma out of the BFS order:
+// rules with a positive priority after all other rules, so that they win
+// regardless of where they are declared, rules with a negative priority
+// before all other rules, so that every other matching rule overrides them.
+// Rules are ordered by ascending priority, rules with the same priority keep
+// their order.
+func sortByPriority(rules []Rule) {
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].Priority < rules[j].Priority
+	})
+}
+
+// parseFilePragmas reads the pragmas on the comment lines of a nested CO file.
+// It returns the section of the file, which defaults to the dir of the file
+// and is nil if the file has no section pragmas, and the attributes of all
+// rules of the file.
+func parseFilePragmas(lines []string, source, dir string) (*Section, ruleAttributes, error) {
+	var section *Section
+	var attrs ruleAttributes
+	sectionLine := 0
+
+	for i, line := range lines {
+		if !strings.HasPrefix(strings.TrimSpace(line), codeownersCommentPrefix) {
+			continue
+		}
+
+		// The comment starts after the "#"
+		commentColumn := strings.Index(line, codeownersCommentPrefix) + 2
+		for _, p := range parsePragmas(line[commentColumn-1:]) {
+			if rulePragmas[p.key] {
+				err := attrs.set(p)
+				if err != nil {
+					return nil, attrs, &ParseError{Source: source, Line: i + 1, Column: commentColumn + p.offset, Err: err}
+				}
+				continue
+			}
+
+			if section == nil {
+				section = &Section{Name: dir}
+			}
+			sectionLine = i + 1
+
+			err := section.set(p)
+			if err != nil {
+				return nil, attrs, &ParseError{Source: source, Line: i + 1, Column: commentColumn + p.offset, Err: err}
+			}
+		}
+	}
+
+	if section != nil && section.Optional && section.Approvals > 0 {
+		err := fmt.Errorf("optional section %s can't require approvals", section.Name)
+		return nil, attrs, &ParseError{Source: source, Line: sectionLine, Err: err}
+	}
+
+	return section, attrs, nil
+}
+
+// checkUnknownPragmas reports the unknown pragmas in the comments of the lines
+// of the CO file source, see splitPragmas and reportParseProblem.
+func checkUnknownPragmas(source string, lines []string, opts Options) error {
+	for i, line := range lines {
+		var comment string
+		var commentColumn int
+		if strings.HasPrefix(strings.TrimSpace(line), codeownersCommentPrefix) {
+			commentColumn = strings.Index(line, codeownersCommentPrefix) + 2
+			comment = line[commentColumn-1:]
+		} else if tokens, trailing := tokenizeCodeownersRule(line); trailing != "" {
+			commentColumn = tokenColumns(line)[len(tokens)] + 1
+			comment = line[commentColumn-1:]
+		}
+
+		_, unknown := splitPragmas(comment)
+		for _, p := range unknown {
+			err := reportParseProblem(Finding{
+				Check:    "unknown-pragma",
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("unknown pragma %s", p.key),
+				File:     source,
+				Line:     i + 1,
+				Column:   commentColumn + p.offset,
+			}, opts)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseRulePragmas reads the pragmas in the trailing comment of the rule in
+// line, they override the attributes set for the whole file.
+func parseRulePragmas(source string, line int, text string, attrs ruleAttributes) (ruleAttributes, error) {
+	tokens, comment := tokenizeCodeownersRule(text)
+	if comment == "" {
+		return attrs, nil
+	}
+
+	// The comment starts after the "#" of the first token that begins with it
+	commentColumn := tokenColumns(text)[len(tokens)] + 1
+	for _, p := range parsePragmas(text[commentColumn-1:]) {
+		if filePragmas[p.key] {
+			err := fmt.Errorf("the %s pragma can only be set for the whole file", p.key)
+			return attrs, &ParseError{Source: source, Line: line, Column: commentColumn + p.offset, Err: err}
+		}
+
+		err := attrs.set(p)
+		if err != nil {
+			return attrs, &ParseError{Source: source, Line: line, Column: commentColumn + p.offset, Err: err}
+		}
+	}
+
+	return attrs, nil
+}
+
+// set applies a section pragma.
+func (s *Section) set(p pragma) error {
+	switch p.key {
+	case pragmaSection:
+		if p.value == "" || strings.ContainsAny(p.value, "[]") {
+			return fmt.Errorf("invalid section name %q", p.value)
+		}
+		s.Name = p.value
+	case pragmaApprovals:
+		approvals, err := strconv.Atoi(p.value)
+		if err != nil || approvals < 1 {
+			return fmt.Errorf("invalid number of approvals %q", p.value)
+		}
+		s.Approvals = approvals
+	case pragmaOptional:
+		optional, err := strconv.ParseBool(p.value)
+		if err != nil {
+			return fmt.Errorf("invalid optional value %q", p.value)
+		}
+		s.Optional = optional
+	}
+
+	return nil
+}
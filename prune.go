@@ -0,0 +1,102 @@
+package codeowners
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// PrunedFile is a nested CO file with its stale rules removed.
+type PrunedFile struct {
+	// Source is the path of the file relative to the root.
+	Source string
+
+	Before string
+	After  string
+
+	// Removed are the stale-pattern findings of the removed rules.
+	Removed []Finding
+}
+
+// Diff returns the unified diff of the pruning.
+func (f PrunedFile) Diff() string {
+	return UnifiedDiff("a/"+f.Source, "b/"+f.Source, f.Before, f.After)
+}
+
+// PruneStaleRules finds the rules of the nested CO files under root whose
+// patterns don't match any file anymore, see FindStaleRules, and returns the
+// affected files with these rules removed. Rules whose stale-pattern finding
+// is suppressed are kept. The files aren't modified, see WritePrunedFiles.
+func PruneStaleRules(ctx context.Context, root string) ([]PrunedFile, error) {
+	rules, err := RewriteCodeownersRules(ctx, root, Options{})
+	if err != nil {
+		return nil, fmt.Errorf("error while rewriting codeowner rules: %w", err)
+	}
+
+	files, err := ListFiles(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+
+	// Invalid suppressions are reported by audit and lint
+	suppressions, _, err := FindSuppressions(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []PrunedFile
+	bySource := map[string]int{}
+	for _, finding := range ApplySuppressions(FindStaleRules(rules, files), suppressions) {
+		i, ok := bySource[finding.File]
+		if !ok {
+			i = len(pruned)
+			bySource[finding.File] = i
+			pruned = append(pruned, PrunedFile{Source: finding.File})
+		}
+		pruned[i].Removed = append(pruned[i].Removed, finding)
+	}
+
+	for i := range pruned {
+		file := &pruned[i]
+
+		lines, err := readCodeownersFile(filepath.Join(root, filepath.FromSlash(file.Source)))
+		if err != nil {
+			return nil, err
+		}
+
+		removed := map[int]bool{}
+		for _, finding := range file.Removed {
+			removed[finding.Line] = true
+		}
+
+		kept := make([]string, 0, len(lines))
+		for j, line := range lines {
+			if !removed[j+1] {
+				kept = append(kept, line)
+			}
+		}
+
+		file.Before = strings.Join(lines, "\n")
+		file.After = strings.Join(kept, "\n")
+	}
+
+	return pruned, nil
+}
+
+// WritePrunedFiles writes the pruned files, following symlinked CO files.
+func WritePrunedFiles(root string, files []PrunedFile) error {
+	for _, file := range files {
+		path, err := resolveCodeownersFile(filepath.Join(root, filepath.FromSlash(file.Source)))
+		if err != nil {
+			return fmt.Errorf("can't prune %s: %w", file.Source, err)
+		}
+
+		err = WriteFileAtomic(path, file.After)
+		if err != nil {
+			return fmt.Errorf("can't prune %s: %w", file.Source, err)
+		}
+	}
+
+	return nil
+}
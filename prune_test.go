@@ -0,0 +1,56 @@
+package codeowners
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneStaleRules(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admin\n")
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/dev\n# Legacy\nold.go @org/legacy\nmain.go @org/core\ngone/ @org/gone\n")
+	writeFile(t, repoPath, "src/main.go", "")
+
+	files, err := PruneStaleRules(context.Background(), repoPath)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "src/CODEOWNERS", files[0].Source)
+	require.Len(t, files[0].Removed, 2)
+	require.Equal(t, `--- a/src/CODEOWNERS
++++ b/src/CODEOWNERS
+@@ -1,5 +1,3 @@
+ @org/dev
+ # Legacy
+-old.go @org/legacy
+ main.go @org/core
+-gone/ @org/gone
+`, files[0].Diff())
+
+	require.NoError(t, WritePrunedFiles(repoPath, files))
+	content, err := os.ReadFile(filepath.Join(repoPath, "src/CODEOWNERS"))
+	require.NoError(t, err)
+	require.Equal(t, "@org/dev\n# Legacy\nmain.go @org/core\n", string(content))
+
+	files, err = PruneStaleRules(context.Background(), repoPath)
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
+
+func TestPruneKeepsSuppressedRules(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admin\nold.go @org/legacy # codeowners-lint: disable=CO001 reason=Restored next release\ngone.go @org/gone\n")
+	writeFile(t, repoPath, "docs/CODEOWNERS", "# codeowners-lint: disable=stale-pattern\n@org/docs\n*.pdf @org/design\n")
+	writeFile(t, repoPath, "docs/index.md", "")
+
+	files, err := PruneStaleRules(context.Background(), repoPath)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "CODEOWNERS", files[0].Source)
+	require.Equal(t, "@org/admin\nold.go @org/legacy # codeowners-lint: disable=CO001 reason=Restored next release\n", files[0].After)
+}
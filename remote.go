@@ -0,0 +1,60 @@
+package codeowners
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ParseRemote parses a remote repo given as "host/owner/name", e.g.
+// "github.com/org/repo", optionally with scheme and ".git" suffix. It returns
+// the API root for GitHub Enterprise hosts, empty for github.com, and the repo
+// as "owner/name".
+func ParseRemote(remote string) (baseURL, repo string, err error) {
+	trimmed := remote
+	for _, prefix := range []string{"https://", "http://"} {
+		trimmed = strings.TrimPrefix(trimmed, prefix)
+	}
+	trimmed = strings.TrimSuffix(strings.TrimSuffix(trimmed, "/"), ".git")
+
+	parts := strings.Split(trimmed, "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", "", fmt.Errorf("invalid remote %s, expected host/owner/repo", remote)
+	}
+
+	if parts[0] != "github.com" {
+		baseURL = fmt.Sprintf("https://%s/api/v3", parts[0])
+	}
+
+	return baseURL, parts[1] + "/" + parts[2], nil
+}
+
+// RewriteRemoteCodeownersRules is RewriteCodeownersRules for a repo on GitHub
+// at ref: The nested CO files are listed and fetched with the API instead of
+// being read from a local checkout. opts.Files isn't supported.
+func RewriteRemoteCodeownersRules(ctx context.Context, client *GitHubClient, repo, ref string, opts Options) ([]Rule, error) {
+	entries, err := client.Tree(ctx, repo, ref)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing files of %s: %w", repo, err)
+	}
+
+	var paths []string
+	blobs := map[string]string{}
+	links := map[string]bool{}
+	for _, entry := range entries {
+		if entry.Type == "blob" {
+			paths = append(paths, entry.Path)
+			blobs[entry.Path] = entry.SHA
+			links[entry.Path] = entry.Mode == symlinkMode
+		}
+	}
+
+	return rewriteCodeownersTree(ctx, paths, followTreeSymlinks(links, func(source string) ([]byte, error) {
+		sha, ok := blobs[source]
+		if !ok {
+			return nil, fmt.Errorf("%s doesn't exist in %s at %s", source, repo, ref)
+		}
+
+		return client.Blob(ctx, repo, sha)
+	}), opts)
+}
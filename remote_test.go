@@ -0,0 +1,78 @@
+package codeowners
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteRemoteCodeownersRules(t *testing.T) {
+	blobs := map[string]string{
+		"1": "@org/admins\n",
+		"2": "@org/dev\n*.md @org/docs\n",
+		"3": "@org/lib\n",
+		"4": "../src/CODEOWNERS",
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/git/trees/main", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "1", r.URL.Query().Get("recursive"))
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"tree": []TreeEntry{
+				{Path: "CODEOWNERS", Type: "blob", SHA: "1"},
+				{Path: "src", Type: "tree", SHA: "x"},
+				{Path: "src/lib/CODEOWNERS", Type: "blob", SHA: "3"},
+				{Path: "src/CODEOWNERS", Type: "blob", SHA: "2"},
+				{Path: "src/main.go", Type: "blob", SHA: "y"},
+				{Path: "web/CODEOWNERS", Mode: "120000", Type: "blob", SHA: "4"},
+				{Path: ".github/CODEOWNERS", Type: "blob", SHA: "z"},
+			},
+		})
+	})
+	mux.HandleFunc("/repos/org/repo/git/blobs/", func(w http.ResponseWriter, r *http.Request) {
+		sha := r.URL.Path[len("/repos/org/repo/git/blobs/"):]
+		content, ok := blobs[sha]
+		require.True(t, ok, sha)
+
+		// GitHub wraps the base64 content
+		encoded := base64.StdEncoding.EncodeToString([]byte(content))
+		encoded = encoded[:4] + "\n" + encoded[4:]
+		_ = json.NewEncoder(w).Encode(map[string]string{"content": encoded, "encoding": "base64"})
+	})
+	mux.HandleFunc("/repos/org/repo/git/trees/huge", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"tree": [], "truncated": true}`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := &GitHubClient{BaseURL: server.URL, HTTPClient: server.Client()}
+
+	rules, err := RewriteRemoteCodeownersRules(context.Background(), client, "org/repo", "main", Options{PathPrefix: "/mono"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"/mono @org/admins", "/mono/src @org/dev", "/mono/src/*.md @org/docs", "/mono/web @org/dev", "/mono/web/*.md @org/docs", "/mono/src/lib @org/lib"}, ruleStrings(rules))
+	require.Equal(t, "src/CODEOWNERS:2", rules[2].Location())
+
+	_, err = RewriteRemoteCodeownersRules(context.Background(), client, "org/repo", "huge", Options{})
+	require.EqualError(t, err, "error while listing files of org/repo: tree of org/repo at huge is too large for the GitHub API")
+}
+
+func TestParseRemote(t *testing.T) {
+	baseURL, repo, err := ParseRemote("github.com/org/repo")
+	require.NoError(t, err)
+	require.Equal(t, "", baseURL)
+	require.Equal(t, "org/repo", repo)
+
+	baseURL, repo, err = ParseRemote("https://github.example.com/org/repo.git")
+	require.NoError(t, err)
+	require.Equal(t, "https://github.example.com/api/v3", baseURL)
+	require.Equal(t, "org/repo", repo)
+
+	_, _, err = ParseRemote("org/repo")
+	require.EqualError(t, err, "invalid remote org/repo, expected host/owner/repo")
+}
@@ -0,0 +1,164 @@
+package codeowners
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// SuggestRenameMigrations detects the dirs and files renamed between base and
+// HEAD of the repo in root, see gitRenames, and reports the rules of the
+// nested CO files that still point to their old paths together with the
+// replacements. This catches renames done without the mv command. If base is
+// empty, the last commit that changed the generated CO file is used.
+func SuggestRenameMigrations(ctx context.Context, root, base string) ([]Finding, error) {
+	if base == "" {
+		commit, err := gitLastCommitOf(ctx, root, GeneratedFileName)
+		if err != nil {
+			return nil, err
+		}
+		if commit == "" {
+			return nil, fmt.Errorf("%s was never committed, the base of the renames must be given", GeneratedFileName)
+		}
+		base = commit
+	}
+
+	renames, err := gitRenames(ctx, root, base, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := gitListFiles(ctx, root, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	moves := detectMoves(renames, files)
+	if len(moves) == 0 {
+		return nil, nil
+	}
+
+	var findings []Finding
+	err = walkCodeownersFiles(ctx, root, func(coPath string) error {
+		source, err := relativeSourcePath(root, coPath)
+		if err != nil {
+			return err
+		}
+
+		lines, err := readCodeownersFile(coPath)
+		if err != nil {
+			return err
+		}
+
+		dir := path.Dir(source)
+		for i, line := range lines {
+			pattern, _, _ := splitCodeownersRule(line)
+			if finding, ok := renameFinding(dir, pattern, moves); ok {
+				finding.File = source
+				finding.Line = i + 1
+				findings = append(findings, finding)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error while searching renamed paths in CODEOWNERS files: %w", err)
+	}
+
+	return findings, nil
+}
+
+// renameFinding reports the pattern of a rule in a CO file in dir if it points
+// into a moved path, the first matching move wins.
+func renameFinding(dir, pattern string, moves []Rename) (Finding, bool) {
+	for _, move := range moves {
+		if !isPathPrefix(dir, move.From) || move.From == dir {
+			continue
+		}
+
+		newPattern, ok := movedPattern(dir, pattern, move.From, move.To)
+		if !ok {
+			continue
+		}
+
+		message := fmt.Sprintf("pattern %s points into %s which was renamed to %s, replace it by %s", pattern, move.From, move.To, newPattern)
+		if newPattern == "" {
+			message = fmt.Sprintf("pattern %s points into %s which was renamed to %s outside of %s, move the rule to a CODEOWNERS file above %s", pattern, move.From, move.To, dir, move.To)
+		}
+
+		return Finding{Check: "renamed-path", Severity: SeverityWarning, Message: message}, true
+	}
+
+	return Finding{}, false
+}
+
+// detectMoves derives the moved dirs from the renamed files. A rename moves
+// the outermost dir that is left after removing common trailing segments of
+// both paths and doesn't exist anymore in files, if all of its files went to
+// the same dir. Renames not explained by a moved dir are kept as file moves.
+// Dir moves come first, the deepest ones first.
+func detectMoves(renames []Rename, files []string) []Rename {
+	remaining := map[string]bool{}
+	for _, dir := range listDirs(files) {
+		remaining[dir] = true
+	}
+
+	targets := map[string]map[string]bool{}
+	for _, rename := range renames {
+		from, to := strings.Split(rename.From, "/"), strings.Split(rename.To, "/")
+		common := 0
+		for common+1 < len(from) && common+1 < len(to) && from[len(from)-1-common] == to[len(to)-1-common] {
+			common++
+		}
+
+		// Prefer the outermost moved dir that is gone
+		for k := common; k > 0; k-- {
+			fromDir := strings.Join(from[:len(from)-k], "/")
+			if remaining[fromDir] {
+				continue
+			}
+
+			if targets[fromDir] == nil {
+				targets[fromDir] = map[string]bool{}
+			}
+			targets[fromDir][strings.Join(to[:len(to)-k], "/")] = true
+			break
+		}
+	}
+
+	var dirMoves []Rename
+	for fromDir, toDirs := range targets {
+		if len(toDirs) != 1 {
+			continue
+		}
+		for toDir := range toDirs {
+			dirMoves = append(dirMoves, Rename{From: fromDir, To: toDir})
+		}
+	}
+	sort.Slice(dirMoves, func(i, j int) bool {
+		di, dj := dirDepth(dirMoves[i].From), dirDepth(dirMoves[j].From)
+		if di != dj {
+			return di > dj
+		}
+		return dirMoves[i].From < dirMoves[j].From
+	})
+
+	moves := dirMoves
+	for _, rename := range renames {
+		explained := false
+		for _, move := range dirMoves {
+			if strings.HasPrefix(rename.From, move.From+"/") {
+				explained = true
+				break
+			}
+		}
+		if !explained {
+			moves = append(moves, rename)
+		}
+	}
+
+	return moves
+}
@@ -0,0 +1,88 @@
+package codeowners
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNameStatus(t *testing.T) {
+	out := "M\x00README.md\x00R100\x00src/legacy/a.go\x00src/core/a.go\x00C075\x00x.go\x00y.go\x00D\x00gone.go\x00"
+
+	require.Equal(t, []Rename{{From: "src/legacy/a.go", To: "src/core/a.go"}}, parseNameStatus(out))
+}
+
+func TestDetectMoves(t *testing.T) {
+	renames := []Rename{
+		{From: "src/legacy/a.go", To: "src/core/a.go"},
+		{From: "src/legacy/sub/b.go", To: "src/core/sub/b.go"},
+		{From: "lib/util.go", To: "lib/helpers.go"},
+		{From: "lib/x/c.go", To: "pkg/x/c.go"},
+		{From: "split/d.go", To: "one/d.go"},
+		{From: "split/e.go", To: "two/e.go"},
+	}
+	files := []string{"src/core/a.go", "src/core/sub/b.go", "lib/helpers.go", "lib/other.go", "pkg/x/c.go", "one/d.go", "two/e.go"}
+
+	require.Equal(t, []Rename{
+		{From: "lib/x", To: "pkg/x"},
+		{From: "src/legacy", To: "src/core"},
+		{From: "lib/util.go", To: "lib/helpers.go"},
+		{From: "split/d.go", To: "one/d.go"},
+		{From: "split/e.go", To: "two/e.go"},
+	}, detectMoves(renames, files))
+}
+
+func TestSuggestRenameMigrations(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repoPath := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoPath
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	git("init", "--quiet")
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admin\n")
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/dev\nlegacy/main.go @org/core\n/legacy/api/ @org/api\nmain.go @org/dev\n")
+	writeFile(t, repoPath, "src/legacy/main.go", "package main\n")
+	writeFile(t, repoPath, "src/legacy/api/api.go", "package api\n")
+	writeFile(t, repoPath, GeneratedFileName, "* @org/admin\n")
+	git("add", "--all")
+	git("commit", "--quiet", "--message", "Generate CODEOWNERS")
+
+	git("mv", "src/legacy", "src/core")
+	git("commit", "--quiet", "--message", "Rename legacy to core")
+
+	findings, err := SuggestRenameMigrations(context.Background(), repoPath, "")
+	require.NoError(t, err)
+	require.Equal(t, []Finding{
+		{
+			Check:    "renamed-path",
+			Severity: SeverityWarning,
+			Message:  "pattern legacy/main.go points into src/legacy which was renamed to src/core, replace it by core/main.go",
+			File:     "src/CODEOWNERS",
+			Line:     2,
+		},
+		{
+			Check:    "renamed-path",
+			Severity: SeverityWarning,
+			Message:  "pattern /legacy/api/ points into src/legacy which was renamed to src/core, replace it by /core/api/",
+			File:     "src/CODEOWNERS",
+			Line:     3,
+		},
+	}, findings)
+
+	findings, err = SuggestRenameMigrations(context.Background(), repoPath, "HEAD")
+	require.NoError(t, err)
+	require.Empty(t, findings)
+}
@@ -0,0 +1,109 @@
+package codeowners
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ReviewRequests is the set of reviews GitHub requests for a set of changed
+// files based on their owners.
+type ReviewRequests struct {
+	// Owners are the requested reviewers with the changed files they own,
+	// sorted by owner.
+	Owners []OwnerFiles `json:"owners"`
+
+	// Unowned are the changed files that don't trigger any review request.
+	Unowned []string `json:"unowned"`
+}
+
+// OwnerFiles is an owner together with the files it owns.
+type OwnerFiles struct {
+	Owner string   `json:"owner"`
+	Files []string `json:"files"`
+}
+
+// ResolveReviewRequests resolves the owners of the changed files and groups
+// the files by owner.
+func ResolveReviewRequests(rules []Rule, files []string) ReviewRequests {
+	matcher := NewMatcher(rules)
+
+	byOwner := map[string]*OwnerFiles{}
+	requests := ReviewRequests{Owners: []OwnerFiles{}, Unowned: []string{}}
+
+	for _, file := range files {
+		rule, ok := matcher.Match(file)
+		if !ok || len(rule.Owners) == 0 {
+			requests.Unowned = append(requests.Unowned, file)
+			continue
+		}
+
+		for _, owner := range rule.Owners {
+			key := strings.ToLower(owner)
+			if byOwner[key] == nil {
+				byOwner[key] = &OwnerFiles{Owner: owner}
+			}
+			byOwner[key].Files = append(byOwner[key].Files, file)
+		}
+	}
+
+	for _, ownerFiles := range byOwner {
+		requests.Owners = append(requests.Owners, *ownerFiles)
+	}
+	sort.Slice(requests.Owners, func(i, j int) bool {
+		return strings.ToLower(requests.Owners[i].Owner) < strings.ToLower(requests.Owners[j].Owner)
+	})
+
+	return requests
+}
+
+// reviewCommentMarker identifies comments rendered by RenderReviewComment, so
+// that bots can find and update them.
+const reviewCommentMarker = "<!-- codeowners-review-summary -->"
+
+// ReviewSummary is the ownership impact of a pull request.
+type ReviewSummary struct {
+	Requests ReviewRequests `json:"requests"`
+
+	// GeneratedFileChanged is true if the pull request changes the generated
+	// CODEOWNERS file.
+	GeneratedFileChanged bool `json:"generatedFileChanged"`
+
+	// GeneratedFileOutdated is true if the generated CODEOWNERS file doesn't
+	// match the nested CODEOWNERS files.
+	GeneratedFileOutdated bool `json:"generatedFileOutdated"`
+}
+
+// RenderReviewComment renders the summary as Markdown comment for a pull
+// request.
+func RenderReviewComment(summary ReviewSummary) string {
+	var b strings.Builder
+	b.WriteString(reviewCommentMarker + "\n")
+	b.WriteString("### Code ownership\n\n")
+
+	if len(summary.Requests.Owners) == 0 {
+		b.WriteString("No reviews are requested by CODEOWNERS.\n")
+	} else {
+		b.WriteString("| Reviewer | Files |\n|---|---|\n")
+		for _, owner := range summary.Requests.Owners {
+			fmt.Fprintf(&b, "| %s | %d |\n", owner.Owner, len(owner.Files))
+		}
+	}
+
+	if len(summary.Requests.Unowned) > 0 {
+		fmt.Fprintf(&b, "\n<details><summary>%s without owner</summary>\n\n", Pluralize(len(summary.Requests.Unowned), "changed file"))
+		for _, file := range summary.Requests.Unowned {
+			fmt.Fprintf(&b, "- `%s`\n", file)
+		}
+		b.WriteString("\n</details>\n")
+	}
+
+	if summary.GeneratedFileChanged {
+		fmt.Fprintf(&b, "\n:pencil: This pull request changes `%s`.\n", GeneratedFileName)
+	}
+	if summary.GeneratedFileOutdated {
+		fmt.Fprintf(&b, "\n:warning: `%s` is out of date, regenerate it from the nested CODEOWNERS files.\n", GeneratedFileName)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,50 @@
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveReviewRequests(t *testing.T) {
+	rules := []Rule{
+		{Pattern: "/src", Owners: []string{"@org/user"}},
+		{Pattern: "/src/main.go", Owners: []string{"@org/gopher", "@org/User"}},
+	}
+
+	requests := ResolveReviewRequests(rules, []string{"README.md", "src/lib.go", "src/main.go"})
+	require.Equal(t, ReviewRequests{
+		Owners: []OwnerFiles{
+			{Owner: "@org/gopher", Files: []string{"src/main.go"}},
+			{Owner: "@org/user", Files: []string{"src/lib.go", "src/main.go"}},
+		},
+		Unowned: []string{"README.md"},
+	}, requests)
+}
+
+func TestRenderReviewComment(t *testing.T) {
+	summary := ReviewSummary{
+		Requests: ReviewRequests{
+			Owners:  []OwnerFiles{{Owner: "@org/user", Files: []string{"src/lib.go", "src/main.go"}}},
+			Unowned: []string{"README.md"},
+		},
+		GeneratedFileOutdated: true,
+	}
+
+	expected := reviewCommentMarker + `
+### Code ownership
+
+| Reviewer | Files |
+|---|---|
+| @org/user | 2 |
+
+<details><summary>1 changed file without owner</summary>
+
+- ` + "`README.md`" + `
+
+</details>
+
+:warning: ` + "`.github/CODEOWNERS`" + ` is out of date, regenerate it from the nested CODEOWNERS files.
+`
+	require.Equal(t, expected, RenderReviewComment(summary))
+}
@@ -0,0 +1,139 @@
+package codeowners
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Rule is a CO rule rewritten for inclusion in the root CO file.
+type Rule struct {
+	// Pattern is the rewritten pattern, e.g. "/src/dir" or "/src/dir/*.js".
+	Pattern string
+
+	// Owners are the GitHub users, teams or email addresses owning Pattern.
+	Owners []string
+
+	// Dir is set for rules that assign a whole dir, i.e. the owners-only lines
+	// of nested CO files, as opposed to file and glob rules.
+	Dir bool
+
+	// Comment is the trailing comment of the rule without the leading "#".
+	Comment string
+
+	// Comments are the comment lines directly above the rule in Source,
+	// without the leading "#". Pragmas aren't included.
+	Comments []string
+
+	// Source is the path of the nested CO file that declared the rule,
+	// relative to the root and with forward slashes.
+	Source string
+
+	// Line is the 1-based line number of the rule in Source.
+	Line int
+
+	// Section is the GitLab section of the rule, shared by all rules of its
+	// nested CO file. Nil if the file has no section pragmas.
+	Section *Section
+
+	// Expires is the date after which the ownership expires, zero if it
+	// doesn't.
+	Expires time.Time
+
+	// Labels tag the rule, e.g. to mark provisional ownership.
+	Labels []string
+
+	// Priority moves the rule out of the BFS order, see sortByPriority.
+	Priority int
+}
+
+// String renders the rule as a line of the root CO file.
+func (r Rule) String() string {
+	line := r.Pattern
+	if len(r.Owners) > 0 {
+		line = fmt.Sprintf("%s %s", line, strings.Join(r.Owners, " "))
+	}
+
+	if r.Comment != "" {
+		line = fmt.Sprintf("%s %s %s", line, codeownersCommentPrefix, r.Comment)
+	}
+
+	return line
+}
+
+// Location returns the position of the rule in its source file, e.g.
+// "src/dir/CODEOWNERS:12".
+func (r Rule) Location() string {
+	return fmt.Sprintf("%s:%d", r.Source, r.Line)
+}
+
+// HasOwner checks whether owner is one of the owners of the rule. GitHub
+// handles and emails are compared case-insensitively.
+func (r Rule) HasOwner(owner string) bool {
+	for _, o := range r.Owners {
+		if strings.EqualFold(o, owner) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasLabel checks whether the rule is tagged with label.
+func (r Rule) HasLabel(label string) bool {
+	return ContainsString(r.Labels, label)
+}
+
+// FilterRuleKind returns the dir rules if dir is set, the file and glob rules
+// otherwise.
+func FilterRuleKind(rules []Rule, dir bool) []Rule {
+	var filtered []Rule
+	for _, rule := range rules {
+		if rule.Dir == dir {
+			filtered = append(filtered, rule)
+		}
+	}
+
+	return filtered
+}
+
+// ruleStrings renders every rule as a line of the root CO file.
+func ruleStrings(rules []Rule) []string {
+	lines := make([]string, len(rules))
+	for i, rule := range rules {
+		lines[i] = rule.String()
+	}
+
+	return lines
+}
+
+// ContainsString checks whether values contains value.
+func ContainsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Pluralize formats a count with a noun, e.g. "1 file" or "2 files".
+func Pluralize(count int, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, noun)
+	}
+
+	return fmt.Sprintf("%d %ss", count, noun)
+}
+
+// SplitTeam splits a team owner "@org/slug", with or without the "@", into
+// its org and slug. ok is false if owner isn't a team.
+func SplitTeam(owner string) (org, slug string, ok bool) {
+	parts := strings.SplitN(strings.TrimPrefix(owner, "@"), "/", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+
+	return parts[0], parts[1], true
+}
@@ -0,0 +1,57 @@
+package codeowners
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxRulesTopSources is the number of CO files CheckMaxRules points at.
+const maxRulesTopSources = 5
+
+// CheckMaxRules reports if there are more than max rules, naming the CO files
+// that contribute the most rules. GitHub's matching slows down and the file
+// becomes unreviewable past a certain size. A max of 0 disables the check.
+func CheckMaxRules(rules []Rule, max int) []Finding {
+	if max <= 0 || len(rules) <= max {
+		return nil
+	}
+
+	counts := map[string]int{}
+	for _, rule := range rules {
+		if rule.Source != "" { // Skip the rules without CO file, e.g. never-owned rules
+			counts[rule.Source]++
+		}
+	}
+
+	sources := make([]string, 0, len(counts))
+	for source := range counts {
+		sources = append(sources, source)
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		if counts[sources[i]] != counts[sources[j]] {
+			return counts[sources[i]] > counts[sources[j]]
+		}
+		return sources[i] < sources[j]
+	})
+	if len(sources) > maxRulesTopSources {
+		sources = sources[:maxRulesTopSources]
+	}
+
+	top := make([]string, len(sources))
+	for i, source := range sources {
+		top[i] = fmt.Sprintf("%s (%d)", source, counts[source])
+	}
+
+	finding := Finding{
+		Check:    "max-rules",
+		Severity: SeverityWarning,
+		Message:  fmt.Sprintf("%d rules exceed the limit of %d", len(rules), max),
+	}
+	if len(top) > 0 {
+		finding.Message += ", most rules come from " + strings.Join(top, ", ")
+		finding.File = sources[0]
+	}
+
+	return []Finding{finding}
+}
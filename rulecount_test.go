@@ -0,0 +1,25 @@
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckMaxRules(t *testing.T) {
+	rules := []Rule{
+		{Pattern: "*", Owners: []string{"@org/admin"}, Source: "CODEOWNERS", Line: 1},
+		{Pattern: "/src/a", Owners: []string{"@org/dev"}, Source: "src/CODEOWNERS", Line: 1},
+		{Pattern: "/src/b", Owners: []string{"@org/dev"}, Source: "src/CODEOWNERS", Line: 2},
+		{Pattern: "/gen/**", Comment: neverOwnedComment},
+	}
+
+	require.Empty(t, CheckMaxRules(rules, 0))
+	require.Empty(t, CheckMaxRules(rules, 4))
+	require.Equal(t, []Finding{{
+		Check:    "max-rules",
+		Severity: SeverityWarning,
+		Message:  "4 rules exceed the limit of 3, most rules come from src/CODEOWNERS (2), CODEOWNERS (1)",
+		File:     "src/CODEOWNERS",
+	}}, CheckMaxRules(rules, 3))
+}
@@ -0,0 +1,282 @@
+package codeowners
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ServerOptions configures a Server.
+type ServerOptions struct {
+	// Pull fast-forwards the checkout before every build.
+	Pull bool
+
+	// ConfigFile is the config whose never-owned paths are removed from the
+	// ownership, see LoadConfig. It is read by every build.
+	ConfigFile string
+
+	// WebhookSecret enables the webhook endpoint, whose requests must be
+	// signed with this secret, see handleWebhook.
+	WebhookSecret string
+}
+
+// Server answers ownership queries over HTTP from an in-memory matcher that
+// is rebuilt periodically and on demand, see Handler.
+type Server struct {
+	root       string
+	opts       Options
+	serverOpts ServerOptions
+
+	// trigger requests a build from Poll, several requests coalesce.
+	trigger chan struct{}
+
+	// buildMu serializes the builds of Reload, which are run by Poll and the
+	// reload endpoint, so that they don't pull concurrently and an older build
+	// can't replace the matcher of a newer one.
+	buildMu sync.Mutex
+
+	// mu guards the fields below, which are replaced by every successful
+	// build.
+	mu      sync.RWMutex
+	matcher *Matcher
+	rules   int
+	builtAt time.Time
+	lastErr error
+}
+
+// NewServer creates a server for the repo in root. It isn't ready before the
+// first successful Reload.
+func NewServer(root string, opts Options, serverOpts ServerOptions) *Server {
+	return &Server{root: root, opts: opts, serverOpts: serverOpts, trigger: make(chan struct{}, 1)}
+}
+
+// Reload rebuilds the matcher from the CO files of the repo, after pulling
+// if configured. If that fails, the previous matcher stays in use. Concurrent
+// calls build one after the other.
+func (s *Server) Reload(ctx context.Context) error {
+	s.buildMu.Lock()
+	defer s.buildMu.Unlock()
+
+	var rules []Rule
+	var cfg Config
+	var err error
+	if s.serverOpts.Pull {
+		err = gitPull(ctx, s.root)
+	}
+	if err == nil {
+		cfg, err = LoadConfig(s.root, s.serverOpts.ConfigFile)
+	}
+	if err == nil {
+		rules, err = LoadRulesCached(ctx, s.root, s.opts, cfg.Policy.NeverOwned)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastErr = err
+	if err != nil {
+		return fmt.Errorf("error while rewriting codeowner rules in %s: %w", s.root, err)
+	}
+
+	s.matcher = NewMatcher(rules)
+	s.rules = len(rules)
+	s.builtAt = time.Now().UTC()
+	return nil
+}
+
+// Poll reloads the matcher every interval, if it is positive, and whenever a
+// build is triggered until ctx is done, failures are logged.
+func (s *Server) Poll(ctx context.Context, interval time.Duration) {
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+		case <-s.trigger:
+		}
+
+		if err := s.Reload(ctx); err != nil && ctx.Err() == nil {
+			log.Print(err)
+		}
+	}
+}
+
+// Trigger requests a build from Poll without waiting for it.
+func (s *Server) Trigger() {
+	select {
+	case s.trigger <- struct{}{}:
+	default: // A build is already pending
+	}
+}
+
+// serverStatus is the JSON response of the status endpoints.
+type serverStatus struct {
+	Ready   bool      `json:"ready"`
+	Rules   int       `json:"rules"`
+	BuiltAt time.Time `json:"builtAt,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// status returns the state of the server.
+func (s *Server) status() serverStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	status := serverStatus{Ready: s.matcher != nil, Rules: s.rules, BuiltAt: s.builtAt}
+	if s.lastErr != nil {
+		status.Error = s.lastErr.Error()
+	}
+
+	return status
+}
+
+// Handler serves the endpoints of the server:
+//
+//	GET  /owners?path=a&path=b  the owners of the paths, like query --json
+//	GET  /healthz               200 as long as the process serves requests
+//	GET  /readyz                200 after the first successful build, 503 before
+//	POST /-/reload              rebuilds the matcher, 500 if that fails
+//	POST /-/webhook             triggers a build for GitHub push events
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/owners", s.handleOwners)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSONResponse(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		status := s.status()
+		code := http.StatusOK
+		if !status.Ready {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSONResponse(w, code, status)
+	})
+	mux.HandleFunc("/-/reload", s.handleReload)
+	if s.serverOpts.WebhookSecret != "" {
+		mux.HandleFunc("/-/webhook", s.handleWebhook)
+	}
+
+	return mux
+}
+
+// handleOwners resolves the owners of the path query parameters.
+func (s *Server) handleOwners(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+		return
+	}
+
+	paths := r.URL.Query()["path"]
+	if len(paths) == 0 {
+		writeJSONError(w, http.StatusBadRequest, "no path given")
+		return
+	}
+
+	s.mu.RLock()
+	matcher := s.matcher
+	s.mu.RUnlock()
+	if matcher == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, "the rules haven't been built yet")
+		return
+	}
+
+	results := make([]QueryResult, len(paths))
+	for i, path := range paths {
+		results[i] = QueryOwners(matcher, path)
+	}
+	writeJSONResponse(w, http.StatusOK, results)
+}
+
+// handleReload forces a rebuild of the matcher.
+func (s *Server) handleReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+		return
+	}
+
+	err := s.Reload(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "%s", err)
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, s.status())
+}
+
+// writeJSONResponse writes v as JSON response with the status code.
+func writeJSONResponse(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeJSONError writes an error response with the formatted message.
+func writeJSONError(w http.ResponseWriter, code int, format string, args ...interface{}) {
+	writeJSONResponse(w, code, map[string]string{"error": fmt.Sprintf(format, args...)})
+}
+
+// handleWebhook triggers a build for GitHub push events, whose signature in
+// the X-Hub-Signature-256 header must match the webhook secret. The build
+// runs in the background since GitHub only waits 10s for the response.
+func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBodySize))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "can't read body: %s", err)
+		return
+	}
+
+	if !validWebhookSignature([]byte(s.serverOpts.WebhookSecret), body, r.Header.Get("X-Hub-Signature-256")) {
+		writeJSONError(w, http.StatusUnauthorized, "invalid signature")
+		return
+	}
+
+	switch event := r.Header.Get("X-GitHub-Event"); event {
+	case "ping":
+		writeJSONResponse(w, http.StatusOK, map[string]string{"status": "pong"})
+	case "push":
+		s.Trigger()
+		writeJSONResponse(w, http.StatusAccepted, map[string]string{"status": "build triggered"})
+	default:
+		writeJSONResponse(w, http.StatusOK, map[string]string{"status": "ignored event " + event})
+	}
+}
+
+// maxWebhookBodySize bounds the webhook payloads, GitHub caps them at 25MB.
+const maxWebhookBodySize = 25 << 20
+
+// validWebhookSignature checks the "sha256=<hex HMAC>" signature of the body.
+func validWebhookSignature(secret, body []byte, signature string) bool {
+	const prefix = "sha256="
+	if !strings.HasPrefix(signature, prefix) {
+		return false
+	}
+
+	actual, err := hex.DecodeString(signature[len(prefix):])
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), actual)
+}
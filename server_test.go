@@ -0,0 +1,136 @@
+package codeowners
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	repoPath := t.TempDir()
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admin\n")
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/dev\n")
+
+	server := NewServer(repoPath, Options{}, ServerOptions{})
+	handler := server.Handler()
+	request := func(method, target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(method, target, nil))
+		return recorder
+	}
+
+	// Healthy but not ready before the first build
+	require.Equal(t, http.StatusOK, request(http.MethodGet, "/healthz").Code)
+	require.Equal(t, http.StatusServiceUnavailable, request(http.MethodGet, "/readyz").Code)
+	require.Equal(t, http.StatusServiceUnavailable, request(http.MethodGet, "/owners?path=README.md").Code)
+
+	require.Equal(t, http.StatusMethodNotAllowed, request(http.MethodGet, "/-/reload").Code)
+	require.Equal(t, http.StatusOK, request(http.MethodPost, "/-/reload").Code)
+	require.Equal(t, http.StatusOK, request(http.MethodGet, "/readyz").Code)
+
+	response := request(http.MethodGet, "/owners?path=README.md&path=src/main.go")
+	require.Equal(t, http.StatusOK, response.Code)
+	var results []QueryResult
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &results))
+	require.Len(t, results, 2)
+	require.Equal(t, []string{"@org/admin"}, results[0].Owners)
+	require.Equal(t, []string{"@org/dev"}, results[1].Owners)
+	require.Equal(t, http.StatusBadRequest, request(http.MethodGet, "/owners").Code)
+
+	// A failed reload keeps the previous rules
+	writeFile(t, repoPath, "src/CODEOWNERS", "@org/dev\n*.go @org/go # codeowners: priority=high\n")
+	require.Error(t, server.Reload(context.Background()))
+	require.Equal(t, http.StatusInternalServerError, request(http.MethodPost, "/-/reload").Code)
+	require.Equal(t, http.StatusOK, request(http.MethodGet, "/readyz").Code)
+	require.Equal(t, 2, server.status().Rules)
+	require.Contains(t, server.status().Error, "invalid priority")
+}
+
+func TestServerNeverOwned(t *testing.T) {
+	repoPath := t.TempDir()
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admin\n")
+	writeFile(t, repoPath, ConfigFileName, "policy:\n  never-owned:\n    - /gen/**\n")
+
+	server := NewServer(repoPath, Options{}, ServerOptions{})
+	require.NoError(t, server.Reload(context.Background()))
+
+	recorder := httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/owners?path=gen/api.go&path=main.go", nil))
+	var results []QueryResult
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &results))
+	require.Equal(t, []string{}, results[0].Owners)
+	require.Equal(t, []string{"@org/admin"}, results[1].Owners)
+}
+
+func TestServerSerializesReloads(t *testing.T) {
+	repoPath := t.TempDir()
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admin\n")
+
+	server := NewServer(repoPath, Options{}, ServerOptions{})
+
+	// A reload waits for the running build
+	server.buildMu.Lock()
+	done := make(chan error)
+	go func() { done <- server.Reload(context.Background()) }()
+	select {
+	case <-done:
+		t.Fatal("reload didn't wait for the running build")
+	case <-time.After(50 * time.Millisecond):
+	}
+	require.False(t, server.status().Ready)
+
+	server.buildMu.Unlock()
+	require.NoError(t, <-done)
+	require.True(t, server.status().Ready)
+}
+
+func TestServerWebhook(t *testing.T) {
+	repoPath := t.TempDir()
+	writeFile(t, repoPath, "CODEOWNERS", "@org/admin\n")
+
+	server := NewServer(repoPath, Options{}, ServerOptions{WebhookSecret: "secret"})
+	handler := server.Handler()
+	request := func(event, body, signature string) int {
+		r := httptest.NewRequest(http.MethodPost, "/-/webhook", strings.NewReader(body))
+		r.Header.Set("X-GitHub-Event", event)
+		r.Header.Set("X-Hub-Signature-256", signature)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, r)
+		return recorder.Code
+	}
+
+	body := `{"ref":"refs/heads/main"}`
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(body))
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	require.Equal(t, http.StatusUnauthorized, request("push", body, "sha256=00"))
+	require.Equal(t, http.StatusUnauthorized, request("push", body+" ", signature))
+	require.Equal(t, http.StatusOK, request("issues", body, signature))
+	require.Equal(t, http.StatusAccepted, request("push", body, signature))
+
+	// The push triggered a build, which Poll runs
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		server.Poll(ctx, 0)
+		close(done)
+	}()
+	require.Eventually(t, func() bool { return server.status().Ready }, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+
+	// Without secret there is no webhook
+	recorder := httptest.NewRecorder()
+	NewServer(repoPath, Options{}, ServerOptions{}).Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/-/webhook", nil))
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
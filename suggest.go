@@ -0,0 +1,62 @@
+package codeowners
+
+import (
+	"sort"
+	"strings"
+)
+
+// maxSuggestions is the number of known owners suggested for an unknown one.
+const maxSuggestions = 3
+
+// suggestOwners returns the known owners closest to owner by edit distance,
+// ignoring case, at most maxSuggestions in lexicographic order. Owners that
+// differ by more than a third of the length of owner aren't suggested, since
+// typos rarely change more.
+func suggestOwners(owner string, known []string) []string {
+	best := len(owner) / 3
+	if best < 1 {
+		best = 1
+	}
+
+	var suggestions []string
+	for _, k := range known {
+		d := editDistance(strings.ToLower(owner), strings.ToLower(k))
+		switch {
+		case d < best:
+			best = d
+			suggestions = []string{k}
+		case d == best && !ContainsString(suggestions, k):
+			suggestions = append(suggestions, k)
+		}
+	}
+
+	sort.Strings(suggestions)
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
+	}
+
+	return suggestions
+}
+
+// editDistance returns the Levenshtein distance between a and b in bytes.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, minInt(current[j-1]+1, previous[j-1]+cost))
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
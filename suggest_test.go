@@ -0,0 +1,20 @@
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuggestOwners(t *testing.T) {
+	require.Equal(t, 0, editDistance("", ""))
+	require.Equal(t, 3, editDistance("kitten", "sitting"))
+	require.Equal(t, 2, editDistance("@org/paymnets", "@org/payments"))
+
+	known := []string{"@org/payments", "@org/payment", "@org/platform", "@alice"}
+	require.Equal(t, []string{"@org/payments"}, suggestOwners("@org/paymnets", known))
+	require.Equal(t, []string{"@org/teama", "@org/teamb"}, suggestOwners("@org/team", []string{"@org/teamb", "@org/teama", "@org/other"}))
+	require.Equal(t, []string{"@org/payments"}, suggestOwners("@ORG/Payments", known))
+	require.Equal(t, []string{"@alice"}, suggestOwners("@alcie", known))
+	require.Empty(t, suggestOwners("@org/security", known))
+}
@@ -0,0 +1,195 @@
+package codeowners
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// suppressionPrefix starts the comments of nested CO files that suppress
+// findings, "# codeowners-lint: disable=CO001,CO004 reason=...". Like pragmas,
+// a suppression on a comment line applies to the whole file, in the trailing
+// comment of a rule only to the findings on that line.
+const suppressionPrefix = "codeowners-lint:"
+
+// Suppression is a suppression comment. Suppressions are listed in the reports
+// together with the number of findings they suppressed, so that they remain
+// visible and can be audited.
+type Suppression struct {
+	File string `json:"file"`
+
+	// Line is the line of the rule whose findings are suppressed, 0 if the
+	// findings of the whole file are suppressed.
+	Line int `json:"line,omitempty"`
+
+	// Checks are the IDs of the suppressed checks.
+	Checks []string `json:"checks"`
+	Reason string   `json:"reason,omitempty"`
+
+	// Suppressed is the number of suppressed findings.
+	Suppressed int `json:"suppressed"`
+}
+
+func (s Suppression) String() string {
+	location := s.File
+	if s.Line > 0 {
+		location = fmt.Sprintf("%s:%d", s.File, s.Line)
+	}
+
+	reason := ""
+	if s.Reason != "" {
+		reason = ": " + s.Reason
+	}
+
+	return fmt.Sprintf("%s: %s suppressed %s%s", location, strings.Join(s.Checks, ","), Pluralize(s.Suppressed, "finding"), reason)
+}
+
+// suppresses checks whether the suppression applies to the finding. Findings
+// about the whole repo can't be suppressed.
+func (s Suppression) suppresses(finding Finding) bool {
+	if finding.File != s.File || (s.Line > 0 && finding.Line != s.Line) {
+		return false
+	}
+
+	return ContainsString(s.Checks, finding.ID())
+}
+
+// FindSuppressions reads the suppressions of every nested CO file under root.
+// Suppressions of unknown checks are reported as findings.
+func FindSuppressions(ctx context.Context, root string) ([]Suppression, []Finding, error) {
+	var suppressions []Suppression
+	var findings []Finding
+
+	err := walkCodeownersFiles(ctx, root, func(coPath string) error {
+		source, err := relativeSourcePath(root, coPath)
+		if err != nil {
+			return err
+		}
+
+		lines, err := readCodeownersFile(coPath)
+		if err != nil {
+			return err
+		}
+
+		fileSuppressions, fileFindings := parseSuppressions(source, lines)
+		suppressions = append(suppressions, fileSuppressions...)
+		findings = append(findings, fileFindings...)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while reading suppressions: %w", err)
+	}
+
+	return suppressions, findings, nil
+}
+
+// parseSuppressions parses the suppression comments in the lines of the CO
+// file source.
+func parseSuppressions(source string, lines []string) ([]Suppression, []Finding) {
+	var suppressions []Suppression
+	var findings []Finding
+	for i, line := range lines {
+		suppressionLine := 0
+		var comment string
+		var commentColumn int
+		if strings.HasPrefix(strings.TrimSpace(line), codeownersCommentPrefix) {
+			commentColumn = strings.Index(line, codeownersCommentPrefix) + 2
+			comment = line[commentColumn-1:]
+		} else if tokens, trailing := tokenizeCodeownersRule(line); trailing != "" {
+			suppressionLine = i + 1
+			commentColumn = tokenColumns(line)[len(tokens)] + 1
+			comment = line[commentColumn-1:]
+		}
+
+		trimmed := strings.TrimSpace(comment)
+		if !strings.HasPrefix(trimmed, suppressionPrefix) {
+			continue
+		}
+
+		invalid := func(message string) {
+			findings = append(findings, Finding{
+				Check:    "invalid-suppression",
+				Severity: SeverityError,
+				Message:  message,
+				File:     source,
+				Line:     i + 1,
+				Column:   commentColumn + len(comment) - len(strings.TrimLeft(comment, " \t")),
+			})
+		}
+
+		checks, reason := parseSuppression(strings.TrimPrefix(trimmed, suppressionPrefix))
+		if len(checks) == 0 {
+			invalid("suppression doesn't disable any checks, expected disable=ID,...")
+			continue
+		}
+
+		suppression := Suppression{File: source, Line: suppressionLine, Reason: reason}
+		for _, check := range checks {
+			id, ok := lookupCheckID(check)
+			if !ok {
+				invalid(fmt.Sprintf("suppression of unknown check %s", check))
+				continue
+			}
+			if !ContainsString(suppression.Checks, id) {
+				suppression.Checks = append(suppression.Checks, id)
+			}
+		}
+
+		if len(suppression.Checks) > 0 {
+			suppressions = append(suppressions, suppression)
+		}
+	}
+
+	return suppressions, findings
+}
+
+// parseSuppression parses the "disable=ID,... reason=..." part of a
+// suppression comment. The reason extends to the end of the comment.
+func parseSuppression(s string) ([]string, string) {
+	var checks []string
+	var reason string
+
+	fields := strings.Fields(s)
+	for i, field := range fields {
+		if strings.HasPrefix(field, "reason=") {
+			reason = strings.TrimPrefix(strings.Join(fields[i:], " "), "reason=")
+			break
+		}
+
+		if strings.HasPrefix(field, "disable=") {
+			for _, check := range strings.Split(strings.TrimPrefix(field, "disable="), ",") {
+				if check != "" {
+					checks = append(checks, check)
+				}
+			}
+		}
+	}
+
+	return checks, reason
+}
+
+// ApplySuppressions removes the suppressed findings and counts them in the
+// suppressions.
+func ApplySuppressions(findings []Finding, suppressions []Suppression) []Finding {
+	if len(suppressions) == 0 {
+		return findings
+	}
+
+	var kept []Finding
+	for _, finding := range findings {
+		suppressed := false
+		for i := range suppressions {
+			if suppressions[i].suppresses(finding) {
+				suppressions[i].Suppressed++
+				suppressed = true
+				break
+			}
+		}
+
+		if !suppressed {
+			kept = append(kept, finding)
+		}
+	}
+
+	return kept
+}
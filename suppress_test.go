@@ -0,0 +1,42 @@
+package codeowners
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuppressions(t *testing.T) {
+	repoPath := t.TempDir()
+
+	writeFile(t, repoPath, "CODEOWNERS", "* @org/admins\n")
+	writeFile(t, repoPath, "src/CODEOWNERS", "# codeowners-lint: disable=require-teams reason=Contractors have no team yet\n@org/dev @someone\nlib.go @org/lib # codeowners-lint: disable=co001 reason=Moved in the next release\nold.go @org/lib\n")
+	writeFile(t, repoPath, "docs/CODEOWNERS", "# codeowners-lint: disable=CO999,CO001\n@docs\n")
+	writeFile(t, repoPath, "src/main.go", "")
+	writeFile(t, repoPath, "docs/guide.md", "")
+
+	report, err := Audit(context.Background(), repoPath, Config{Policy: PolicyConfig{RequireTeams: true}})
+	require.NoError(t, err)
+
+	var findings []string
+	for _, finding := range report.Findings {
+		findings = append(findings, finding.String())
+	}
+	require.Equal(t, []string{
+		"docs/CODEOWNERS:1:3: error: suppression of unknown check CO999 [CO030 invalid-suppression]",
+		"docs/CODEOWNERS:2: error: owner @docs of /docs is not a team [CO006 require-teams]",
+		"src/CODEOWNERS:4: warning: pattern /src/old.go doesn't match any file [CO001 stale-pattern]",
+	}, findings)
+
+	require.Equal(t, []Suppression{
+		{File: "docs/CODEOWNERS", Checks: []string{"CO001"}, Suppressed: 0},
+		{File: "src/CODEOWNERS", Checks: []string{"CO006"}, Reason: "Contractors have no team yet", Suppressed: 1},
+		{File: "src/CODEOWNERS", Line: 3, Checks: []string{"CO001"}, Reason: "Moved in the next release", Suppressed: 1},
+	}, report.Suppressions)
+	require.Equal(t, "src/CODEOWNERS:3: CO001 suppressed 1 finding: Moved in the next release", report.Suppressions[2].String())
+
+	_, invalid := parseSuppressions("CODEOWNERS", []string{"# codeowners-lint: reason=nothing"})
+	require.Len(t, invalid, 1)
+	require.Equal(t, "suppression doesn't disable any checks, expected disable=ID,...", invalid[0].Message)
+}
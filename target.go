@@ -0,0 +1,120 @@
+package codeowners
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Output targets of the generated file.
+const (
+	TargetGitHub    = "github"
+	TargetGitLab    = "gitlab"
+	TargetBitbucket = "bitbucket"
+	TargetGitea     = "gitea"
+)
+
+// Targets are all supported output targets.
+var Targets = []string{TargetGitHub, TargetGitLab, TargetBitbucket, TargetGitea}
+
+// targetFileNames are the paths relative to the root the generated file is
+// written to by --commit and --append, for the targets that support them.
+var targetFileNames = map[string]string{
+	TargetGitHub: GeneratedFileName,
+	TargetGitLab: DefaultGitLabFileName,
+	TargetGitea:  ".gitea/CODEOWNERS",
+}
+
+// DefaultGitLabFileName is the default of the GitLab locations, the only one
+// that can't collide with a nested CO file.
+const DefaultGitLabFileName = ".gitlab/CODEOWNERS"
+
+// GitLabFileNames are the locations GitLab reads the CO file from, in the
+// order it looks for them. GitLab only uses the first file it finds.
+var GitLabFileNames = []string{codeownersFileName, "docs/CODEOWNERS", DefaultGitLabFileName}
+
+// TargetFileName returns the path relative to the root the generated file of
+// the target is written to and whether the target supports writing it.
+// gitLabFileName selects one of the GitLab locations.
+func TargetFileName(target, gitLabFileName string) (string, bool) {
+	if target == TargetGitLab && gitLabFileName != "" {
+		return gitLabFileName, true
+	}
+
+	name, ok := targetFileNames[target]
+	return name, ok
+}
+
+// ValidGitLabFileName checks whether name is one of the GitLab locations.
+func ValidGitLabFileName(name string) error {
+	if ContainsString(GitLabFileNames, name) {
+		return nil
+	}
+
+	return fmt.Errorf("unknown GitLab location %s, expected one of %s", name, strings.Join(GitLabFileNames, ", "))
+}
+
+// CheckGitLabLocation checks that the generated file at name is the only CO
+// file at one of the GitLab locations in root. Otherwise GitLab might use
+// another one, e.g. a nested CO file in the root dir.
+func CheckGitLabLocation(root, name string) error {
+	for _, other := range GitLabFileNames {
+		if other == name {
+			continue
+		}
+
+		_, err := os.Lstat(filepath.Join(root, filepath.FromSlash(other)))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("can't check GitLab location %s: %w", other, err)
+		}
+
+		return fmt.Errorf("GitLab uses only one of %s, but %s exists besides the generated %s", strings.Join(GitLabFileNames, ", "), other, name)
+	}
+
+	return nil
+}
+
+// isGeneratedFile checks whether path is a generated file of any target. Such
+// files are never processed as nested CO files. Generated files at locations
+// of nested CO files are skipped through Options.Output instead.
+func isGeneratedFile(path string) bool {
+	path = filepath.ToSlash(path)
+	for _, name := range targetFileNames {
+		if strings.HasSuffix(path, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TargetRules converts the rules to the syntax of the target.
+func TargetRules(rules []Rule, target string) []Rule {
+	switch target {
+	case TargetGitLab:
+		return groupBySection(rules)
+	case TargetBitbucket:
+		return bitbucketRules(rules)
+	case TargetGitea:
+		return giteaRules(rules)
+	}
+
+	return rules
+}
+
+// ValidTarget checks whether target is a supported output target.
+func ValidTarget(target string) error {
+	for _, t := range Targets {
+		if t == target {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown target %s, expected one of %s", target, strings.Join(Targets, ", "))
+}
@@ -0,0 +1,90 @@
+package codeowners
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// TemplateData is the data passed to a user-supplied output template.
+type TemplateData struct {
+	// Header is the header comment block as it would be generated by
+	// GenerateCodeownersFile, empty if it is disabled.
+	Header string
+
+	// Rules are the rewritten rules, including their provenance.
+	Rules []Rule
+
+	// Stats summarizes the rules.
+	Stats Stats
+
+	// Metadata describes the generation run, nil if not requested.
+	Metadata *Metadata
+}
+
+// Stats summarizes a set of rewritten rules.
+type Stats struct {
+	Rules       int
+	SourceFiles int
+	Owners      int
+}
+
+// computeStats counts the rules and the distinct source files and owners
+// referenced by them.
+func computeStats(rules []Rule) Stats {
+	sources := map[string]bool{}
+	owners := map[string]bool{}
+	for _, rule := range rules {
+		sources[rule.Source] = true
+		for _, owner := range rule.Owners {
+			owners[owner] = true
+		}
+	}
+
+	return Stats{
+		Rules:       len(rules),
+		SourceFiles: len(sources),
+		Owners:      len(owners),
+	}
+}
+
+// templateFuncs are the functions available in output templates in addition
+// to the text/template builtins.
+var templateFuncs = template.FuncMap{
+	"join":       strings.Join,
+	"hasPrefix":  strings.HasPrefix,
+	"trimPrefix": strings.TrimPrefix,
+	"replace":    strings.ReplaceAll,
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+}
+
+// ParseOutputTemplate parses a text/template for rendering the output with
+// RenderTemplate.
+func ParseOutputTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse template %s: %w", name, err)
+	}
+
+	return tmpl, nil
+}
+
+// RenderTemplate renders the rules with a template parsed by
+// ParseOutputTemplate into w.
+func RenderTemplate(w io.Writer, tmpl *template.Template, rules []Rule, opts GenerateOptions) error {
+	data := TemplateData{
+		Header:   generateHeader(opts),
+		Rules:    rules,
+		Stats:    computeStats(rules),
+		Metadata: opts.Metadata,
+	}
+
+	err := tmpl.Execute(w, data)
+	if err != nil {
+		return fmt.Errorf("can't render template %s: %w", tmpl.Name(), err)
+	}
+
+	return nil
+}
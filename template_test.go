@@ -0,0 +1,28 @@
+package codeowners
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	rules := []Rule{
+		{Pattern: "*", Owners: []string{"@org/admin"}, Source: "CODEOWNERS", Line: 2},
+		{Pattern: "/src", Owners: []string{"@org/user", "@org/admin"}, Source: "src/CODEOWNERS", Line: 1},
+	}
+
+	tmpl, err := ParseOutputTemplate("test", `{{ .Stats.Rules }} rules from {{ .Stats.SourceFiles }} files, {{ .Stats.Owners }} owners
+{{ range .Rules }}{{ .Pattern }}: {{ join .Owners "," }} ({{ .Location }})
+{{ end }}`)
+	require.NoError(t, err)
+
+	var out strings.Builder
+	err = RenderTemplate(&out, tmpl, rules, GenerateOptions{})
+	require.NoError(t, err)
+	require.Equal(t, `2 rules from 2 files, 2 owners
+*: @org/admin (CODEOWNERS:2)
+/src: @org/user,@org/admin (src/CODEOWNERS:1)
+`, out.String())
+}
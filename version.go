@@ -0,0 +1,21 @@
+package codeowners
+
+import "runtime/debug"
+
+// version is the tool version, it is set at build time via
+// -ldflags "-X github.com/gmolau/codeowners.version=v1.2.3".
+var version = ""
+
+// ToolVersion returns the version of this build. Falls back to the module
+// version recorded by the Go toolchain and "dev" for local builds.
+func ToolVersion() string {
+	if version != "" {
+		return version
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+
+	return "dev"
+}
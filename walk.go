@@ -0,0 +1,115 @@
+package codeowners
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/denormal/go-gitignore"
+)
+
+// WalkCodeownersFn gets the slash separated path of a CO file in the walked
+// file system and its rules, rewritten relative to the root of the walk.
+type WalkCodeownersFn = func(path string, rules []Rule) error
+
+// WalkCodeowners walks the dirs under root in fsys in the same BFS and
+// lexicographic order as RewriteCodeownersRules, skipping dirs ignored by
+// .gitignore files and the generated files, and calls fn with the rules of
+// every CO file. It allows custom aggregations without reimplementing the
+// walk, e.g. WalkCodeowners(os.DirFS(repo), ".", fn). An error returned by fn
+// stops the walk and is returned.
+func WalkCodeowners(fsys fs.FS, root string, fn WalkCodeownersFn) error {
+	type dirIgnore struct {
+		dir    string
+		ignore gitignore.GitIgnore
+	}
+
+	var ignores []dirIgnore
+	ignored := func(dir string) bool {
+		if path.Base(dir) == ".git" {
+			return true
+		}
+
+		for _, i := range ignores {
+			if rel := strings.TrimPrefix(dir, i.dir+"/"); rel != dir || i.dir == "." {
+				if match := i.ignore.Relative(rel, true); match != nil && match.Ignore() {
+					return true
+				}
+			}
+		}
+
+		return false
+	}
+
+	dirQueue := newStringQueue()
+	dirQueue.Enqueue(root)
+
+	for dirQueue.Len() > 0 {
+		currentDir := dirQueue.Dequeue()
+		if currentDir != root && ignored(currentDir) {
+			continue
+		}
+
+		// fs.ReadDir returns the entries in lexicographic order
+		dirEntries, err := fs.ReadDir(fsys, currentDir)
+		if err != nil {
+			return fmt.Errorf("error while reading dir %s: %w", currentDir, err)
+		}
+
+		for _, dirEntry := range dirEntries {
+			if dirEntry.Name() != ".gitignore" || dirEntry.IsDir() {
+				continue
+			}
+
+			content, err := fs.ReadFile(fsys, path.Join(currentDir, dirEntry.Name()))
+			if err == nil { // Ignore errors as ignore is an optional feature
+				ignores = append(ignores, dirIgnore{dir: currentDir, ignore: gitignore.New(bytes.NewReader(content), currentDir, nil)})
+			}
+		}
+
+		for _, dirEntry := range dirEntries {
+			entryPath := path.Join(currentDir, dirEntry.Name())
+			if dirEntry.IsDir() {
+				dirQueue.Enqueue(entryPath)
+				continue
+			}
+
+			source := entryPath
+			if root != "." {
+				source = strings.TrimPrefix(entryPath, root+"/")
+			}
+			if !isCodeownersFile(dirEntry) || isGeneratedFile(source) {
+				continue
+			}
+
+			content, err := fs.ReadFile(fsys, entryPath)
+			if err != nil {
+				return fmt.Errorf("error while reading %s: %w", entryPath, err)
+			}
+
+			err = checkFileSize(source, int64(len(content)), Options{})
+			if err != nil {
+				return err
+			}
+
+			lines, err := splitCodeownersContent(source, content)
+			if err != nil {
+				return err
+			}
+
+			rules, err := processCodeownersLines(source, path.Join("/", path.Dir(source)), lines, Options{})
+			if err != nil {
+				return err
+			}
+
+			err = fn(entryPath, rules)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package codeowners
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalkCodeowners(t *testing.T) {
+	fsys := fstest.MapFS{
+		"repo/CODEOWNERS":            {Data: []byte("@org/admin\n")},
+		"repo/.gitignore":            {Data: []byte("build/\n")},
+		"repo/.github/CODEOWNERS":    {Data: []byte("* @org/generated\n")},
+		"repo/src/CODEOWNERS":        {Data: []byte("@org/dev\n*.md @org/docs\n")},
+		"repo/src/api/CODEOWNERS":    {Data: []byte("@org/api\n")},
+		"repo/build/CODEOWNERS":      {Data: []byte("@org/ignored\n")},
+		"repo/docs/.gitignore":       {Data: []byte("tmp\n")},
+		"repo/docs/tmp/CODEOWNERS":   {Data: []byte("@org/ignored\n")},
+		"repo/docs/guide/CODEOWNERS": {Data: []byte("@org/docs\n")},
+		"repo/docs/guide/README.md":  {Data: []byte("# Guide\n")},
+		"other/CODEOWNERS":           {Data: []byte("@org/other\n")},
+	}
+
+	var paths []string
+	var rules []Rule
+	err := WalkCodeowners(fsys, "repo", func(path string, fileRules []Rule) error {
+		paths = append(paths, path)
+		rules = append(rules, fileRules...)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"repo/CODEOWNERS", "repo/src/CODEOWNERS", "repo/docs/guide/CODEOWNERS", "repo/src/api/CODEOWNERS"}, paths)
+	require.Equal(t, []string{"* @org/admin", "/src @org/dev", "/src/*.md @org/docs", "/docs/guide @org/docs", "/src/api @org/api"}, ruleStrings(rules))
+	require.Equal(t, "src/CODEOWNERS:2", rules[2].Location())
+
+	stop := errors.New("stop")
+	err = WalkCodeowners(fsys, "repo", func(string, []Rule) error { return stop })
+	require.ErrorIs(t, err, stop)
+}